@@ -0,0 +1,310 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/term"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/auth"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+)
+
+// cmdAuth implements `bridge auth export|import` for moving tokens to a
+// headless machine without a second browser flow, and `backup|restore`
+// for migrating the whole bridge identity to a new machine.
+func cmdAuth(cfg *config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "bridge: usage: bridge auth <export|import|backup|restore> [flags]")
+		return 2
+	}
+	switch args[0] {
+	case "export":
+		return authExport(cfg, args[1:])
+	case "import":
+		return authImport(cfg, args[1:])
+	case "backup":
+		return authBackup(cfg, args[1:])
+	case "restore":
+		return authRestore(cfg, args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "bridge: unknown auth subcommand %q\n", args[0])
+		return 2
+	}
+}
+
+// readPassphrase prompts on the terminal, falling back to the
+// BRIDGE_PASSPHRASE environment variable for non-interactive use.
+func readPassphrase(confirm bool) (string, error) {
+	if p := os.Getenv("BRIDGE_PASSPHRASE"); p != "" {
+		return p, nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("no terminal for passphrase prompt; set BRIDGE_PASSPHRASE")
+	}
+	fmt.Fprint(os.Stderr, "Passphrase: ")
+	p1, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if confirm {
+		fmt.Fprint(os.Stderr, "Confirm passphrase: ")
+		p2, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		if string(p1) != string(p2) {
+			return "", fmt.Errorf("passphrases do not match")
+		}
+	}
+	return string(p1), nil
+}
+
+func authExport(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("auth export", flag.ContinueOnError)
+	file := fs.String("file", "", "output file (default: stdout)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	dir, err := config.Dir()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	tokens, err := auth.NewTokenStore(dir).Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	if tokens == nil {
+		fmt.Fprintln(os.Stderr, "bridge: no stored tokens; run `bridge login` first")
+		return 1
+	}
+	if !tokens.Valid() && tokens.RefreshToken == "" {
+		fmt.Fprintln(os.Stderr, "bridge: warning: exported token is already expired and has no refresh token")
+	} else if until := time.Until(tokens.ExpiresAt); until < time.Hour && tokens.RefreshToken == "" {
+		fmt.Fprintf(os.Stderr, "bridge: warning: exported token expires in %s\n", until.Round(time.Minute))
+	}
+
+	passphrase, err := readPassphrase(true)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	data, err := auth.EncryptBundle(tokens, passphrase)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	if *file == "" {
+		fmt.Println(string(data))
+		return 0
+	}
+	if err := os.WriteFile(*file, data, 0o600); err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	fmt.Printf("Tokens exported to %s\n", *file)
+	return 0
+}
+
+func authImport(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("auth import", flag.ContinueOnError)
+	file := fs.String("file", "", "input file (default: stdin)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	var data []byte
+	var err error
+	if *file == "" || *file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(*file)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	passphrase, err := readPassphrase(false)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	tokens, err := auth.DecryptBundle(data, passphrase)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	if !tokens.Valid() {
+		if tokens.RefreshToken != "" {
+			fmt.Fprintln(os.Stderr, "bridge: note: access token expired; it will be refreshed on first use")
+		} else {
+			fmt.Fprintln(os.Stderr, "bridge: warning: imported token is expired and has no refresh token")
+		}
+	}
+	dir, err := config.Dir()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	if err := auth.NewTokenStore(dir).Save(tokens); err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	fmt.Println("Tokens imported.")
+	return 0
+}
+
+// backupFiles names the identity files swept into a backup, relative to
+// the config or data directory.
+var backupFiles = []string{"bridge.yaml", "transcripts.key"}
+
+func authBackup(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("auth backup", flag.ContinueOnError)
+	file := fs.String("file", "", "output file (default: stdout)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	dir, err := config.Dir()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	tokens, err := auth.NewTokenStore(dir).Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	if tokens == nil {
+		fmt.Fprintln(os.Stderr, "bridge: no stored tokens; run `bridge login` first")
+		return 1
+	}
+
+	host, _ := os.Hostname()
+	backup := &auth.Backup{
+		Created: time.Now(),
+		Host:    host,
+		Tokens:  tokens,
+		Files:   map[string][]byte{},
+	}
+	dirs := []string{dir}
+	if dataDir, err := cfg.DataDir(); err == nil && dataDir != dir {
+		dirs = append(dirs, dataDir)
+	}
+	for _, d := range dirs {
+		for _, name := range backupFiles {
+			if data, err := os.ReadFile(filepath.Join(d, name)); err == nil {
+				backup.Files[name] = data
+			}
+		}
+		// Per-audience token entries created by `For`.
+		matches, _ := filepath.Glob(filepath.Join(d, "tokens-*.json"))
+		for _, m := range matches {
+			if data, err := os.ReadFile(m); err == nil {
+				backup.Files[filepath.Base(m)] = data
+			}
+		}
+	}
+
+	passphrase, err := readPassphrase(true)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	data, err := auth.EncryptBackup(backup, passphrase)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	if *file == "" {
+		fmt.Println(string(data))
+		return 0
+	}
+	if err := os.WriteFile(*file, data, 0o600); err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	fmt.Printf("Backup written to %s (%d identity files)\n", *file, len(backup.Files))
+	return 0
+}
+
+func authRestore(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("auth restore", flag.ContinueOnError)
+	file := fs.String("file", "", "input file (default: stdin)")
+	force := fs.Bool("force", false, "overwrite identity files that already exist")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	var data []byte
+	var err error
+	if *file == "" || *file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(*file)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	passphrase, err := readPassphrase(false)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	backup, err := auth.DecryptBackup(data, passphrase)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	dir, err := config.Dir()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	if backup.Tokens == nil {
+		fmt.Fprintln(os.Stderr, "bridge: backup holds no tokens")
+		return 1
+	}
+	if err := auth.NewTokenStore(dir).Save(backup.Tokens); err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	restored := 0
+	for name, contents := range backup.Files {
+		// Names come from an encrypted backup the user made, but stay
+		// defensive: never write outside the config directory.
+		if filepath.Base(name) != name {
+			fmt.Fprintf(os.Stderr, "bridge: skipping suspicious backup entry %q\n", name)
+			continue
+		}
+		target := dir
+		if name == "transcripts.key" {
+			if dataDir, err := cfg.DataDir(); err == nil {
+				target = dataDir
+			}
+		}
+		path := filepath.Join(target, name)
+		if _, err := os.Stat(path); err == nil && !*force {
+			fmt.Fprintf(os.Stderr, "bridge: %s already exists; use -force to overwrite\n", path)
+			continue
+		}
+		if err := os.WriteFile(path, contents, 0o600); err != nil {
+			fmt.Fprintln(os.Stderr, "bridge:", err)
+			return 1
+		}
+		restored++
+	}
+	from := backup.Host
+	if from == "" {
+		from = "another machine"
+	}
+	fmt.Printf("Restored tokens and %d identity files from %s (backup taken %s).\n",
+		restored, from, backup.Created.Format("2006-01-02"))
+	return 0
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/auth"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/hooks"
+)
+
+// cmdLogin runs the browser PKCE flow and stores the resulting tokens.
+// With -from-app it instead waits for the already-authenticated desktop
+// app to hand its tokens over loopback, skipping the second browser flow.
+func cmdLogin(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	fromApp := fs.Bool("from-app", false, "receive tokens from the CloudToLocalLLM desktop app instead of the browser")
+	fs.Parse(args)
+
+	dir, err := config.Dir()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	var tokens *auth.Tokens
+	if *fromApp {
+		tokens, err = auth.ReceiveHandoff(ctx, func(code, endpoint string) {
+			fmt.Printf("Pairing code: %s\n", code)
+			fmt.Println("In the CloudToLocalLLM app, open Settings > Bridge > Link bridge and enter the code.")
+		})
+	} else {
+		flow := &auth.Flow{
+			Domain:   cfg.Cloud.Auth0Domain,
+			ClientID: cfg.Cloud.Auth0ClientID,
+			Audience: cfg.Cloud.Auth0Audience,
+		}
+		tokens, err = flow.Login(ctx)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge: login failed:", err)
+		return 1
+	}
+	if err := auth.NewTokenStore(dir).Save(tokens); err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	hooks.Set(cfg.Hooks)
+	hooks.Login(tokens.ExpiresAt)
+	fmt.Println("Login successful.")
+	return 0
+}
+
+// cmdLogout removes stored tokens, including per-audience entries.
+func cmdLogout(cfg *config.Config) int {
+	dir, err := config.Dir()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	if err := auth.NewTokenStore(dir).ClearAll(); err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	hooks.Set(cfg.Hooks)
+	hooks.Logout()
+	fmt.Println("Logged out.")
+	return 0
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/auth"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/control"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/doctor"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/logging"
+)
+
+// recentBridgeLogs fetches the running bridge's log tail from the local
+// status API; nil when no bridge is up.
+func recentBridgeLogs(port int) []logging.Entry {
+	client := &http.Client{Timeout: 3 * time.Second}
+	resp, err := client.Get(fmt.Sprintf("http://localhost:%d/status/logs", port))
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Entries []logging.Entry `json:"entries"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil
+	}
+	return body.Entries
+}
+
+// cmdDoctor runs the diagnostic battery and prints pass/fail per check.
+func cmdDoctor(cfg *config.Config) int {
+	dir, err := config.Dir()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	results := doctor.Run(ctx, cfg, auth.NewTokenStore(dir))
+	failed := 0
+	for _, r := range results {
+		if !r.OK {
+			failed++
+		}
+	}
+	if outputJSON {
+		code := printJSON(struct {
+			Checks []doctor.Result `json:"checks"`
+			Failed int             `json:"failed"`
+			// RecentLogs is the running bridge's in-memory log tail, so a
+			// single doctor invocation yields a complete support bundle.
+			RecentLogs []logging.Entry `json:"recentLogs,omitempty"`
+		}{results, failed, recentBridgeLogs(control.ActivePort(cfg))})
+		if code == 0 && failed > 0 {
+			code = 1
+		}
+		return code
+	}
+	failed = 0
+	for _, r := range results {
+		mark := "PASS"
+		if !r.OK {
+			mark = "FAIL"
+			failed++
+		}
+		fmt.Printf("[%s] %-16s %s\n", mark, r.Name, r.Detail)
+		if !r.OK && r.Hint != "" {
+			fmt.Printf("       %-16s hint: %s\n", "", r.Hint)
+		}
+	}
+	if failed > 0 {
+		fmt.Printf("\n%d of %d checks failed.\n", failed, len(results))
+		return 1
+	}
+	fmt.Printf("\nAll %d checks passed.\n", len(results))
+	return 0
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/filter"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/ollama"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/quota"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/tunnel"
+)
+
+// cmdExec routes a single request through the same backend/policy
+// pipeline cloud traffic takes — route rules, content filter, limits,
+// quotas — without the relay, and prints the response body. Useful for
+// verifying routing rules and policies locally.
+func cmdExec(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("exec", flag.ExitOnError)
+	path := fs.String("path", "", "request path, e.g. /api/generate (required)")
+	method := fs.String("method", "POST", "HTTP method")
+	data := fs.String("data", "", "request body; @file reads the body from a file, - from stdin")
+	user := fs.String("user", "", "cloud user ID to attribute the request to, for quota rules")
+	fs.Parse(args)
+	if *path == "" {
+		fmt.Fprintln(os.Stderr, "bridge: exec requires --path")
+		return 2
+	}
+
+	body, err := readData(*data)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+
+	engine, err := filter.New(cfg.Filter)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	oc := ollama.NewTunedClient(cfg.OllamaBaseURL(), cfg.Ollama.Timeout, ollama.TransportOptions{
+		MaxIdleConns:    cfg.Ollama.MaxIdleConns,
+		IdleConnTimeout: cfg.Ollama.IdleConnTimeout,
+		ForceHTTP2:      cfg.Ollama.HTTP2,
+	})
+	t := tunnel.New(cfg, oc, engine, quota.NewManager(cfg.Quotas), func() (string, error) {
+		return "", fmt.Errorf("exec mode has no relay connection")
+	})
+
+	resp, err := t.Exec(context.Background(), &tunnel.RequestData{
+		Method: *method,
+		Path:   *path,
+		Body:   body,
+		UserID: *user,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	out := []byte(resp.Body)
+	if resp.BodyEncoding == "base64" {
+		if decoded, err := base64.StdEncoding.DecodeString(resp.Body); err == nil {
+			out = decoded
+		}
+	}
+	os.Stdout.Write(out)
+	if len(out) > 0 && out[len(out)-1] != '\n' {
+		fmt.Println()
+	}
+	if resp.StatusCode >= 400 {
+		fmt.Fprintf(os.Stderr, "bridge: request failed with status %d\n", resp.StatusCode)
+		return 1
+	}
+	return 0
+}
+
+// readData resolves a curl-style --data value: literal text, @file, or
+// - for stdin.
+func readData(data string) (string, error) {
+	switch {
+	case data == "":
+		return "", nil
+	case data == "-":
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("reading body from stdin: %w", err)
+		}
+		return string(b), nil
+	case strings.HasPrefix(data, "@"):
+		b, err := os.ReadFile(data[1:])
+		if err != nil {
+			return "", fmt.Errorf("reading body file: %w", err)
+		}
+		return string(b), nil
+	default:
+		return data, nil
+	}
+}
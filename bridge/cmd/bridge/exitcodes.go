@@ -0,0 +1,17 @@
+package main
+
+// Exit codes for supervisors and monitoring to distinguish failure
+// causes. 1 remains the generic failure and 2 doubles as the usage
+// error code the flag package already uses.
+const (
+	exitFailure = 1
+	// exitConfig: the configuration is missing or invalid.
+	exitConfig = 2
+	// exitAuth: no usable credentials; run `bridge login`.
+	exitAuth = 3
+	// exitRelay: the cloud relay is unreachable (with run --exit-on-error).
+	exitRelay = 4
+	// exitOllama: the local Ollama server is unreachable (with run
+	// --exit-on-error).
+	exitOllama = 5
+)
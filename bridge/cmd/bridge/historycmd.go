@@ -0,0 +1,173 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/history"
+)
+
+// historyDBPath returns the history database location for this config.
+func historyDBPath(cfg *config.Config) (string, error) {
+	dir, err := cfg.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history.db"), nil
+}
+
+// cmdHistory implements `bridge history list|show|export`.
+func cmdHistory(cfg *config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "bridge: usage: bridge history <list|show|export> [flags]")
+		return 2
+	}
+	path, err := historyDBPath(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	store, err := history.Open(path, cfg.History.MaxEntries)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	defer store.Close()
+
+	switch args[0] {
+	case "list":
+		return historyList(store, args[1:])
+	case "show":
+		return historyShow(store, args[1:])
+	case "export":
+		return historyExport(store, args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "bridge: unknown history subcommand %q\n", args[0])
+		return 2
+	}
+}
+
+// parseHistoryFilter parses the shared --since/--model/--status/--limit
+// flags into a history.Filter.
+func parseHistoryFilter(fs *flag.FlagSet, args []string) (history.Filter, error) {
+	since := fs.String("since", "", "only entries newer than this duration (e.g. 24h) or RFC3339 time")
+	model := fs.String("model", "", "only entries for this model")
+	status := fs.Int("status", 0, "only entries with this HTTP status code")
+	limit := fs.Int("limit", 50, "maximum entries to return (0 = all)")
+	if err := fs.Parse(args); err != nil {
+		return history.Filter{}, err
+	}
+	f := history.Filter{Model: *model, Status: *status, Limit: *limit}
+	if *since != "" {
+		if d, err := time.ParseDuration(*since); err == nil {
+			f.Since = time.Now().Add(-d)
+		} else if ts, err := time.Parse(time.RFC3339, *since); err == nil {
+			f.Since = ts
+		} else {
+			return history.Filter{}, fmt.Errorf("invalid --since value %q", *since)
+		}
+	}
+	return f, nil
+}
+
+func historyList(store *history.Store, args []string) int {
+	fs := flag.NewFlagSet("history list", flag.ContinueOnError)
+	f, err := parseHistoryFilter(fs, args)
+	if err != nil {
+		return 2
+	}
+	entries, err := store.List(f)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	if outputJSON {
+		if entries == nil {
+			entries = []history.Entry{}
+		}
+		return printJSON(entries)
+	}
+	if len(entries) == 0 {
+		fmt.Println("No matching history entries.")
+		return 0
+	}
+	fmt.Printf("%-36s  %-20s  %-6s  %-24s  %-16s  %6s  %8s\n",
+		"ID", "TIME", "METHOD", "PATH", "MODEL", "STATUS", "DURATION")
+	for _, e := range entries {
+		fmt.Printf("%-36s  %-20s  %-6s  %-24s  %-16s  %6d  %7dms\n",
+			e.ID, e.Timestamp.Local().Format("2006-01-02 15:04:05"),
+			e.Method, e.Path, e.Model, e.StatusCode, e.DurationMS)
+	}
+	return 0
+}
+
+func historyShow(store *history.Store, args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "bridge: usage: bridge history show <id>")
+		return 2
+	}
+	e, err := store.Get(args[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	if outputJSON {
+		return printJSON(e)
+	}
+	fmt.Printf("ID:        %s\n", e.ID)
+	fmt.Printf("Time:      %s\n", e.Timestamp.Local().Format(time.RFC3339))
+	fmt.Printf("Method:    %s\n", e.Method)
+	fmt.Printf("Path:      %s\n", e.Path)
+	if e.Model != "" {
+		fmt.Printf("Model:     %s\n", e.Model)
+	}
+	if e.UserID != "" {
+		fmt.Printf("User:      %s\n", e.UserID)
+	}
+	fmt.Printf("Status:    %d\n", e.StatusCode)
+	fmt.Printf("Duration:  %dms\n", e.DurationMS)
+	if e.Body != "" {
+		fmt.Printf("Body:\n%s\n", e.Body)
+	}
+	return 0
+}
+
+func historyExport(store *history.Store, args []string) int {
+	fs := flag.NewFlagSet("history export", flag.ContinueOnError)
+	format := fs.String("format", "csv", "export format: csv or jsonl")
+	out := fs.String("file", "", "output file (default: stdout)")
+	f, err := parseHistoryFilter(fs, args)
+	if err != nil {
+		return 2
+	}
+	f.Limit = 0
+
+	w := os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "bridge:", err)
+			return 1
+		}
+		defer file.Close()
+		w = file
+	}
+	switch *format {
+	case "csv":
+		err = store.ExportCSV(w, f)
+	case "jsonl":
+		err = store.ExportJSONL(w, f)
+	default:
+		fmt.Fprintf(os.Stderr, "bridge: unknown export format %q\n", *format)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	return 0
+}
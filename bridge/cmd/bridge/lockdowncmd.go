@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/control"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/lockdown"
+)
+
+// cmdLockdown engages or lifts the emergency lockdown. With a running
+// bridge the control API does the work (cancel everything, pause,
+// revoke the relay session); without one, only the persistent marker is
+// written so the next `bridge run` starts locked.
+func cmdLockdown(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("lockdown", flag.ExitOnError)
+	unlock := fs.Bool("unlock", false, "lift the lockdown and resume forwarding")
+	fs.Parse(args)
+
+	body, _ := json.Marshal(map[string]bool{"unlock": *unlock})
+	client := &http.Client{Timeout: 15 * time.Second}
+	req, err := http.NewRequest(http.MethodPost,
+		fmt.Sprintf("http://localhost:%d/control/lockdown", control.ActivePort(cfg)),
+		bytes.NewReader(body))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token, terr := control.LoadOrCreateToken(); terr == nil {
+		req.Header.Set(control.TokenHeader, token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// No running bridge: fall back to the marker alone.
+		if *unlock {
+			if err := lockdown.Lift(); err != nil {
+				fmt.Fprintln(os.Stderr, "bridge:", err)
+				return 1
+			}
+			fmt.Println("Lockdown lifted. No bridge was running.")
+			return 0
+		}
+		if err := lockdown.Engage(); err != nil {
+			fmt.Fprintln(os.Stderr, "bridge:", err)
+			return 1
+		}
+		fmt.Println("Lockdown engaged. No bridge was running; the next `bridge run` starts locked.")
+		return 0
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintf(os.Stderr, "bridge: control API returned %s\n", resp.Status)
+		return 1
+	}
+	if *unlock {
+		fmt.Println("Lockdown lifted; the bridge is reconnecting.")
+		return 0
+	}
+	var result struct {
+		Cancelled int `json:"cancelled"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	fmt.Printf("Lockdown engaged: %d requests cancelled, forwarding paused, relay session revoked.\n", result.Cancelled)
+	fmt.Println("Unlock with: bridge lockdown --unlock")
+	return 0
+}
@@ -0,0 +1,178 @@
+// Command bridge is the CloudToLocalLLM bridge: it connects a local
+// Ollama instance to the user's cloud account through the relay's
+// WebSocket tunnel.
+package main
+
+import (
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/auth"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/logging"
+)
+
+// version is overridden at build time via -ldflags.
+var version = "dev"
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `CloudToLocalLLM bridge %s
+
+Usage: bridge [flags] <command> [args]
+
+Commands:
+  run       Connect to the cloud relay and forward requests (default)
+  exec      Route one request through the local pipeline and print the response
+  replay    Replay a recorded tunnel session against local Ollama
+  login     Authenticate with the cloud account
+  pair      Authorize a headless bridge via QR/short code
+  logout    Remove stored tokens
+  auth      Export or import encrypted token bundles
+  status    Show the running bridge's status
+  stats     Show or reset lifetime counters
+  history   Query the recorded request history
+  transcripts   List or export captured conversation transcripts
+  doctor    Run connectivity and configuration diagnostics
+  ping      Measure relay and Ollama latency
+  serve-openai  Serve a local OpenAI-compatible API with bridge policies
+  set-ollama    Repoint a running bridge at another Ollama host:port
+  share     Mint, list or revoke temporary guest access links
+  lockdown  Cancel everything, pause forwarding and revoke the relay session
+  mock-relay    Serve a local mock relay for end-to-end testing
+  mock-ollama   Serve canned Ollama API responses for development
+  version   Print the bridge version
+
+Flags:
+`, version)
+	flag.PrintDefaults()
+}
+
+func main() {
+	configPath := flag.String("config", "", "path to bridge.yaml (default: ~/.cloudtolocalllm/bridge.yaml)")
+	logLevel := flag.String("log-level", "", "override configured log level")
+	profile := flag.String("profile", "", "override configured tuning profile (low-resource)")
+	output := flag.String("output", "text", "output format for command results: text or json")
+	insecureTokens := flag.Bool("insecure-token-file", false, "load tokens even from a world-readable token file")
+	flag.Usage = usage
+	flag.Parse()
+
+	path := *configPath
+	if path == "" {
+		p, err := config.Path()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "bridge:", err)
+			os.Exit(1)
+		}
+		path = p
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		os.Exit(exitConfig)
+	}
+	if *logLevel != "" {
+		cfg.Bridge.LogLevel = *logLevel
+	}
+	if *profile != "" {
+		cfg.Bridge.Profile = *profile
+	}
+	cfg.ApplyProfile()
+	logging.Setup(cfg.Bridge.LogLevel)
+	if err := logging.SetRedactPatterns(cfg.Bridge.RedactPatterns); err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		os.Exit(exitConfig)
+	}
+	auth.AllowInsecureTokenFile = *insecureTokens
+	config.AuditPermissions()
+
+	// Install the TLS policy on the default transport so every HTTPS
+	// client (auth, registration, Ollama, telemetry) shares it; the
+	// WebSocket dialer picks it up separately in the tunnel.
+	tlsConf, err := cfg.TLS.ClientConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		os.Exit(exitConfig)
+	}
+	if cfg.Cloud.Insecure {
+		slog.Warn("cloud.insecure is set: TLS certificate verification is DISABLED and plain-HTTP relays are allowed; never use this outside a trusted development network")
+		if tlsConf == nil {
+			tlsConf = &tls.Config{}
+		}
+		tlsConf.InsecureSkipVerify = true
+	}
+	if tlsConf != nil {
+		tr := http.DefaultTransport.(*http.Transport).Clone()
+		tr.TLSClientConfig = tlsConf
+		http.DefaultTransport = tr
+	}
+
+	switch *output {
+	case "text", "json":
+		outputJSON = *output == "json"
+	default:
+		fmt.Fprintf(os.Stderr, "bridge: unknown output format %q (want text or json)\n", *output)
+		os.Exit(2)
+	}
+
+	cmd := flag.Arg(0)
+	if cmd == "" {
+		cmd = "run"
+	}
+	args := flag.Args()
+	if len(args) > 0 {
+		args = args[1:]
+	}
+
+	var code int
+	switch cmd {
+	case "run":
+		code = cmdRun(cfg, args)
+	case "exec":
+		code = cmdExec(cfg, args)
+	case "replay":
+		code = cmdReplay(cfg, args)
+	case "login":
+		code = cmdLogin(cfg, args)
+	case "pair":
+		code = cmdPair(cfg, args)
+	case "logout":
+		code = cmdLogout(cfg)
+	case "auth":
+		code = cmdAuth(cfg, args)
+	case "status":
+		code = cmdStatus(cfg)
+	case "stats":
+		code = cmdStats(cfg, args)
+	case "history":
+		code = cmdHistory(cfg, args)
+	case "transcripts":
+		code = cmdTranscripts(cfg, args)
+	case "doctor":
+		code = cmdDoctor(cfg)
+	case "ping":
+		code = cmdPing(cfg, args)
+	case "serve-openai":
+		code = cmdServeOpenAI(cfg, args)
+	case "set-ollama":
+		code = cmdSetOllama(cfg, args)
+	case "share":
+		code = cmdShare(cfg, args)
+	case "lockdown":
+		code = cmdLockdown(cfg, args)
+	case "mock-relay":
+		code = cmdMockRelay(cfg, args)
+	case "mock-ollama":
+		code = cmdMockOllama(cfg, args)
+	case "version":
+		code = cmdVersion(args)
+	default:
+		fmt.Fprintf(os.Stderr, "bridge: unknown command %q\n", cmd)
+		usage()
+		code = 2
+	}
+	os.Exit(code)
+}
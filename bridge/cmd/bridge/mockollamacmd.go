@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/mockollama"
+)
+
+// cmdMockOllama serves canned Ollama API responses for development on
+// machines without a GPU or Ollama installed.
+func cmdMockOllama(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("mock-ollama", flag.ExitOnError)
+	port := fs.Int("port", 11434, "port to listen on")
+	models := fs.String("models", "llama3:8b", "comma-separated model names for /api/tags")
+	delay := fs.Duration("chunk-delay", 50*time.Millisecond, "pause between streamed chunks")
+	fs.Parse(args)
+
+	srv := mockollama.New()
+	srv.Models = strings.Split(*models, ",")
+	srv.ChunkDelay = *delay
+
+	addr := fmt.Sprintf("127.0.0.1:%d", *port)
+	slog.Info("Mock Ollama listening", "addr", addr, "models", srv.Models)
+	if err := http.ListenAndServe(addr, srv.Handler()); err != nil {
+		slog.Error("Mock Ollama failed", "error", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/mockrelay"
+)
+
+// cmdMockRelay serves an in-process relay implementing the bridge
+// WebSocket contract, for end-to-end testing without the cloud service.
+// Point a bridge at it with cloud.base_url: http://localhost:<port> and
+// drive requests via POST /forward.
+func cmdMockRelay(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("mock-relay", flag.ExitOnError)
+	port := fs.Int("port", 8765, "port to listen on")
+	fs.Parse(args)
+
+	srv := mockrelay.New()
+	addr := fmt.Sprintf("127.0.0.1:%d", *port)
+	slog.Info("Mock relay listening", "addr", addr)
+	if err := http.ListenAndServe(addr, srv.Handler()); err != nil {
+		slog.Error("Mock relay failed", "error", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// outputJSON is set by the global --output flag. Commands that support
+// machine-readable output check it before printing and emit a stable JSON
+// schema instead of the human-readable form.
+var outputJSON bool
+
+// printJSON writes v to stdout as indented JSON and returns an exit code.
+func printJSON(v any) int {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		fmt.Fprintln(os.Stderr, "bridge: encoding output:", err)
+		return 1
+	}
+	return 0
+}
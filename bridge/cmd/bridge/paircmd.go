@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"rsc.io/qr"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/auth"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/clipboard"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/hooks"
+)
+
+// cmdPair authorizes a headless bridge through the OAuth device flow:
+// it prints a QR code and a short code the user approves from the cloud
+// web app on any other device, then stores the resulting tokens.
+func cmdPair(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("pair", flag.ExitOnError)
+	noQR := fs.Bool("no-qr", false, "skip the QR code and print only the URL and code")
+	fs.Parse(args)
+
+	dir, err := config.Dir()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	flow := &auth.Flow{
+		Domain:   cfg.Cloud.Auth0Domain,
+		ClientID: cfg.Cloud.Auth0ClientID,
+		Audience: cfg.Cloud.Auth0Audience,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+	defer cancel()
+
+	da, err := flow.DeviceAuthorize(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge: pairing failed:", err)
+		return 1
+	}
+
+	if !*noQR {
+		if code, err := qr.Encode(da.VerificationURIComplete, qr.L); err == nil {
+			fmt.Println(renderQR(code))
+		}
+	}
+	fmt.Printf("Scan the QR code, or open %s on another device\n", da.VerificationURI)
+	fmt.Printf("and enter the code: %s\n", da.UserCode)
+	if err := clipboard.Copy(da.VerificationURIComplete); err == nil {
+		fmt.Println("(the approval URL is on your clipboard)")
+	}
+	fmt.Println()
+	fmt.Printf("Waiting for approval (expires %s)...\n", da.ExpiresAt.Format(time.Kitchen))
+
+	tokens, err := flow.DevicePoll(ctx, da)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge: pairing failed:", err)
+		return 1
+	}
+	if err := auth.NewTokenStore(dir).Save(tokens); err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	hooks.Set(cfg.Hooks)
+	hooks.Login(tokens.ExpiresAt)
+	fmt.Println("Pairing successful. This bridge is now linked to your account.")
+	return 0
+}
+
+// renderQR draws the QR matrix with half-block characters, two modules
+// per terminal row, with a quiet-zone border so scanners lock on.
+func renderQR(code *qr.Code) string {
+	var b strings.Builder
+	size := code.Size
+	border := 2
+	black := func(x, y int) bool {
+		if x < 0 || y < 0 || x >= size || y >= size {
+			return false
+		}
+		return code.Black(x, y)
+	}
+	for y := -border; y < size+border; y += 2 {
+		for x := -border; x < size+border; x++ {
+			top, bottom := black(x, y), black(x, y+1)
+			switch {
+			case top && bottom:
+				b.WriteRune('█')
+			case top:
+				b.WriteRune('▀')
+			case bottom:
+				b.WriteRune('▄')
+			default:
+				b.WriteRune(' ')
+			}
+		}
+		b.WriteRune('\n')
+	}
+	return b.String()
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/auth"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/ollama"
+)
+
+// cmdPing measures relay WebSocket round-trip latency and local Ollama
+// generate latency, printing p50/p95 over the requested iterations.
+func cmdPing(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("ping", flag.ContinueOnError)
+	iterations := fs.Int("n", 5, "number of iterations per target")
+	model := fs.String("model", "", "model for the Ollama generate probe (default: first installed)")
+	skipRelay := fs.Bool("skip-relay", false, "only probe local Ollama")
+	skipOllama := fs.Bool("skip-ollama", false, "only probe the relay")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	code := 0
+	if !*skipRelay {
+		if err := pingRelay(ctx, cfg, *iterations); err != nil {
+			fmt.Fprintln(os.Stderr, "bridge: relay ping failed:", err)
+			code = 1
+		}
+	}
+	if !*skipOllama {
+		if err := pingOllama(ctx, cfg, *model, *iterations); err != nil {
+			fmt.Fprintln(os.Stderr, "bridge: ollama ping failed:", err)
+			code = 1
+		}
+	}
+	return code
+}
+
+// pingRelay dials the bridge WebSocket once and measures control-frame
+// ping/pong round trips.
+func pingRelay(ctx context.Context, cfg *config.Config, n int) error {
+	dir, err := config.Dir()
+	if err != nil {
+		return err
+	}
+	tokens, err := auth.NewTokenStore(dir).Load()
+	if err != nil {
+		return err
+	}
+	if tokens == nil || !tokens.Valid() {
+		return fmt.Errorf("no valid token; run `bridge login` first")
+	}
+
+	dialCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	conn, _, err := websocket.DefaultDialer.DialContext(dialCtx,
+		cfg.WebSocketURL()+"?token="+tokens.AccessToken, nil)
+	if err != nil {
+		return fmt.Errorf("dialing relay: %w", err)
+	}
+	defer conn.Close()
+
+	pong := make(chan struct{}, 1)
+	conn.SetPongHandler(func(string) error {
+		select {
+		case pong <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+	// The pong handler only runs while a reader is active.
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	var samples []time.Duration
+	for i := 0; i < n; i++ {
+		start := time.Now()
+		if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+			return fmt.Errorf("sending ping: %w", err)
+		}
+		select {
+		case <-pong:
+			samples = append(samples, time.Since(start))
+		case <-time.After(10 * time.Second):
+			return fmt.Errorf("timed out waiting for pong")
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	printLatency("relay WebSocket RTT", samples)
+	return nil
+}
+
+// pingOllama runs single-token generations against the local Ollama.
+func pingOllama(ctx context.Context, cfg *config.Config, model string, n int) error {
+	client := ollama.NewClient(cfg.OllamaBaseURL(), 60*time.Second)
+	if model == "" {
+		var err error
+		model, err = firstInstalledModel(ctx, client)
+		if err != nil {
+			return err
+		}
+	}
+	body, _ := json.Marshal(map[string]any{
+		"model":   model,
+		"prompt":  "Hi",
+		"stream":  false,
+		"options": map[string]any{"num_predict": 1},
+	})
+
+	var samples []time.Duration
+	for i := 0; i < n; i++ {
+		start := time.Now()
+		resp, err := client.Forward(ctx, http.MethodPost, "/api/generate",
+			map[string]string{"Content-Type": "application/json"}, body)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			err := fmt.Errorf("ollama returned %d: %s", resp.StatusCode, resp.Body)
+			resp.Release()
+			return err
+		}
+		resp.Release()
+		samples = append(samples, time.Since(start))
+	}
+	printLatency(fmt.Sprintf("ollama generate (%s)", model), samples)
+	return nil
+}
+
+func firstInstalledModel(ctx context.Context, client *ollama.Client) (string, error) {
+	resp, err := client.Forward(ctx, http.MethodGet, "/api/tags", nil, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Release()
+	var tags struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.Unmarshal(resp.Body, &tags); err != nil {
+		return "", fmt.Errorf("parsing /api/tags: %w", err)
+	}
+	if len(tags.Models) == 0 {
+		return "", fmt.Errorf("no models installed; pass -model or `ollama pull` one")
+	}
+	return tags.Models[0].Name, nil
+}
+
+func printLatency(label string, samples []time.Duration) {
+	fmt.Printf("%s (%d samples): min %s  p50 %s  p95 %s  max %s\n",
+		label, len(samples),
+		percentile(samples, 0).Round(time.Millisecond),
+		percentile(samples, 50).Round(time.Millisecond),
+		percentile(samples, 95).Round(time.Millisecond),
+		percentile(samples, 100).Round(time.Millisecond))
+}
+
+// percentile returns the pth percentile (nearest-rank) of samples.
+func percentile(samples []time.Duration, p int) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := make([]time.Duration, len(samples))
+	copy(sorted, samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	if p <= 0 {
+		return sorted[0]
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
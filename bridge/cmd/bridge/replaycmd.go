@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/filter"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/ollama"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/quota"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/tunnel"
+)
+
+// cmdReplay feeds a session recorded with `bridge run -record` back
+// through the request handler against local Ollama, without touching the
+// cloud relay.
+func cmdReplay(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: bridge replay <recording-file>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		slog.Error("Opening recording failed", "error", err)
+		return 1
+	}
+	defer f.Close()
+
+	engine, err := filter.New(cfg.Filter)
+	if err != nil {
+		slog.Error("Loading content filter failed", "error", err)
+		return 1
+	}
+	oc := ollama.NewClient(cfg.OllamaBaseURL(), cfg.Ollama.Timeout)
+	t := tunnel.New(cfg, oc, engine, quota.NewManager(cfg.Quotas), func() (string, error) {
+		return "", fmt.Errorf("replay mode has no relay connection")
+	})
+
+	if err := t.Replay(context.Background(), f, os.Stdout); err != nil {
+		slog.Error("Replay failed", "error", err)
+		return 1
+	}
+	return 0
+}
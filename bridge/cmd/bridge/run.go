@@ -0,0 +1,736 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/anomaly"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/attest"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/auth"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/browser"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/buildinfo"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/clipboard"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/control"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/filter"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/flags"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/harden"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/history"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/hooks"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/hotkey"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/lockdown"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/lockfile"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/logging"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/mirror"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/notify"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/ollama"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/power"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/quota"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/rag"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/share"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/stats"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/systemd"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/telemetry"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/transcript"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/tray"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/tunnel"
+)
+
+// cmdRun connects to the relay and serves tunneled requests until
+// interrupted.
+func cmdRun(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	recordPath := fs.String("record", "", "record incoming tunnel messages to this file for `bridge replay`")
+	takeover := fs.Bool("takeover", false, "ask a running bridge instance to shut down and take its place")
+	forceTray := fs.Bool("force-tray", false, "attempt the tray icon even when no tray support is detected")
+	exitOnError := fs.Bool("exit-on-error", false, "exit with a distinct code instead of retrying when the relay (4) or Ollama (5) is unreachable")
+	fs.Parse(args)
+
+	dir, err := config.Dir()
+	if err != nil {
+		slog.Error("Resolving config directory failed", "error", err)
+		return 1
+	}
+
+	if cfg.Hardening.Enabled {
+		writable := []string{dir}
+		if dataDir, err := cfg.DataDir(); err == nil && dataDir != dir {
+			writable = append(writable, dataDir)
+		}
+		harden.Apply(cfg.Hardening, writable)
+	}
+
+	lock, err := acquireInstanceLock(filepath.Join(dir, "bridge.lock"), control.ActivePort(cfg), *takeover)
+	if err != nil {
+		slog.Error("Another bridge instance is already running", "error", err,
+			"hint", "use `bridge run --takeover` to replace it, or `bridge status` to inspect it")
+		return 1
+	}
+	defer lock.Release()
+	store := auth.NewTokenStore(dir)
+	flow := &auth.Flow{
+		Domain:   cfg.Cloud.Auth0Domain,
+		ClientID: cfg.Cloud.Auth0ClientID,
+		Audience: cfg.Cloud.Auth0Audience,
+	}
+
+	engine, err := filter.New(cfg.Filter)
+	if err != nil {
+		slog.Error("Loading content filter failed", "error", err)
+		return 1
+	}
+	if engine.Enabled() {
+		slog.Info("Content filter enabled")
+	}
+
+	quotas := quota.NewManager(cfg.Quotas)
+	if quotas.Enabled() {
+		slog.Info("Per-user quotas enabled")
+	}
+	if cfg.Access.Enabled {
+		slog.Info("Delegated access list enabled",
+			"users", len(cfg.Access.Users), "default", cfg.Access.Default)
+	}
+
+	oc := ollama.NewTunedClient(cfg.OllamaBaseURL(), cfg.Ollama.Timeout, ollama.TransportOptions{
+		MaxIdleConns:    cfg.Ollama.MaxIdleConns,
+		IdleConnTimeout: cfg.Ollama.IdleConnTimeout,
+		ForceHTTP2:      cfg.Ollama.HTTP2,
+	})
+	var authExhausted atomic.Bool
+	var stopForAuth func()
+	t := tunnel.New(cfg, oc, engine, quotas, func() (string, error) {
+		tok, err := currentAccessToken(store, flow)
+		if err != nil && cfg.Bridge.AuthGrace > 0 && authFailingFor() > cfg.Bridge.AuthGrace {
+			// Grace exhausted: stop retrying and exit with a distinct
+			// code so a supervisor can surface the re-login requirement.
+			if authExhausted.CompareAndSwap(false, true) && stopForAuth != nil {
+				stopForAuth()
+			}
+		}
+		return tok, err
+	})
+	t.SetBuildInfo(version, buildinfo.Get(false).ShortCommit())
+	if cfg.Bridge.Profile == "low-resource" {
+		// One forwarded request at a time; a Pi-class box stalls when it
+		// buffers several large responses at once. Battery caps from the
+		// power monitor still take precedence while they apply.
+		t.SetMaxConcurrent(1)
+		slog.Info("Low-resource profile active")
+	} else if config.LowMemoryMachine() {
+		slog.Info("This machine has little memory; consider bridge.profile: low-resource")
+	}
+
+	if aud := cfg.Cloud.Auth0ManagementAudience; aud != "" && aud != cfg.Cloud.Auth0Audience {
+		mgmtFlow := &auth.Flow{
+			Domain:   cfg.Cloud.Auth0Domain,
+			ClientID: cfg.Cloud.Auth0ClientID,
+			Audience: aud,
+		}
+		t.SetManagementToken(func() (string, error) {
+			return scopedAccessToken(store, mgmtFlow)
+		})
+		slog.Info("Using separate management API audience", "audience", aud)
+	}
+
+	if dataDir, err := cfg.DataDir(); err == nil {
+		t.SetFeatureFlags(flags.NewClient(filepath.Join(dataDir, "flags.json")))
+	}
+
+	var lifetime *stats.Store
+	if dataDir, err := cfg.DataDir(); err == nil {
+		lifetime, err = stats.Open(filepath.Join(dataDir, "stats.json"))
+		if err != nil {
+			slog.Warn("Opening lifetime stats failed; counters restart at zero", "error", err)
+		} else {
+			t.SetStats(lifetime)
+		}
+	}
+
+	if cfg.History.Enabled {
+		path, err := historyDBPath(cfg)
+		if err != nil {
+			slog.Error("Resolving history database path failed", "error", err)
+			return 1
+		}
+		store, err := history.Open(path, cfg.History.MaxEntries)
+		if err != nil {
+			slog.Error("Opening history database failed", "error", err)
+			return 1
+		}
+		defer store.Close()
+		t.SetHistory(store)
+		slog.Info("Request history enabled", "path", path)
+	}
+
+	shares, err := share.NewStore()
+	if err != nil {
+		slog.Warn("Share store unavailable; guest share links are disabled", "error", err)
+		shares = nil
+	}
+	t.SetShares(shares)
+
+	if cfg.Attestation.Enabled {
+		signer, err := attest.LoadOrCreateSigner()
+		if err != nil {
+			slog.Error("Loading attestation key failed", "error", err)
+			return 1
+		}
+		t.SetAttester(signer)
+		slog.Info("Response attestations enabled", "public_key", signer.PublicKey())
+	}
+
+	if cfg.Anomaly.Enabled {
+		t.SetAnomaly(anomaly.New(cfg.Anomaly, func(kind, detail string) {
+			slog.Warn("Anomaly detected", "kind", kind, "detail", detail)
+			go hooks.Anomaly(kind, detail)
+			if cfg.Anomaly.AutoPause {
+				t.SetPause("anomaly: " + kind)
+			}
+			notify.Show(notify.Notification{
+				Title: "Unusual bridge activity",
+				Body:  detail,
+				Event: notify.EventError,
+			})
+		}))
+		slog.Info("Anomaly detection enabled", "auto_pause", cfg.Anomaly.AutoPause)
+	}
+
+	if lockdown.Active() {
+		t.Lockdown()
+		slog.Warn("Bridge starting in lockdown; run `bridge lockdown --unlock` to resume")
+	}
+
+	if cfg.Transcripts.Enabled {
+		dbPath, keyPath, err := transcriptPaths(cfg)
+		if err != nil {
+			slog.Error("Resolving transcript paths failed", "error", err)
+			return 1
+		}
+		store, err := transcript.Open(dbPath, keyPath, cfg.Transcripts.Retention)
+		if err != nil {
+			slog.Error("Opening transcript store failed", "error", err)
+			return 1
+		}
+		defer store.Close()
+		t.SetTranscripts(store)
+		slog.Info("Transcript capture enabled",
+			"path", dbPath, "retention", cfg.Transcripts.Retention)
+	}
+
+	var ragIndex *rag.Index
+	if cfg.Rag.Enabled {
+		dataDir, err := cfg.DataDir()
+		if err != nil {
+			slog.Error("Resolving data directory failed", "error", err)
+			return 1
+		}
+		ragIndex, err = rag.Open(cfg.Rag, oc, filepath.Join(dataDir, "rag.db"))
+		if err != nil {
+			slog.Error("Opening document index failed", "error", err)
+			return 1
+		}
+		defer ragIndex.Close()
+		t.SetRAG(ragIndex)
+		slog.Info("Document retrieval enabled",
+			"folders", cfg.Rag.Folders, "model", cfg.Rag.EmbedModel)
+	}
+
+	if *recordPath != "" {
+		f, err := os.OpenFile(*recordPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+		if err != nil {
+			slog.Error("Opening session recording failed", "error", err)
+			return 1
+		}
+		defer f.Close()
+		t.SetRecorder(tunnel.NewRecorder(f))
+		slog.Info("Recording tunnel session", "path", *recordPath)
+	}
+
+	if cfg.Mirror.Enabled {
+		dataDir, err := cfg.DataDir()
+		if err != nil {
+			slog.Error("Resolving data directory failed", "error", err)
+			return 1
+		}
+		m, err := mirror.New(cfg.Mirror.BaseURL, cfg.Mirror.Percent, cfg.Ollama.Timeout,
+			filepath.Join(dataDir, "mirror.jsonl"))
+		if err != nil {
+			slog.Error("Configuring request mirror failed", "error", err)
+			return 1
+		}
+		defer m.Close()
+		t.SetMirror(m)
+		slog.Info("Request mirroring enabled",
+			"target", cfg.Mirror.BaseURL, "percent", cfg.Mirror.Percent)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	stopForAuth = stop
+
+	// Measure relay clock skew so token expiry checks stay correct on
+	// machines with a wrong clock.
+	go func() {
+		skew, err := auth.MeasureSkew(ctx, cfg.CloudBase())
+		if err != nil {
+			slog.Debug("Measuring clock skew failed", "error", err)
+			return
+		}
+		auth.SetClockSkew(skew)
+		t.SetClockSkew(skew)
+		if skew < -30*time.Second || skew > 30*time.Second {
+			slog.Warn("Local clock differs significantly from the relay; check NTP",
+				"skew", skew)
+		}
+	}()
+
+	if ragIndex != nil {
+		go ragIndex.Run(ctx)
+	}
+
+	if lifetime != nil {
+		go lifetime.Run(ctx)
+		defer lifetime.Flush()
+	}
+
+	notify.SetPolicy(cfg.Notifications)
+	hooks.Set(cfg.Hooks)
+	t.SetStateHook(func(s tunnel.State) {
+		switch s {
+		case tunnel.StateConnected:
+			notify.Show(notify.Notification{
+				Event: notify.EventConnect,
+				Title: "CloudToLocalLLM bridge connected",
+				Body:  "The tunnel to the cloud relay is up.",
+			})
+		case tunnel.StateDisconnected:
+			notify.Show(notify.Notification{
+				Event: notify.EventDisconnect,
+				Title: "CloudToLocalLLM bridge disconnected",
+				Body:  "Lost the relay connection; reconnecting in the background.",
+			})
+		}
+	})
+
+	// togglePause flips manual pause for the tray menu and the global
+	// hotkey; both share one reason string so either can undo the other.
+	togglePause := func() string {
+		if t.PauseReason() != "" {
+			t.SetPause("")
+			return ""
+		}
+		t.SetPause("paused by the user")
+		return "paused by the user"
+	}
+
+	if cfg.Bridge.PauseHotkey != "" {
+		go func() {
+			err := hotkey.Run(ctx, cfg.Bridge.PauseHotkey, func() {
+				if reason := togglePause(); reason != "" {
+					notify.Show(notify.Notification{
+						Title: "CloudToLocalLLM bridge paused",
+						Body:  "Remote requests are rejected until you resume.",
+					})
+				} else {
+					notify.Show(notify.Notification{
+						Title: "CloudToLocalLLM bridge resumed",
+						Body:  "Remote requests are being forwarded again.",
+					})
+				}
+			})
+			if err != nil {
+				slog.Warn("Global pause hotkey unavailable", "error", err)
+			}
+		}()
+	}
+
+	if cfg.Bridge.Tray || *forceTray {
+		tray.SetIconTheme(cfg.Bridge.TrayIconTheme)
+		if ok, reason := tray.Supported(); ok || *forceTray {
+			if !ok {
+				slog.Warn("No tray support detected; --force-tray set, attempting anyway", "reason", reason)
+			}
+			go func() {
+				err := tray.Run(ctx, func() tray.State {
+					st := t.Status()
+					return tray.State{
+						Connected:       st.State == tunnel.StateConnected,
+						Paused:          st.Paused,
+						AuthRequired:    authFailingFor() > 0,
+						Degraded:        st.Registration == "degraded",
+						RequestsHandled: st.RequestsHandled,
+						Queued:          len(st.Queued),
+					}
+				}, tray.Controls{
+					TogglePause:    togglePause,
+					CancelRequests: func() int { return t.CancelRequests("") },
+					ClearQueue:     t.ClearQueue,
+					TestGeneration: func() { runTestGeneration(oc) },
+					OpenLogs: func() {
+						url := fmt.Sprintf("http://localhost:%d/logs", control.ActivePort(cfg))
+						if err := browser.Open(url); err != nil {
+							slog.Warn("Opening log viewer failed", "url", url, "error", err)
+						}
+					},
+					DebugLogs:    func() { logging.DebugFor(10 * time.Minute) },
+					CopyLoginURL: func() { trayLogin(ctx, store, flow) },
+					ShareLink:    func() { trayShare(ctx, cfg, shares, store, flow) },
+					Lockdown:     func() { engageLockdown(ctx, t) },
+					ToggleMute: func() bool {
+						notify.SetMuted(!notify.Muted())
+						return notify.Muted()
+					},
+					Muted: notify.Muted,
+					Quit:  stop,
+				})
+				if err != nil {
+					slog.Warn("Tray exited; continuing headless", "error", err)
+				}
+			}()
+		} else {
+			slog.Warn("Tray unavailable; running headless", "reason", reason,
+				"dashboard", fmt.Sprintf("http://localhost:%d/status", control.ActivePort(cfg)),
+				"hint", "pass --force-tray to attempt the tray anyway")
+		}
+	}
+
+	if cfg.Power.Enabled {
+		go power.NewMonitor(cfg.Power, t).Run(ctx)
+		slog.Info("Power and thermal throttling enabled",
+			"pause_on_battery", cfg.Power.PauseOnBattery,
+			"max_temp_celsius", cfg.Power.MaxTempCelsius)
+	}
+
+	go systemd.RunWatchdog(ctx)
+	systemd.NotifyReady()
+	defer systemd.NotifyStopping()
+
+	if cfg.Telemetry.Enabled {
+		uploader, err := telemetry.NewUploader(cfg.Telemetry, cfg.CloudBase(),
+			func() (string, error) { return currentAccessToken(store, flow) },
+			func() telemetry.Report {
+				st := t.Status()
+				return telemetry.Report{
+					BridgeID:        st.BridgeID,
+					RequestsHandled: st.RequestsHandled,
+					RequestsDenied:  st.RequestsDenied,
+					Errors:          t.RecentErrors(),
+				}
+			})
+		if err != nil {
+			slog.Error("Configuring telemetry failed", "error", err)
+			return 1
+		}
+		go uploader.Run(ctx)
+		slog.Info("Telemetry sync enabled", "interval", cfg.Telemetry.Interval)
+	}
+
+	srv := control.NewServer(cfg.StatusAddr(), t)
+	srv.OnShutdown = stop
+	srv.PortRange = cfg.Bridge.PortRange
+	if token, err := control.LoadOrCreateToken(); err != nil {
+		slog.Warn("Control token unavailable; control endpoints left unauthenticated", "error", err)
+	} else {
+		srv.Token = token
+	}
+	if token, err := control.LoadOrCreateStatusToken(); err != nil {
+		slog.Warn("Status token unavailable", "error", err)
+	} else {
+		srv.StatusToken = token
+	}
+	if tlsConf, err := control.ListenerTLS(cfg); err != nil {
+		slog.Error("Configuring listener TLS failed", "error", err)
+		return 1
+	} else {
+		srv.TLS = tlsConf
+	}
+	go func() {
+		if err := srv.Run(); err != nil {
+			slog.Error("Status server failed", "error", err)
+		}
+	}()
+
+	if *exitOnError {
+		t.SetFailFast(true)
+		if _, err := oc.Version(ctx); err != nil {
+			slog.Error("Ollama is unreachable", "url", cfg.OllamaBaseURL(), "error", err)
+			return exitOllama
+		}
+	}
+
+	err = t.Run(ctx)
+	if authExhausted.Load() {
+		slog.Error("Token refresh kept failing past bridge.auth_grace; run `bridge login` and restart",
+			"grace", cfg.Bridge.AuthGrace)
+		return exitAuth
+	}
+	if err != nil && ctx.Err() == nil {
+		if err == tunnel.ErrIdleExit {
+			slog.Info("Exiting after idle timeout")
+			return 0
+		}
+		slog.Error("Tunnel terminated", "error", err)
+		if *exitOnError {
+			return exitRelay
+		}
+		return exitFailure
+	}
+	slog.Info("Bridge shut down")
+	return 0
+}
+
+// acquireInstanceLock takes the single-instance lock. With takeover set
+// it asks a running instance to shut down through the control API and
+// waits for the lock to free up.
+func acquireInstanceLock(path string, controlPort int, takeover bool) (*lockfile.Lock, error) {
+	lock, err := lockfile.Acquire(path)
+	if err == nil || !errors.Is(err, &lockfile.HeldError{}) || !takeover {
+		return lock, err
+	}
+
+	slog.Info("Requesting running instance to shut down", "error", err)
+	client := &http.Client{Timeout: 3 * time.Second}
+	req, postErr := http.NewRequest(http.MethodPost,
+		fmt.Sprintf("http://localhost:%d/control/shutdown", controlPort), nil)
+	if postErr != nil {
+		return nil, fmt.Errorf("requesting shutdown of running instance: %w", postErr)
+	}
+	if token, terr := control.LoadOrCreateToken(); terr == nil {
+		req.Header.Set(control.TokenHeader, token)
+	}
+	resp, postErr := client.Do(req)
+	if postErr != nil {
+		return nil, fmt.Errorf("requesting shutdown of running instance: %w", postErr)
+	}
+	resp.Body.Close()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		time.Sleep(500 * time.Millisecond)
+		if lock, err = lockfile.Acquire(path); err == nil {
+			return lock, nil
+		}
+	}
+	return nil, fmt.Errorf("running instance did not release the lock: %w", err)
+}
+
+// trayLogin runs a login flow whose URL goes to the clipboard instead
+// of a browser, so a remote-desktop user can paste it into the browser
+// on their own machine. The callback listener still runs here.
+func trayLogin(ctx context.Context, store *auth.TokenStore, flow *auth.Flow) {
+	loginFlow := *flow
+	loginFlow.NoBrowser = true
+	loginFlow.OnAuthURL = func(u string) {
+		if err := clipboard.Copy(u); err != nil {
+			slog.Warn("Copying login URL failed; see the log for the URL", "url", u, "error", err)
+			return
+		}
+		notify.Show(notify.Notification{
+			Title: "Login URL copied",
+			Body:  "Paste it into a browser to finish logging in.",
+			Event: notify.EventAuth,
+		})
+	}
+	tokens, err := loginFlow.Login(ctx)
+	if err != nil {
+		slog.Warn("Tray-initiated login failed", "error", err)
+		return
+	}
+	if err := store.Save(tokens); err != nil {
+		slog.Error("Saving tokens from tray login failed", "error", err)
+		return
+	}
+	markAuthHealthy()
+	slog.Info("Tray-initiated login succeeded")
+}
+
+// engageLockdown is the tray's emergency lockdown: cancel everything,
+// pause forwarding, persist the lockdown and revoke the relay session.
+func engageLockdown(ctx context.Context, t *tunnel.Tunnel) {
+	cancelled := t.Lockdown()
+	if err := lockdown.Engage(); err != nil {
+		slog.Error("Persisting lockdown marker failed", "error", err)
+	}
+	rctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := t.RevokeSession(rctx); err != nil {
+		slog.Warn("Relay session revocation failed; lockdown holds locally", "error", err)
+	}
+	notify.Show(notify.Notification{
+		Title: "Bridge locked down",
+		Body: fmt.Sprintf("%d requests cancelled. Unlock with `bridge lockdown --unlock`.",
+			cancelled),
+		Event: notify.EventError,
+	})
+}
+
+// trayShare mints a four-hour guest share link, registers it with the
+// relay and puts it on the clipboard.
+func trayShare(ctx context.Context, cfg *config.Config, shares *share.Store, store *auth.TokenStore, flow *auth.Flow) {
+	if shares == nil {
+		slog.Warn("Share store unavailable; cannot mint a guest link")
+		return
+	}
+	sh, err := shares.Create(4*time.Hour, "tray")
+	if err != nil {
+		slog.Error("Minting guest share failed", "error", err)
+		return
+	}
+	if token, err := currentAccessToken(store, flow); err != nil {
+		slog.Warn("Share minted but not registered with the relay; no valid login", "id", sh.ID, "error", err)
+	} else if err := share.Register(ctx, cfg, token, sh); err != nil {
+		slog.Warn("Registering share with the relay failed", "id", sh.ID, "error", err)
+	}
+	url := share.GuestURL(cfg, sh)
+	if err := clipboard.Copy(url); err != nil {
+		slog.Warn("Copying share link failed; see the log for the URL", "url", url, "error", err)
+		return
+	}
+	notify.Show(notify.Notification{
+		Title: "Guest share link copied",
+		Body: fmt.Sprintf("Valid until %s; revoke with `bridge share --revoke %s`.",
+			sh.ExpiresAt.Format(time.Kitchen), sh.ID),
+	})
+}
+
+// currentAccessToken returns a valid access token, refreshing the stored
+// token set when expired. When re-authentication is needed a desktop
+// notification points the user at `bridge login`.
+func currentAccessToken(store *auth.TokenStore, flow *auth.Flow) (string, error) {
+	tokens, err := store.Load()
+	if err != nil {
+		return "", err
+	}
+	if tokens == nil {
+		notifyLoginRequired("The bridge is not logged in.")
+		return "", fmt.Errorf("not authenticated: run `bridge login` first")
+	}
+	if tokens.Valid() {
+		markAuthHealthy()
+		return tokens.AccessToken, nil
+	}
+	if tokens.RefreshToken == "" {
+		notifyLoginRequired("Your session expired.")
+		return "", fmt.Errorf("access token expired and no refresh token stored: run `bridge login`")
+	}
+	refreshed, err := flow.Refresh(context.Background(), tokens.RefreshToken)
+	if err != nil {
+		notifyLoginRequired("Renewing your session failed.")
+		return "", fmt.Errorf("refreshing token: %w", err)
+	}
+	markAuthHealthy()
+	if err := store.Save(refreshed); err != nil {
+		return "", err
+	}
+	go hooks.TokenRefresh(flow.Audience, refreshed.ExpiresAt)
+	return refreshed.AccessToken, nil
+}
+
+var (
+	authStateMu     sync.Mutex
+	authFailedSince time.Time
+)
+
+// markAuthHealthy clears the auth failure window after a usable token.
+func markAuthHealthy() {
+	authStateMu.Lock()
+	authFailedSince = time.Time{}
+	authStateMu.Unlock()
+}
+
+// authFailingFor reports how long the bridge has been unable to
+// authenticate; zero while healthy. The window opens on the first
+// re-login notification and closes on the next usable token.
+func authFailingFor() time.Duration {
+	authStateMu.Lock()
+	defer authStateMu.Unlock()
+	if authFailedSince.IsZero() {
+		return 0
+	}
+	return time.Since(authFailedSince)
+}
+
+// scopedAccessToken returns a valid access token for a secondary
+// audience, kept as its own entry under the token store. A missing or
+// expired entry is minted from its own refresh token, falling back to
+// the primary login's refresh token.
+func scopedAccessToken(primary *auth.TokenStore, flow *auth.Flow) (string, error) {
+	store := primary.For(flow.Audience)
+	tokens, err := store.Load()
+	if err != nil {
+		return "", err
+	}
+	if tokens.Valid() {
+		return tokens.AccessToken, nil
+	}
+	var refresh string
+	if tokens != nil {
+		refresh = tokens.RefreshToken
+	}
+	if refresh == "" {
+		base, err := primary.Load()
+		if err != nil {
+			return "", err
+		}
+		if base == nil || base.RefreshToken == "" {
+			return "", fmt.Errorf("no refresh token for audience %s: run `bridge login`", flow.Audience)
+		}
+		refresh = base.RefreshToken
+	}
+	refreshed, err := flow.Refresh(context.Background(), refresh)
+	if err != nil {
+		return "", fmt.Errorf("refreshing %s token: %w", flow.Audience, err)
+	}
+	if err := store.Save(refreshed); err != nil {
+		return "", err
+	}
+	go hooks.TokenRefresh(flow.Audience, refreshed.ExpiresAt)
+	return refreshed.AccessToken, nil
+}
+
+// authNoticeInterval throttles re-auth notifications so a retry loop does
+// not flood the desktop.
+const authNoticeInterval = 15 * time.Minute
+
+var (
+	authNoticeMu   sync.Mutex
+	lastAuthNotice time.Time
+)
+
+// notifyLoginRequired opens the auth failure window and shows at most
+// one "login required" notification (and on_auth_required hook firing)
+// per interval.
+func notifyLoginRequired(why string) {
+	authStateMu.Lock()
+	if authFailedSince.IsZero() {
+		authFailedSince = time.Now()
+	}
+	authStateMu.Unlock()
+
+	authNoticeMu.Lock()
+	defer authNoticeMu.Unlock()
+	if time.Since(lastAuthNotice) < authNoticeInterval {
+		return
+	}
+	lastAuthNotice = time.Now()
+	go hooks.AuthRequired(why)
+	notify.Show(notify.Notification{
+		Event:         notify.EventAuth,
+		Title:         "CloudToLocalLLM login required",
+		Body:          why + " Run `bridge login` to reconnect your cloud account.",
+		ActionLabel:   "Login now",
+		ActionCommand: "cloudtolocalllm://login",
+	})
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/filter"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/localapi"
+)
+
+// cmdServeOpenAI exposes a local OpenAI-compatible endpoint backed by the
+// configured Ollama instance and the bridge's policy pipeline.
+func cmdServeOpenAI(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("serve-openai", flag.ContinueOnError)
+	port := fs.Int("port", 8080, "port for the local OpenAI-compatible API")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	engine, err := filter.New(cfg.Filter)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	srv, err := localapi.NewOpenAIServer(cfg.OllamaBaseURL(), engine)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	if err := srv.Run(fmt.Sprintf("127.0.0.1:%d", *port)); err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	return 0
+}
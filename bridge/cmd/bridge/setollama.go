@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/control"
+)
+
+// cmdSetOllama points the running bridge at a different Ollama host:port
+// without dropping the cloud tunnel.
+func cmdSetOllama(cfg *config.Config, args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "bridge: usage: bridge set-ollama <host:port>")
+		return 2
+	}
+	host, portStr, err := net.SplitHostPort(args[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge: invalid target:", err)
+		return 2
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge: invalid port:", err)
+		return 2
+	}
+	body, _ := json.Marshal(map[string]any{"host": host, "port": port})
+	client := &http.Client{Timeout: 5 * time.Second}
+	req, err := http.NewRequest(http.MethodPost,
+		fmt.Sprintf("http://localhost:%d/control/ollama", control.ActivePort(cfg)),
+		bytes.NewReader(body))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token, terr := control.LoadOrCreateToken(); terr == nil {
+		req.Header.Set(control.TokenHeader, token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge: no running bridge found:", err)
+		return 1
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := json.Marshal(resp.Status)
+		fmt.Fprintf(os.Stderr, "bridge: control API returned %s\n", msg)
+		return 1
+	}
+	fmt.Printf("Ollama target changed to http://%s:%d\n", host, port)
+	return 0
+}
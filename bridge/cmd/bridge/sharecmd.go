@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/auth"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/clipboard"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/share"
+)
+
+// cmdShare mints, lists and revokes temporary guest access links. A
+// minted link is registered with the relay so the guest can reach this
+// bridge, and enforced locally so expiry and revocation hold even if
+// the relay keeps routing.
+func cmdShare(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("share", flag.ExitOnError)
+	hours := fs.Float64("hours", 4, "how long the link stays valid")
+	label := fs.String("label", "", "note about who the link is for")
+	list := fs.Bool("list", false, "list existing shares instead of minting")
+	revoke := fs.String("revoke", "", "revoke the share with this ID")
+	fs.Parse(args)
+
+	store, err := share.NewStore()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	switch {
+	case *list:
+		return shareList(store)
+	case *revoke != "":
+		sh, err := store.Revoke(*revoke)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "bridge:", err)
+			return 1
+		}
+		if token, terr := relayAccessToken(); terr != nil {
+			fmt.Fprintln(os.Stderr, "bridge: share revoked locally; relay not notified:", terr)
+		} else if uerr := share.Unregister(ctx, cfg, token, sh); uerr != nil {
+			fmt.Fprintln(os.Stderr, "bridge: share revoked locally; relay not notified:", uerr)
+		}
+		fmt.Printf("Share %s revoked.\n", sh.ID)
+		return 0
+	default:
+		sh, err := store.Create(time.Duration(float64(time.Hour)**hours), *label)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "bridge:", err)
+			return 1
+		}
+		if token, terr := relayAccessToken(); terr != nil {
+			fmt.Fprintln(os.Stderr, "bridge: warning: link not registered with the relay:", terr)
+		} else if rerr := share.Register(ctx, cfg, token, sh); rerr != nil {
+			fmt.Fprintln(os.Stderr, "bridge: warning: link not registered with the relay:", rerr)
+		}
+		url := share.GuestURL(cfg, sh)
+		fmt.Println(url)
+		fmt.Printf("Valid until %s. Revoke early with: bridge share --revoke %s\n",
+			sh.ExpiresAt.Format(time.RFC1123), sh.ID)
+		if err := clipboard.Copy(url); err == nil {
+			fmt.Println("(the link is on your clipboard)")
+		}
+		return 0
+	}
+}
+
+func shareList(store *share.Store) int {
+	shares, err := store.List()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	if len(shares) == 0 {
+		fmt.Println("No shares minted.")
+		return 0
+	}
+	for _, sh := range shares {
+		state := "active"
+		switch {
+		case sh.Revoked:
+			state = "revoked"
+		case sh.Expired():
+			state = "expired"
+		}
+		label := sh.Label
+		if label == "" {
+			label = "-"
+		}
+		fmt.Printf("%s  %-8s  expires %s  %s\n",
+			sh.ID, state, sh.ExpiresAt.Format(time.RFC1123), label)
+	}
+	return 0
+}
+
+// relayAccessToken returns the stored access token for relay API calls,
+// or an error telling the user to log in.
+func relayAccessToken() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	tokens, err := auth.NewTokenStore(dir).Load()
+	if err != nil {
+		return "", err
+	}
+	if tokens == nil || !tokens.Valid() {
+		return "", fmt.Errorf("no valid token; run `bridge login` first")
+	}
+	return tokens.AccessToken, nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/stats"
+)
+
+// cmdStats prints the lifetime counters persisted in stats.json, or
+// zeroes them with `bridge stats reset`. A running bridge keeps counting
+// from its in-memory totals after a reset until it restarts.
+func cmdStats(cfg *config.Config, args []string) int {
+	dataDir, err := cfg.DataDir()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	store, err := stats.Open(filepath.Join(dataDir, "stats.json"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	if len(args) > 0 {
+		if args[0] != "reset" {
+			fmt.Fprintf(os.Stderr, "bridge: unknown stats subcommand %q (want reset)\n", args[0])
+			return 2
+		}
+		if err := store.Reset(); err != nil {
+			fmt.Fprintln(os.Stderr, "bridge:", err)
+			return 1
+		}
+		fmt.Println("Lifetime stats reset.")
+		return 0
+	}
+
+	totals := store.Totals()
+	if outputJSON {
+		return printJSON(totals)
+	}
+	fmt.Printf("Counting since:    %s\n", totals.Since.Format("2006-01-02 15:04"))
+	fmt.Printf("Requests served:   %d\n", totals.Requests)
+	fmt.Printf("Bytes returned:    %d\n", totals.Bytes)
+	fmt.Printf("Connected time:    %s\n", (time.Duration(totals.UptimeSeconds) * time.Second).String())
+	fmt.Printf("Reconnects:        %d\n", totals.Reconnects)
+	return 0
+}
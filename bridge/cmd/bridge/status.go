@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/control"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/tunnel"
+)
+
+// cmdStatus queries the running bridge's local status API and prints a
+// human-readable summary.
+func cmdStatus(cfg *config.Config) int {
+	client := &http.Client{Timeout: 3 * time.Second}
+	resp, err := client.Get(fmt.Sprintf("http://localhost:%d/status", control.ActivePort(cfg)))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge: no running bridge found:", err)
+		return 1
+	}
+	defer resp.Body.Close()
+
+	var st tunnel.Status
+	if err := json.NewDecoder(resp.Body).Decode(&st); err != nil {
+		fmt.Fprintln(os.Stderr, "bridge: decoding status:", err)
+		return 1
+	}
+	if outputJSON {
+		return printJSON(st)
+	}
+	fmt.Printf("State:             %s\n", st.State)
+	if st.Version != "" {
+		v := st.Version
+		if st.Commit != "" {
+			v += " (" + st.Commit + ")"
+		}
+		fmt.Printf("Version:           %s\n", v)
+	}
+	if st.BridgeID != "" {
+		fmt.Printf("Bridge ID:         %s\n", st.BridgeID)
+	}
+	if st.Registration != "" {
+		fmt.Printf("Registration:      %s\n", st.Registration)
+	}
+	fmt.Printf("Requests handled:  %d\n", st.RequestsHandled)
+	fmt.Printf("Requests denied:   %d\n", st.RequestsDenied)
+	return 0
+}
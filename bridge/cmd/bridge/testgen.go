@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/notify"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/ollama"
+)
+
+// runTestGeneration sends a tiny prompt to the first installed model and
+// reports latency and output in a notification, so users can verify the
+// local Ollama works independently of the cloud path.
+func runTestGeneration(oc *ollama.Client) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	model, err := firstModel(ctx, oc)
+	if err != nil {
+		notify.Show(notify.Notification{
+			Title: "Test generation failed",
+			Body:  fmt.Sprintf("Could not list models: %v", err),
+		})
+		return
+	}
+
+	body, _ := json.Marshal(map[string]any{
+		"model":  model,
+		"prompt": "Reply with a short greeting.",
+		"stream": false,
+	})
+	start := time.Now()
+	resp, err := oc.Forward(ctx, http.MethodPost, "/api/generate",
+		map[string]string{"Content-Type": "application/json"}, body)
+	if err != nil {
+		notify.Show(notify.Notification{
+			Title: "Test generation failed",
+			Body:  fmt.Sprintf("Request to %s failed: %v", model, err),
+		})
+		return
+	}
+	defer resp.Release()
+	elapsed := time.Since(start).Round(10 * time.Millisecond)
+
+	if resp.StatusCode != http.StatusOK {
+		notify.Show(notify.Notification{
+			Title: "Test generation failed",
+			Body:  fmt.Sprintf("%s answered with status %d", model, resp.StatusCode),
+		})
+		return
+	}
+	var out struct {
+		Response string `json:"response"`
+	}
+	_ = json.Unmarshal(resp.Body, &out)
+	reply := out.Response
+	if len(reply) > 120 {
+		reply = reply[:120] + "…"
+	}
+	notify.Show(notify.Notification{
+		Title: fmt.Sprintf("%s replied in %s", model, elapsed),
+		Body:  reply,
+	})
+}
+
+// firstModel returns the first installed model from /api/tags.
+func firstModel(ctx context.Context, oc *ollama.Client) (string, error) {
+	resp, err := oc.Forward(ctx, http.MethodGet, "/api/tags", nil, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Release()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("/api/tags returned %d", resp.StatusCode)
+	}
+	var tags struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.Unmarshal(resp.Body, &tags); err != nil {
+		return "", fmt.Errorf("parsing model list: %w", err)
+	}
+	if len(tags.Models) == 0 {
+		return "", fmt.Errorf("no models installed")
+	}
+	return tags.Models[0].Name, nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/transcript"
+)
+
+// transcriptPaths returns the transcript database and key file locations
+// for this config.
+func transcriptPaths(cfg *config.Config) (dbPath, keyPath string, err error) {
+	dir, err := cfg.DataDir()
+	if err != nil {
+		return "", "", err
+	}
+	return filepath.Join(dir, "transcripts.db"), filepath.Join(dir, "transcripts.key"), nil
+}
+
+// cmdTranscripts implements `bridge transcripts list|export`.
+func cmdTranscripts(cfg *config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "bridge: usage: bridge transcripts <list|export> [flags]")
+		return 2
+	}
+	dbPath, keyPath, err := transcriptPaths(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	store, err := transcript.Open(dbPath, keyPath, cfg.Transcripts.Retention)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	defer store.Close()
+
+	switch args[0] {
+	case "list":
+		return transcriptsList(store, args[1:])
+	case "export":
+		return transcriptsExport(store, args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "bridge: unknown transcripts subcommand %q\n", args[0])
+		return 2
+	}
+}
+
+// parseTranscriptSince parses a --since flag value as either a duration
+// back from now or an RFC3339 timestamp.
+func parseTranscriptSince(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if ts, err := time.Parse(time.RFC3339, value); err == nil {
+		return ts, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since value %q", value)
+}
+
+func transcriptsList(store *transcript.Store, args []string) int {
+	fs := flag.NewFlagSet("transcripts list", flag.ContinueOnError)
+	since := fs.String("since", "", "only entries newer than this duration (e.g. 24h) or RFC3339 time")
+	limit := fs.Int("limit", 50, "maximum entries to return (0 = all)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	from, err := parseTranscriptSince(*since)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 2
+	}
+	entries, err := store.List(from, *limit)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	if outputJSON {
+		if entries == nil {
+			entries = []transcript.Entry{}
+		}
+		return printJSON(entries)
+	}
+	if len(entries) == 0 {
+		fmt.Println("No matching transcripts.")
+		return 0
+	}
+	for _, e := range entries {
+		fmt.Printf("%s  %s  %s\n", e.Timestamp.Local().Format("2006-01-02 15:04:05"), e.Model, e.ID)
+		fmt.Printf("  prompt:   %s\n", truncateLine(e.Prompt, 100))
+		fmt.Printf("  response: %s\n", truncateLine(e.Response, 100))
+	}
+	return 0
+}
+
+// truncateLine flattens s to one line and cuts it at max runes.
+func truncateLine(s string, max int) string {
+	flat := make([]rune, 0, max)
+	for _, r := range s {
+		if r == '\n' || r == '\r' || r == '\t' {
+			r = ' '
+		}
+		flat = append(flat, r)
+		if len(flat) == max {
+			return string(flat) + "…"
+		}
+	}
+	return string(flat)
+}
+
+func transcriptsExport(store *transcript.Store, args []string) int {
+	fs := flag.NewFlagSet("transcripts export", flag.ContinueOnError)
+	since := fs.String("since", "", "only entries newer than this duration (e.g. 24h) or RFC3339 time")
+	out := fs.String("file", "", "output file (default: stdout)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	from, err := parseTranscriptSince(*since)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 2
+	}
+
+	w := os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "bridge:", err)
+			return 1
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := store.ExportJSONL(w, from); err != nil {
+		fmt.Fprintln(os.Stderr, "bridge:", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/buildinfo"
+)
+
+// cmdVersion prints the bridge version; with -verbose it includes the
+// embedded build metadata and module versions.
+func cmdVersion(args []string) int {
+	fs := flag.NewFlagSet("version", flag.ExitOnError)
+	verbose := fs.Bool("verbose", false, "include commit, build date, Go version and modules")
+	fs.Parse(args)
+
+	info := buildinfo.Get(*verbose)
+	if outputJSON {
+		return printJSON(struct {
+			Version string `json:"version"`
+			buildinfo.Info
+		}{version, info})
+	}
+	if !*verbose {
+		fmt.Println(version)
+		return 0
+	}
+	fmt.Printf("Version:    %s\n", version)
+	if c := info.ShortCommit(); c != "" {
+		fmt.Printf("Commit:     %s\n", c)
+	}
+	if info.BuildDate != "" {
+		fmt.Printf("Built:      %s\n", info.BuildDate)
+	}
+	fmt.Printf("Go version: %s\n", info.GoVersion)
+	if len(info.Modules) > 0 {
+		fmt.Println("Modules:")
+		for _, m := range info.Modules {
+			fmt.Printf("  %s %s\n", m.Path, m.Version)
+		}
+	}
+	return 0
+}
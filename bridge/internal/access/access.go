@@ -0,0 +1,91 @@
+// Package access enforces the local delegated-access allow-list when a
+// bridge is shared with specific cloud users. Decisions are keyed by the
+// user identity the relay attaches to tunneled requests; the list itself
+// comes from the access section of bridge.yaml and never leaves the
+// machine.
+package access
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+)
+
+// ACL evaluates the access allow-list against request metadata.
+type ACL struct {
+	cfg config.AccessConfig
+}
+
+// New returns an ACL for the given configuration.
+func New(cfg config.AccessConfig) *ACL {
+	return &ACL{cfg: cfg}
+}
+
+// Enabled reports whether access enforcement is active.
+func (a *ACL) Enabled() bool { return a != nil && a.cfg.Enabled }
+
+// Check returns an error when the user may not run this request. model
+// is the parsed model name when the body carries one, empty otherwise;
+// requests without a model (listing, version probes) are never blocked
+// by a model restriction.
+func (a *ACL) Check(userID, path, model string) error {
+	if !a.Enabled() {
+		return nil
+	}
+	rule, ok := a.lookup(userID)
+	if !ok {
+		if a.cfg.Default == "allow" {
+			return nil
+		}
+		if userID == "" {
+			return fmt.Errorf("request carries no user identity and access.default is not allow")
+		}
+		return fmt.Errorf("user %s is not on the access list", userID)
+	}
+	if rule.Deny {
+		return fmt.Errorf("user %s is denied by the access list", userID)
+	}
+	if len(rule.Paths) > 0 && !prefixMatch(path, rule.Paths) {
+		return fmt.Errorf("user %s may not call %s", userID, path)
+	}
+	if len(rule.Models) > 0 && model != "" && !contains(rule.Models, model) {
+		return fmt.Errorf("user %s may not use model %s", userID, model)
+	}
+	return nil
+}
+
+// lookup finds the rule for a user. Emails in the list are matched
+// case-insensitively, since identity providers are inconsistent about
+// email casing; opaque user IDs match exactly.
+func (a *ACL) lookup(userID string) (config.AccessRule, bool) {
+	if rule, ok := a.cfg.Users[userID]; ok {
+		return rule, true
+	}
+	if strings.Contains(userID, "@") {
+		for key, rule := range a.cfg.Users {
+			if strings.EqualFold(key, userID) {
+				return rule, true
+			}
+		}
+	}
+	return config.AccessRule{}, false
+}
+
+func prefixMatch(path string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, want string) bool {
+	for _, s := range list {
+		if s == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package access
+
+import (
+	"testing"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+)
+
+func TestCheckDefaultDeny(t *testing.T) {
+	acl := New(config.AccessConfig{
+		Enabled: true,
+		Users: map[string]config.AccessRule{
+			"auth0|alice": {},
+		},
+	})
+	if err := acl.Check("auth0|alice", "/api/chat", "llama3"); err != nil {
+		t.Fatalf("listed user denied: %v", err)
+	}
+	if err := acl.Check("auth0|mallory", "/api/chat", "llama3"); err == nil {
+		t.Fatal("unlisted user allowed with default deny")
+	}
+	if err := acl.Check("", "/api/chat", ""); err == nil {
+		t.Fatal("anonymous request allowed with default deny")
+	}
+}
+
+func TestCheckDefaultAllowWithDenyEntry(t *testing.T) {
+	acl := New(config.AccessConfig{
+		Enabled: true,
+		Default: "allow",
+		Users: map[string]config.AccessRule{
+			"auth0|mallory": {Deny: true},
+		},
+	})
+	if err := acl.Check("auth0|anyone", "/api/chat", ""); err != nil {
+		t.Fatalf("default allow denied unlisted user: %v", err)
+	}
+	if err := acl.Check("auth0|mallory", "/api/chat", ""); err == nil {
+		t.Fatal("deny entry not enforced")
+	}
+}
+
+func TestCheckModelAndPathRestrictions(t *testing.T) {
+	acl := New(config.AccessConfig{
+		Enabled: true,
+		Users: map[string]config.AccessRule{
+			"teammate@example.com": {
+				Models: []string{"llama3"},
+				Paths:  []string{"/api/chat", "/api/tags"},
+			},
+		},
+	})
+	// Email keys match case-insensitively.
+	if err := acl.Check("Teammate@Example.com", "/api/chat", "llama3"); err != nil {
+		t.Fatalf("allowed model/path denied: %v", err)
+	}
+	if err := acl.Check("teammate@example.com", "/api/chat", "mixtral"); err == nil {
+		t.Fatal("disallowed model forwarded")
+	}
+	if err := acl.Check("teammate@example.com", "/api/pull", ""); err == nil {
+		t.Fatal("disallowed path forwarded")
+	}
+	// No model in the body (listing endpoints) passes the model check.
+	if err := acl.Check("teammate@example.com", "/api/tags", ""); err != nil {
+		t.Fatalf("model-less request denied: %v", err)
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	acl := New(config.AccessConfig{})
+	if err := acl.Check("auth0|anyone", "/api/chat", "llama3"); err != nil {
+		t.Fatalf("disabled ACL denied request: %v", err)
+	}
+}
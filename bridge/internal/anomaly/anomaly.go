@@ -0,0 +1,161 @@
+// Package anomaly watches tunneled request patterns for signs that a
+// shared bridge is being abused: sudden request-rate spikes, paths
+// outside the known API surface, and repeated denied requests. The
+// heuristics are deliberately simple and entirely local; they are a
+// tripwire that raises an alert (and optionally pauses forwarding), not
+// an intrusion detection system.
+package anomaly
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+)
+
+// Defaults applied to zero config values; see config.AnomalyConfig.
+const (
+	defaultRateFactor      = 5.0
+	defaultMinBurst        = 30
+	defaultDeniedThreshold = 10
+)
+
+// window is how many one-minute buckets the trailing averages cover.
+const window = 10
+
+// alertCooldown suppresses repeat alerts of the same kind, so one spike
+// fires one notification instead of hundreds.
+const alertCooldown = 10 * time.Minute
+
+// knownPrefixes is the expected API surface; a forwarded path outside it
+// is flagged as unusual.
+var knownPrefixes = []string{"/api/", "/v1/"}
+
+// Detector keeps the per-minute counters and fires onAlert when a
+// heuristic trips. All methods are safe for concurrent use and nil
+// receivers, so the tunnel can call them unconditionally.
+type Detector struct {
+	cfg     config.AnomalyConfig
+	onAlert func(kind, detail string)
+
+	mu        sync.Mutex
+	minute    time.Time
+	requests  [window]int
+	denied    [window]int
+	lastAlert map[string]time.Time
+	now       func() time.Time
+}
+
+// New returns a Detector with defaults applied to zero config values.
+// onAlert is called at most once per kind per cooldown window, from the
+// goroutine that tripped the heuristic.
+func New(cfg config.AnomalyConfig, onAlert func(kind, detail string)) *Detector {
+	if cfg.RateFactor <= 0 {
+		cfg.RateFactor = defaultRateFactor
+	}
+	if cfg.MinBurst <= 0 {
+		cfg.MinBurst = defaultMinBurst
+	}
+	if cfg.DeniedThreshold <= 0 {
+		cfg.DeniedThreshold = defaultDeniedThreshold
+	}
+	return &Detector{
+		cfg:       cfg,
+		onAlert:   onAlert,
+		lastAlert: make(map[string]time.Time),
+		now:       time.Now,
+	}
+}
+
+// Request records one forwarded request and checks the rate-spike and
+// unusual-path heuristics.
+func (d *Detector) Request(path string) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	d.roll()
+	d.requests[0]++
+	current := d.requests[0]
+	var rest int
+	for _, n := range d.requests[1:] {
+		rest += n
+	}
+	average := float64(rest) / float64(window-1)
+	spike := current >= d.cfg.MinBurst && float64(current) > d.cfg.RateFactor*average
+	unusual := !knownPath(path)
+	d.mu.Unlock()
+
+	if spike {
+		d.alert("rate_spike", fmt.Sprintf(
+			"%d requests this minute against a trailing average of %.1f/min", current, average))
+	}
+	if unusual {
+		d.alert("unusual_path", "request for unexpected path "+path)
+	}
+}
+
+// Denied records one denied request (content filter, access list, body
+// limits, quota) and checks the repeated-denials heuristic.
+func (d *Detector) Denied(reason string) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	d.roll()
+	d.denied[0]++
+	var total int
+	for _, n := range d.denied {
+		total += n
+	}
+	tripped := total >= d.cfg.DeniedThreshold
+	d.mu.Unlock()
+
+	if tripped {
+		d.alert("repeated_denials", fmt.Sprintf(
+			"%d denied requests in the last %d minutes, latest: %s", total, window, reason))
+	}
+}
+
+// roll advances the ring buffers to the current minute. Callers hold
+// d.mu.
+func (d *Detector) roll() {
+	now := d.now().Truncate(time.Minute)
+	if d.minute.IsZero() {
+		d.minute = now
+		return
+	}
+	for d.minute.Before(now) {
+		copy(d.requests[1:], d.requests[:window-1])
+		d.requests[0] = 0
+		copy(d.denied[1:], d.denied[:window-1])
+		d.denied[0] = 0
+		d.minute = d.minute.Add(time.Minute)
+	}
+}
+
+// alert fires onAlert unless the same kind fired within the cooldown.
+func (d *Detector) alert(kind, detail string) {
+	d.mu.Lock()
+	now := d.now()
+	if last, ok := d.lastAlert[kind]; ok && now.Sub(last) < alertCooldown {
+		d.mu.Unlock()
+		return
+	}
+	d.lastAlert[kind] = now
+	d.mu.Unlock()
+	if d.onAlert != nil {
+		d.onAlert(kind, detail)
+	}
+}
+
+func knownPath(path string) bool {
+	for _, p := range knownPrefixes {
+		if strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package anomaly
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+)
+
+func newTestDetector(cfg config.AnomalyConfig) (*Detector, *[]string, *time.Time) {
+	var alerts []string
+	d := New(cfg, func(kind, detail string) { alerts = append(alerts, kind) })
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+	return d, &alerts, &now
+}
+
+func TestRateSpike(t *testing.T) {
+	d, alerts, now := newTestDetector(config.AnomalyConfig{Enabled: true, MinBurst: 10})
+	// Establish a quiet baseline: one request per minute for ten minutes.
+	for i := 0; i < 10; i++ {
+		d.Request("/api/chat")
+		*now = now.Add(time.Minute)
+	}
+	if len(*alerts) != 0 {
+		t.Fatalf("baseline traffic alerted: %v", *alerts)
+	}
+	for i := 0; i < 50; i++ {
+		d.Request("/api/chat")
+	}
+	if len(*alerts) != 1 || (*alerts)[0] != "rate_spike" {
+		t.Fatalf("expected one rate_spike alert, got %v", *alerts)
+	}
+	// More of the same spike stays within the cooldown.
+	for i := 0; i < 50; i++ {
+		d.Request("/api/chat")
+	}
+	if len(*alerts) != 1 {
+		t.Fatalf("cooldown not applied: %v", *alerts)
+	}
+}
+
+func TestUnusualPath(t *testing.T) {
+	d, alerts, _ := newTestDetector(config.AnomalyConfig{Enabled: true})
+	d.Request("/api/chat")
+	d.Request("/v1/chat/completions")
+	if len(*alerts) != 0 {
+		t.Fatalf("known paths alerted: %v", *alerts)
+	}
+	d.Request("/admin/secrets")
+	if len(*alerts) != 1 || (*alerts)[0] != "unusual_path" {
+		t.Fatalf("expected one unusual_path alert, got %v", *alerts)
+	}
+}
+
+func TestRepeatedDenials(t *testing.T) {
+	d, alerts, _ := newTestDetector(config.AnomalyConfig{Enabled: true, DeniedThreshold: 3})
+	d.Denied("blocked model")
+	d.Denied("blocked model")
+	if len(*alerts) != 0 {
+		t.Fatalf("below-threshold denials alerted: %v", *alerts)
+	}
+	d.Denied("blocked model")
+	if len(*alerts) != 1 || (*alerts)[0] != "repeated_denials" {
+		t.Fatalf("expected one repeated_denials alert, got %v", *alerts)
+	}
+}
+
+func TestNilDetector(t *testing.T) {
+	var d *Detector
+	d.Request("/api/chat")
+	d.Denied("anything")
+}
@@ -0,0 +1,142 @@
+// Package attest signs response metadata with a bridge-held Ed25519 key
+// so the cloud UI can prove an answer truly came from the user's own
+// machine and model. The key never leaves the machine; verifiers pin
+// the public key (shown by `bridge status` or the first attested
+// response) and check signatures offline.
+package attest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+)
+
+// keyFileName is the Ed25519 seed file in the config directory.
+const keyFileName = "attestation.key"
+
+// Attestation is the signed response metadata embedded in response
+// messages. Field names match the relay's camelCase wire convention.
+type Attestation struct {
+	// RequestSHA256 and ResponseSHA256 are hex digests of the raw
+	// request and response bodies.
+	RequestSHA256  string `json:"requestSha256"`
+	ResponseSHA256 string `json:"responseSha256"`
+	// Model is the model named in the request body, when it carries one.
+	Model string `json:"model,omitempty"`
+	// BridgeID is the relay-assigned bridge identity at signing time.
+	BridgeID string `json:"bridgeId,omitempty"`
+	// Timestamp is the RFC 3339 signing time.
+	Timestamp string `json:"timestamp"`
+	// PublicKey is the hex Ed25519 public key; Signature is the hex
+	// signature over the canonical payload (see payload).
+	PublicKey string `json:"publicKey"`
+	Signature string `json:"signature"`
+}
+
+// Signer holds the bridge's attestation key.
+type Signer struct {
+	priv ed25519.PrivateKey
+}
+
+// LoadOrCreateSigner returns a Signer backed by attestation.key in the
+// config directory, generating a key on first use.
+func LoadOrCreateSigner() (*Signer, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, keyFileName)
+	if b, err := os.ReadFile(path); err == nil {
+		seed, err := hex.DecodeString(strings.TrimSpace(string(b)))
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("attestation key %s is corrupt; delete it to generate a new one", path)
+		}
+		return &Signer{priv: ed25519.NewKeyFromSeed(seed)}, nil
+	}
+	seed := make([]byte, ed25519.SeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, fmt.Errorf("generating attestation key: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(seed)), 0o600); err != nil {
+		return nil, fmt.Errorf("writing attestation key: %w", err)
+	}
+	return &Signer{priv: ed25519.NewKeyFromSeed(seed)}, nil
+}
+
+// PublicKey returns the hex public key verifiers pin.
+func (s *Signer) PublicKey() string {
+	return hex.EncodeToString(s.priv.Public().(ed25519.PublicKey))
+}
+
+// Attest signs the digests of one request/response pair.
+func (s *Signer) Attest(requestBody, responseBody []byte, model, bridgeID string) Attestation {
+	a := Attestation{
+		RequestSHA256:  digest(requestBody),
+		ResponseSHA256: digest(responseBody),
+		Model:          model,
+		BridgeID:       bridgeID,
+		Timestamp:      time.Now().UTC().Format(time.RFC3339),
+		PublicKey:      s.PublicKey(),
+	}
+	a.Signature = hex.EncodeToString(ed25519.Sign(s.priv, payload(a)))
+	return a
+}
+
+// AttestDigests signs precomputed body digests, for streamed responses
+// hashed incrementally.
+func (s *Signer) AttestDigests(requestSHA256, responseSHA256, model, bridgeID string) Attestation {
+	a := Attestation{
+		RequestSHA256:  requestSHA256,
+		ResponseSHA256: responseSHA256,
+		Model:          model,
+		BridgeID:       bridgeID,
+		Timestamp:      time.Now().UTC().Format(time.RFC3339),
+		PublicKey:      s.PublicKey(),
+	}
+	a.Signature = hex.EncodeToString(ed25519.Sign(s.priv, payload(a)))
+	return a
+}
+
+// Verify checks an attestation's signature against its own public key.
+// Callers must separately decide whether they trust that key.
+func Verify(a Attestation) error {
+	pub, err := hex.DecodeString(a.PublicKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid public key")
+	}
+	sig, err := hex.DecodeString(a.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding")
+	}
+	if !ed25519.Verify(ed25519.PublicKey(pub), payload(a), sig) {
+		return fmt.Errorf("signature does not match")
+	}
+	return nil
+}
+
+// payload is the canonical byte string the signature covers. Versioned
+// so the format can evolve without breaking old verifiers.
+func payload(a Attestation) []byte {
+	return []byte(strings.Join([]string{
+		"cloudtolocalllm-attest-v1",
+		a.RequestSHA256,
+		a.ResponseSHA256,
+		a.Model,
+		a.BridgeID,
+		a.Timestamp,
+	}, "\n"))
+}
+
+// Digest returns the hex SHA-256 of a body, for incremental callers.
+func digest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
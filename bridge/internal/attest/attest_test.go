@@ -0,0 +1,53 @@
+package attest
+
+import (
+	"crypto/ed25519"
+	"testing"
+)
+
+func testSigner(t *testing.T) *Signer {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Signer{priv: priv}
+}
+
+func TestAttestRoundTrip(t *testing.T) {
+	s := testSigner(t)
+	a := s.Attest([]byte(`{"model":"llama3"}`), []byte(`{"response":"hi"}`), "llama3", "bridge-1")
+	if err := Verify(a); err != nil {
+		t.Fatalf("valid attestation rejected: %v", err)
+	}
+	if a.PublicKey != s.PublicKey() {
+		t.Fatalf("attestation carries wrong public key")
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	s := testSigner(t)
+	a := s.Attest([]byte("request"), []byte("response"), "llama3", "bridge-1")
+	tampered := a
+	tampered.Model = "mixtral"
+	if err := Verify(tampered); err == nil {
+		t.Fatal("tampered model accepted")
+	}
+	tampered = a
+	tampered.ResponseSHA256 = digest([]byte("forged response"))
+	if err := Verify(tampered); err == nil {
+		t.Fatal("tampered digest accepted")
+	}
+}
+
+func TestAttestDigestsMatchesAttest(t *testing.T) {
+	s := testSigner(t)
+	req, resp := []byte("request"), []byte("response")
+	a := s.AttestDigests(digest(req), digest(resp), "llama3", "")
+	if err := Verify(a); err != nil {
+		t.Fatalf("digest attestation rejected: %v", err)
+	}
+	if b := s.Attest(req, resp, "llama3", ""); b.RequestSHA256 != a.RequestSHA256 || b.ResponseSHA256 != a.ResponseSHA256 {
+		t.Fatal("digest forms disagree")
+	}
+}
@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// bundleVersion identifies the export bundle format.
+const bundleVersion = 1
+
+// bundle is the on-disk form of an encrypted token export. Kind
+// distinguishes a plain token export from a full backup; empty means
+// tokens for compatibility with bundles written before backups existed.
+type bundle struct {
+	Version    int    `json:"version"`
+	Kind       string `json:"kind,omitempty"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Backup is the plaintext payload of a full bridge backup: the token
+// set plus identity material (config file, transcript key, per-audience
+// token entries) needed to move a bridge to a new machine.
+type Backup struct {
+	Created time.Time         `json:"created"`
+	Host    string            `json:"host,omitempty"`
+	Tokens  *Tokens           `json:"tokens"`
+	Files   map[string][]byte `json:"files,omitempty"`
+}
+
+func deriveKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+}
+
+// sealBundle encrypts plaintext under a passphrase-derived key into the
+// bundle envelope.
+func sealBundle(kind string, plaintext []byte, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("passphrase must not be empty")
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(bundle{
+		Version:    bundleVersion,
+		Kind:       kind,
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, nil),
+	}, "", "  ")
+}
+
+// openBundle decrypts a bundle envelope, checking it holds the expected
+// kind of payload.
+func openBundle(data []byte, kind, passphrase string) ([]byte, error) {
+	var b bundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("parsing bundle: %w", err)
+	}
+	if b.Version != bundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d", b.Version)
+	}
+	if b.Kind != kind {
+		got, want := b.Kind, kind
+		if got == "" {
+			got = "tokens"
+		}
+		if want == "" {
+			want = "tokens"
+		}
+		return nil, fmt.Errorf("bundle holds %s, not %s (use the matching auth subcommand)", got, want)
+	}
+	key, err := deriveKey(passphrase, b.Salt)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, b.Nonce, b.Ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting bundle (wrong passphrase?): %w", err)
+	}
+	return plaintext, nil
+}
+
+// EncryptBundle seals the token set with a passphrase-derived key so it
+// can be moved to another machine safely.
+func EncryptBundle(t *Tokens, passphrase string) ([]byte, error) {
+	plaintext, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	return sealBundle("", plaintext, passphrase)
+}
+
+// DecryptBundle opens an export bundle produced by EncryptBundle.
+func DecryptBundle(data []byte, passphrase string) (*Tokens, error) {
+	plaintext, err := openBundle(data, "", passphrase)
+	if err != nil {
+		return nil, err
+	}
+	var t Tokens
+	if err := json.Unmarshal(plaintext, &t); err != nil {
+		return nil, fmt.Errorf("parsing decrypted tokens: %w", err)
+	}
+	return &t, nil
+}
+
+// EncryptBackup seals a full bridge backup with a passphrase-derived key.
+func EncryptBackup(b *Backup, passphrase string) ([]byte, error) {
+	plaintext, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	return sealBundle("backup", plaintext, passphrase)
+}
+
+// DecryptBackup opens a backup produced by EncryptBackup.
+func DecryptBackup(data []byte, passphrase string) (*Backup, error) {
+	plaintext, err := openBundle(data, "backup", passphrase)
+	if err != nil {
+		return nil, err
+	}
+	var b Backup
+	if err := json.Unmarshal(plaintext, &b); err != nil {
+		return nil, fmt.Errorf("parsing decrypted backup: %w", err)
+	}
+	return &b, nil
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBundleRoundTrip(t *testing.T) {
+	in := &Tokens{
+		AccessToken:  "access",
+		RefreshToken: "refresh",
+		ExpiresAt:    time.Now().Add(time.Hour).Truncate(time.Second),
+	}
+	data, err := EncryptBundle(in, "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := DecryptBundle(data, "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.AccessToken != in.AccessToken || out.RefreshToken != in.RefreshToken {
+		t.Fatalf("tokens not round-tripped: %+v", out)
+	}
+}
+
+func TestBundleWrongPassphrase(t *testing.T) {
+	data, err := EncryptBundle(&Tokens{AccessToken: "x"}, "right")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecryptBundle(data, "wrong"); err == nil {
+		t.Fatal("expected decryption failure with wrong passphrase")
+	}
+}
+
+func TestBundleEmptyPassphrase(t *testing.T) {
+	if _, err := EncryptBundle(&Tokens{}, ""); err == nil {
+		t.Fatal("expected error for empty passphrase")
+	}
+}
+
+func TestBackupRoundTrip(t *testing.T) {
+	in := &Backup{
+		Created: time.Now().Truncate(time.Second),
+		Host:    "old-machine",
+		Tokens:  &Tokens{AccessToken: "access", RefreshToken: "refresh"},
+		Files:   map[string][]byte{"bridge.yaml": []byte("bridge:\n  port: 3100\n")},
+	}
+	data, err := EncryptBackup(in, "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := DecryptBackup(data, "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Tokens.AccessToken != "access" || out.Host != "old-machine" {
+		t.Fatalf("backup not round-tripped: %+v", out)
+	}
+	if string(out.Files["bridge.yaml"]) != string(in.Files["bridge.yaml"]) {
+		t.Fatal("identity file not round-tripped")
+	}
+}
+
+func TestBundleKindMismatch(t *testing.T) {
+	data, err := EncryptBackup(&Backup{Tokens: &Tokens{AccessToken: "x"}}, "p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecryptBundle(data, "p"); err == nil {
+		t.Fatal("token import accepted a backup bundle")
+	}
+	export, err := EncryptBundle(&Tokens{AccessToken: "x"}, "p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecryptBackup(export, "p"); err == nil {
+		t.Fatal("restore accepted a token export bundle")
+	}
+}
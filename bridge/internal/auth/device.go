@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DeviceAuth holds the device authorization grant the user must approve
+// in the cloud web app.
+type DeviceAuth struct {
+	DeviceCode              string
+	UserCode                string
+	VerificationURI         string
+	VerificationURIComplete string
+	Interval                time.Duration
+	ExpiresAt               time.Time
+}
+
+// DeviceAuthorize starts the OAuth device flow, returning the codes the
+// user needs to approve this bridge from another device. Meant for
+// headless machines where the browser PKCE flow is unavailable.
+func (f *Flow) DeviceAuthorize(ctx context.Context) (*DeviceAuth, error) {
+	form := url.Values{
+		"client_id": {f.ClientID},
+		"scope":     {"openid profile email offline_access"},
+		"audience":  {f.Audience},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("https://%s/oauth/device/code", f.Domain),
+		strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("device code request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		DeviceCode              string `json:"device_code"`
+		UserCode                string `json:"user_code"`
+		VerificationURI         string `json:"verification_uri"`
+		VerificationURIComplete string `json:"verification_uri_complete"`
+		ExpiresIn               int    `json:"expires_in"`
+		Interval                int    `json:"interval"`
+		Error                   string `json:"error"`
+		ErrorDesc               string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding device code response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || body.Error != "" {
+		return nil, fmt.Errorf("device code endpoint returned %d: %s %s",
+			resp.StatusCode, body.Error, body.ErrorDesc)
+	}
+	interval := time.Duration(body.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	return &DeviceAuth{
+		DeviceCode:              body.DeviceCode,
+		UserCode:                body.UserCode,
+		VerificationURI:         body.VerificationURI,
+		VerificationURIComplete: body.VerificationURIComplete,
+		Interval:                interval,
+		ExpiresAt:               time.Now().Add(time.Duration(body.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// DevicePoll polls the token endpoint until the user approves or rejects
+// the device grant, or it expires.
+func (f *Flow) DevicePoll(ctx context.Context, da *DeviceAuth) (*Tokens, error) {
+	interval := da.Interval
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+		if time.Now().After(da.ExpiresAt) {
+			return nil, fmt.Errorf("pairing code expired before it was approved")
+		}
+		tokens, errCode, err := f.devicePollOnce(ctx, da.DeviceCode)
+		switch {
+		case err != nil:
+			return nil, err
+		case tokens != nil:
+			return tokens, nil
+		case errCode == "authorization_pending":
+			// keep waiting
+		case errCode == "slow_down":
+			interval += 5 * time.Second
+		case errCode == "expired_token":
+			return nil, fmt.Errorf("pairing code expired before it was approved")
+		case errCode == "access_denied":
+			return nil, fmt.Errorf("pairing was denied in the web app")
+		default:
+			return nil, fmt.Errorf("device token endpoint returned %q", errCode)
+		}
+	}
+}
+
+// devicePollOnce performs one token poll; a nil token with an error code
+// means the grant is still pending or throttled.
+func (f *Flow) devicePollOnce(ctx context.Context, deviceCode string) (*Tokens, string, error) {
+	form := url.Values{
+		"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+		"device_code": {deviceCode},
+		"client_id":   {f.ClientID},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("https://%s/oauth/token", f.Domain),
+		strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		IDToken      string `json:"id_token"`
+		ExpiresIn    int    `json:"expires_in"`
+		Error        string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, "", fmt.Errorf("decoding token response: %w", err)
+	}
+	if body.Error != "" {
+		return nil, body.Error, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("token endpoint returned %d", resp.StatusCode)
+	}
+	return &Tokens{
+		AccessToken:  body.AccessToken,
+		RefreshToken: body.RefreshToken,
+		IDToken:      body.IDToken,
+		ExpiresAt:    time.Now().Add(time.Duration(body.ExpiresIn) * time.Second),
+	}, "", nil
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// handoffPath is where the desktop app POSTs its token set.
+const handoffPath = "/handoff"
+
+// handoffRequest is the payload the Flutter app sends. Expiry arrives as
+// either an absolute time or the remaining seconds, whichever the app
+// has at hand.
+type handoffRequest struct {
+	Code         string    `json:"code"`
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	IDToken      string    `json:"id_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	ExpiresIn    int       `json:"expires_in"`
+}
+
+// ReceiveHandoff waits for the already-authenticated CloudToLocalLLM
+// desktop app to hand its token set to the bridge over loopback. A
+// one-time pairing code, surfaced to the user via onReady, must
+// accompany the POST: it proves the sender can see the user's terminal
+// rather than merely reach the loopback interface. The endpoint accepts
+// exactly one attempt.
+func ReceiveHandoff(ctx context.Context, onReady func(code, endpoint string)) (*Tokens, error) {
+	code, err := pairingCode()
+	if err != nil {
+		return nil, err
+	}
+
+	tokensCh := make(chan *Tokens, 1)
+	errCh := make(chan error, 1)
+	var handled atomic.Bool
+	mux := http.NewServeMux()
+	mux.HandleFunc(handoffPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "POST required", http.StatusMethodNotAllowed)
+			return
+		}
+		if !handled.CompareAndSwap(false, true) {
+			http.Error(w, "handoff already completed", http.StatusBadRequest)
+			return
+		}
+		var req handoffRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid payload", http.StatusBadRequest)
+			errCh <- fmt.Errorf("decoding handoff payload: %w", err)
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(req.Code), []byte(code)) != 1 {
+			http.Error(w, "pairing code mismatch", http.StatusForbidden)
+			errCh <- fmt.Errorf("pairing code mismatch")
+			return
+		}
+		expires := req.ExpiresAt
+		if expires.IsZero() && req.ExpiresIn > 0 {
+			expires = time.Now().Add(time.Duration(req.ExpiresIn) * time.Second)
+		}
+		if req.AccessToken == "" || !expires.After(time.Now()) {
+			http.Error(w, "token missing or expired", http.StatusBadRequest)
+			errCh <- fmt.Errorf("handoff token missing or already expired")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		tokensCh <- &Tokens{
+			AccessToken:  req.AccessToken,
+			RefreshToken: req.RefreshToken,
+			IDToken:      req.IDToken,
+			ExpiresAt:    expires,
+		}
+	})
+
+	ln, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", callbackPort))
+	if err != nil {
+		return nil, fmt.Errorf("starting handoff server: %w", err)
+	}
+	srv := &http.Server{Handler: mux}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	onReady(code, fmt.Sprintf("http://localhost:%d%s", callbackPort, handoffPath))
+
+	select {
+	case t := <-tokensCh:
+		return t, nil
+	case err := <-errCh:
+		return nil, err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// pairingCode returns a six-digit code, short enough to type into the
+// app by hand.
+func pairingCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", fmt.Errorf("generating pairing code: %w", err)
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
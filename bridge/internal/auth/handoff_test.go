@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func postHandoff(t *testing.T, endpoint string, payload handoffRequest) *http.Response {
+	t.Helper()
+	body, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func TestReceiveHandoff(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ready := make(chan [2]string, 1)
+	type result struct {
+		tokens *Tokens
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		tokens, err := ReceiveHandoff(ctx, func(code, endpoint string) {
+			ready <- [2]string{code, endpoint}
+		})
+		done <- result{tokens, err}
+	}()
+
+	r := <-ready
+	code, endpoint := r[0], r[1]
+	resp := postHandoff(t, endpoint, handoffRequest{
+		Code:         code,
+		AccessToken:  "at",
+		RefreshToken: "rt",
+		ExpiresIn:    3600,
+	})
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("handoff returned %d", resp.StatusCode)
+	}
+	got := <-done
+	if got.err != nil {
+		t.Fatal(got.err)
+	}
+	if got.tokens.AccessToken != "at" || got.tokens.RefreshToken != "rt" || !got.tokens.Valid() {
+		t.Fatalf("unexpected tokens: %+v", got.tokens)
+	}
+}
+
+func TestReceiveHandoffRejectsWrongCode(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ready := make(chan string, 1)
+	done := make(chan error, 1)
+	go func() {
+		_, err := ReceiveHandoff(ctx, func(code, endpoint string) {
+			ready <- endpoint
+		})
+		done <- err
+	}()
+
+	endpoint := <-ready
+	resp := postHandoff(t, endpoint, handoffRequest{
+		Code:        "000000x",
+		AccessToken: "at",
+		ExpiresIn:   3600,
+	})
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("wrong code returned %d, want 403", resp.StatusCode)
+	}
+	if err := <-done; err == nil {
+		t.Fatal("handoff with wrong code succeeded")
+	}
+}
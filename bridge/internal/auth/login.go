@@ -0,0 +1,273 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/browser"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/clipboard"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/notify"
+)
+
+// callbackPort is the fixed localhost port Auth0 redirects back to.
+const callbackPort = 3095
+
+// Flow runs the Auth0 authorization-code-with-PKCE flow for a native app.
+type Flow struct {
+	Domain   string
+	ClientID string
+	Audience string
+	// NoBrowser skips opening the system browser; the auth URL is only
+	// reported through OnAuthURL and the log. Used when the URL is
+	// handed to the user another way, e.g. copied to the clipboard from
+	// the tray.
+	NoBrowser bool
+	// OnAuthURL, when set, receives the authorization URL once the
+	// callback listener is ready.
+	OnAuthURL func(url string)
+}
+
+// Login opens the system browser for the user to authenticate, waits for
+// the redirect on localhost, exchanges the code and returns tokens.
+func (f *Flow) Login(ctx context.Context) (*Tokens, error) {
+	verifier, err := randomString(64)
+	if err != nil {
+		return nil, err
+	}
+	state, err := randomString(32)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := randomString(32)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+	redirectURI := fmt.Sprintf("http://localhost:%d/callback", callbackPort)
+
+	authURL := fmt.Sprintf("https://%s/authorize?%s", f.Domain, url.Values{
+		"response_type":         {"code"},
+		"client_id":             {f.ClientID},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {"openid profile email offline_access"},
+		"audience":              {f.Audience},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}.Encode())
+
+	codeCh := make(chan string, 1)
+	errCh := make(chan error, 1)
+	var handled atomic.Bool
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		// The state and verifier are single-use: once a callback has been
+		// accepted, any further hit on the endpoint is rejected.
+		if !handled.CompareAndSwap(false, true) {
+			http.Error(w, "login already completed", http.StatusBadRequest)
+			return
+		}
+		q := r.URL.Query()
+		if subtle.ConstantTimeCompare([]byte(q.Get("state")), []byte(state)) != 1 {
+			http.Error(w, "state mismatch", http.StatusBadRequest)
+			errCh <- fmt.Errorf("authorization state mismatch")
+			return
+		}
+		if e := q.Get("error"); e != "" {
+			http.Error(w, e, http.StatusBadRequest)
+			errCh <- fmt.Errorf("authorization failed: %s (%s)", e, q.Get("error_description"))
+			return
+		}
+		fmt.Fprintln(w, "Login complete. You can close this tab and return to the bridge.")
+		codeCh <- q.Get("code")
+	})
+
+	// Loopback only, regardless of bridge.bind_addr: the redirect URI is
+	// registered with the identity provider as http://localhost and the
+	// browser runs on this machine, so binding wider would only expose
+	// the one-time code endpoint to the network.
+	ln, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", callbackPort))
+	if err != nil {
+		return nil, fmt.Errorf("starting callback server: %w", err)
+	}
+	srv := &http.Server{Handler: mux}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	if f.OnAuthURL != nil {
+		f.OnAuthURL(authURL)
+	}
+	if !f.NoBrowser {
+		slog.Info("Opening browser for login", "url", authURL)
+		if err := browser.Open(authURL); err != nil {
+			slog.Warn("Could not open browser; open the URL manually", "url", authURL, "error", err)
+			fmt.Fprintf(os.Stderr, "Open this URL in your browser to finish logging in:\n\n  %s\n\n", authURL)
+			if cerr := clipboard.Copy(authURL); cerr == nil {
+				fmt.Fprintln(os.Stderr, "(the URL is on your clipboard)")
+			}
+			notify.Show(notify.Notification{
+				Title: "Finish login in your browser",
+				Body:  "Opening a browser failed; the login URL is printed in the terminal and the bridge log.",
+				Event: notify.EventAuth,
+			})
+		}
+	}
+
+	var code string
+	select {
+	case code = <-codeCh:
+	case err := <-errCh:
+		return nil, err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	tokens, err := f.exchange(ctx, url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {f.ClientID},
+		"code":          {code},
+		"code_verifier": {verifier},
+		"redirect_uri":  {redirectURI},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := validateIDToken(tokens.IDToken, f.Domain, f.ClientID, nonce); err != nil {
+		return nil, fmt.Errorf("ID token validation failed: %w", err)
+	}
+	return tokens, nil
+}
+
+// validateIDToken checks the ID token claims against this login attempt:
+// the issuer must be our Auth0 tenant, the audience (and authorized
+// party, for multi-audience tokens) must be our client, and the nonce
+// must echo the one sent in the authorization request. The signature is
+// not re-verified here: the token arrives over TLS directly from the
+// tenant's token endpoint, not through the browser redirect.
+func validateIDToken(idToken, domain, clientID, nonce string) error {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("malformed ID token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return fmt.Errorf("decoding claims: %w", err)
+	}
+	var claims struct {
+		Iss   string          `json:"iss"`
+		Aud   json.RawMessage `json:"aud"`
+		Azp   string          `json:"azp"`
+		Nonce string          `json:"nonce"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return fmt.Errorf("parsing claims: %w", err)
+	}
+	if claims.Iss != "https://"+domain+"/" {
+		return fmt.Errorf("issuer %q does not match tenant %s", claims.Iss, domain)
+	}
+	// aud is a string or an array of strings per OIDC.
+	var auds []string
+	var single string
+	if json.Unmarshal(claims.Aud, &single) == nil {
+		auds = []string{single}
+	} else if err := json.Unmarshal(claims.Aud, &auds); err != nil {
+		return fmt.Errorf("parsing audience: %w", err)
+	}
+	found := false
+	for _, a := range auds {
+		if a == clientID {
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("audience does not include this client")
+	}
+	if (len(auds) > 1 || claims.Azp != "") && claims.Azp != clientID {
+		return fmt.Errorf("authorized party %q is not this client", claims.Azp)
+	}
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return fmt.Errorf("nonce mismatch")
+	}
+	return nil
+}
+
+// Refresh exchanges a refresh token for a fresh token set. The flow's
+// audience is passed along, so a refresh token minted during login can
+// also produce access tokens for a secondary audience.
+func (f *Flow) Refresh(ctx context.Context, refreshToken string) (*Tokens, error) {
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {f.ClientID},
+		"refresh_token": {refreshToken},
+	}
+	if f.Audience != "" {
+		form.Set("audience", f.Audience)
+	}
+	t, err := f.exchange(ctx, form)
+	if err != nil {
+		return nil, err
+	}
+	if t.RefreshToken == "" {
+		t.RefreshToken = refreshToken
+	}
+	return t, nil
+}
+
+func (f *Flow) exchange(ctx context.Context, form url.Values) (*Tokens, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("https://%s/oauth/token", f.Domain),
+		strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		IDToken      string `json:"id_token"`
+		ExpiresIn    int    `json:"expires_in"`
+		Error        string `json:"error"`
+		ErrorDesc    string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || body.Error != "" {
+		return nil, fmt.Errorf("token endpoint returned %d: %s %s", resp.StatusCode, body.Error, body.ErrorDesc)
+	}
+	return &Tokens{
+		AccessToken:  body.AccessToken,
+		RefreshToken: body.RefreshToken,
+		IDToken:      body.IDToken,
+		ExpiresAt:    time.Now().Add(time.Duration(body.ExpiresIn) * time.Second),
+	}, nil
+}
+
+func randomString(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generating random value: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)[:n], nil
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+)
+
+// fakeIDToken builds an unsigned JWT with the given claims; the
+// validator never checks the signature, only the claims.
+func fakeIDToken(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	return header + "." + base64.RawURLEncoding.EncodeToString(payload) + ".sig"
+}
+
+func TestValidateIDToken(t *testing.T) {
+	const (
+		domain   = "example.auth0.com"
+		clientID = "client123"
+		nonce    = "nonce456"
+	)
+	good := map[string]any{
+		"iss":   "https://example.auth0.com/",
+		"aud":   clientID,
+		"nonce": nonce,
+	}
+	if err := validateIDToken(fakeIDToken(t, good), domain, clientID, nonce); err != nil {
+		t.Fatalf("valid token rejected: %v", err)
+	}
+
+	multi := map[string]any{
+		"iss":   "https://example.auth0.com/",
+		"aud":   []string{clientID, "https://api.example.com"},
+		"azp":   clientID,
+		"nonce": nonce,
+	}
+	if err := validateIDToken(fakeIDToken(t, multi), domain, clientID, nonce); err != nil {
+		t.Fatalf("multi-audience token rejected: %v", err)
+	}
+
+	bad := []map[string]any{
+		{"iss": "https://evil.example.com/", "aud": clientID, "nonce": nonce},
+		{"iss": "https://example.auth0.com/", "aud": "other-client", "nonce": nonce},
+		{"iss": "https://example.auth0.com/", "aud": []string{clientID, "x"}, "azp": "other", "nonce": nonce},
+		{"iss": "https://example.auth0.com/", "aud": clientID, "nonce": "wrong"},
+	}
+	for i, claims := range bad {
+		if err := validateIDToken(fakeIDToken(t, claims), domain, clientID, nonce); err == nil {
+			t.Errorf("case %d: invalid token accepted", i)
+		}
+	}
+
+	if err := validateIDToken("", domain, clientID, nonce); err == nil {
+		t.Error("empty token accepted")
+	}
+}
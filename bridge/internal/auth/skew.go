@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// clockSkew is the measured offset between the relay's clock and the
+// local clock (relay minus local), applied to token expiry checks so
+// machines with a wrong clock do not refresh too late or too early.
+var clockSkew atomic.Int64
+
+// SetClockSkew records the measured clock offset.
+func SetClockSkew(d time.Duration) { clockSkew.Store(int64(d)) }
+
+// ClockSkew returns the recorded clock offset.
+func ClockSkew() time.Duration { return time.Duration(clockSkew.Load()) }
+
+// serverNow is the local time corrected by the measured skew; token
+// expiry is compared against this.
+func serverNow() time.Time { return time.Now().Add(ClockSkew()) }
+
+// MeasureSkew estimates the relay/local clock offset from the Date header
+// of the relay's health endpoint, using the request midpoint as the local
+// reference.
+func MeasureSkew(ctx context.Context, baseURL string) (time.Duration, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, baseURL+"/health", nil)
+	if err != nil {
+		return 0, err
+	}
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("querying relay health: %w", err)
+	}
+	resp.Body.Close()
+	rtt := time.Since(start)
+	serverTime, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return 0, fmt.Errorf("parsing relay Date header: %w", err)
+	}
+	local := start.Add(rtt / 2)
+	// The Date header has second granularity; ignore sub-second noise.
+	return serverTime.Sub(local).Round(time.Second), nil
+}
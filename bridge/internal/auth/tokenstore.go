@@ -0,0 +1,145 @@
+// Package auth implements the Auth0 PKCE login flow and persistent token
+// storage for the bridge.
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/logging"
+)
+
+// AllowInsecureTokenFile permits loading tokens from a world-readable
+// file whose permissions could not be repaired; set by the
+// --insecure-token-file flag.
+var AllowInsecureTokenFile bool
+
+// Tokens holds the Auth0 token set for the bridge.
+type Tokens struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	IDToken      string    `json:"id_token,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// Valid reports whether the access token exists and has not expired.
+// Expiry is evaluated against the skew-corrected clock; see MeasureSkew.
+func (t *Tokens) Valid() bool {
+	return t != nil && t.AccessToken != "" && serverNow().Before(t.ExpiresAt)
+}
+
+// registerSecrets masks the token values in all log output, so a debug
+// dump of a request or dial error never leaks them.
+func registerSecrets(t *Tokens) {
+	if t == nil {
+		return
+	}
+	logging.RegisterSecret(t.AccessToken)
+	logging.RegisterSecret(t.RefreshToken)
+	logging.RegisterSecret(t.IDToken)
+}
+
+// TokenStore persists tokens to tokens.json in the config directory.
+type TokenStore struct {
+	path string
+}
+
+// NewTokenStore returns a store writing to tokens.json under dir.
+func NewTokenStore(dir string) *TokenStore {
+	return &TokenStore{path: filepath.Join(dir, "tokens.json")}
+}
+
+// Path returns the token file location.
+func (s *TokenStore) Path() string { return s.path }
+
+// For returns a store holding tokens scoped to a secondary audience,
+// persisted alongside the primary tokens.json. The primary audience
+// keeps tokens.json itself so existing logins carry over.
+func (s *TokenStore) For(audience string) *TokenStore {
+	sum := sha256.Sum256([]byte(audience))
+	name := fmt.Sprintf("tokens-%x.json", sum[:6])
+	return &TokenStore{path: filepath.Join(filepath.Dir(s.path), name)}
+}
+
+// auditPermissions tightens overly-permissive token file modes and
+// refuses to proceed when the file stays world-readable, unless
+// AllowInsecureTokenFile is set.
+func (s *TokenStore) auditPermissions() error {
+	fi, err := os.Stat(s.path)
+	if err != nil {
+		return nil // missing file is handled by Load
+	}
+	mode := fi.Mode().Perm()
+	if mode&0o077 == 0 {
+		return nil
+	}
+	if err := os.Chmod(s.path, 0o600); err == nil {
+		slog.Warn("Tightened token file permissions", "path", s.path, "was", mode)
+		return nil
+	}
+	if mode&0o004 != 0 && !AllowInsecureTokenFile {
+		return fmt.Errorf("token file %s is world-readable (%o) and could not be repaired; "+
+			"fix its permissions or pass --insecure-token-file", s.path, mode)
+	}
+	slog.Warn("Token file has loose permissions", "path", s.path, "mode", mode)
+	return nil
+}
+
+// Load reads the stored tokens. A missing file returns (nil, nil).
+func (s *TokenStore) Load() (*Tokens, error) {
+	if err := s.auditPermissions(); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading token store: %w", err)
+	}
+	var t Tokens
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("parsing token store: %w", err)
+	}
+	registerSecrets(&t)
+	return &t, nil
+}
+
+// Save writes the tokens with owner-only permissions.
+func (s *TokenStore) Save(t *Tokens) error {
+	registerSecrets(t)
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding tokens: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0o600); err != nil {
+		return fmt.Errorf("writing token store: %w", err)
+	}
+	return nil
+}
+
+// Clear removes the stored tokens.
+func (s *TokenStore) Clear() error {
+	err := os.Remove(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// ClearAll removes the primary tokens and every per-audience entry
+// created via For.
+func (s *TokenStore) ClearAll() error {
+	matches, err := filepath.Glob(filepath.Join(filepath.Dir(s.path), "tokens-*.json"))
+	if err == nil {
+		for _, m := range matches {
+			os.Remove(m)
+		}
+	}
+	return s.Clear()
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestForDistinctAudiences(t *testing.T) {
+	dir := t.TempDir()
+	s := NewTokenStore(dir)
+	a := s.For("https://api.example.com")
+	b := s.For("https://mgmt.example.com")
+	if a.Path() == b.Path() || a.Path() == s.Path() {
+		t.Fatalf("audience stores must not share paths: %s %s %s", s.Path(), a.Path(), b.Path())
+	}
+	if s.For("https://api.example.com").Path() != a.Path() {
+		t.Fatal("For is not deterministic")
+	}
+}
+
+func TestClearAllRemovesAudienceEntries(t *testing.T) {
+	dir := t.TempDir()
+	s := NewTokenStore(dir)
+	tok := &Tokens{AccessToken: "x", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := s.Save(tok); err != nil {
+		t.Fatal(err)
+	}
+	scoped := s.For("https://mgmt.example.com")
+	if err := scoped.Save(tok); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ClearAll(); err != nil {
+		t.Fatal(err)
+	}
+	for _, st := range []*TokenStore{s, scoped} {
+		got, err := st.Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != nil {
+			t.Fatalf("tokens at %s survived ClearAll", st.Path())
+		}
+	}
+}
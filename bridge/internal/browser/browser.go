@@ -0,0 +1,46 @@
+// Package browser opens URLs in the user's default web browser.
+package browser
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/sandbox"
+)
+
+// Open launches the platform browser for the given URL. Confined Linux
+// builds (Snap, Flatpak) go through the OpenURI portal, since host
+// binaries like xdg-open are not visible inside the sandbox; WSL
+// reaches the Windows browser via wslview or a cmd.exe start.
+func Open(u string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", u).Start()
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", u).Start()
+	default:
+		if sandbox.Confined() {
+			return portalOpen(u)
+		}
+		if isWSL() {
+			if path, err := exec.LookPath("wslview"); err == nil {
+				return exec.Command(path, u).Start()
+			}
+			return exec.Command("cmd.exe", "/c", "start", u).Start()
+		}
+		return exec.Command("xdg-open", u).Start()
+	}
+}
+
+// isWSL reports whether this Linux process runs under the Windows
+// Subsystem for Linux, where no Linux browser exists but the Windows
+// one is reachable.
+func isWSL() bool {
+	if os.Getenv("WSL_DISTRO_NAME") != "" {
+		return true
+	}
+	rel, err := os.ReadFile("/proc/sys/kernel/osrelease")
+	return err == nil && strings.Contains(strings.ToLower(string(rel)), "microsoft")
+}
@@ -0,0 +1,23 @@
+//go:build linux
+
+package browser
+
+import (
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// portalOpen asks the desktop's OpenURI portal to launch the URL,
+// which works inside Snap/Flatpak sandboxes where exec'ing xdg-open
+// does not.
+func portalOpen(u string) error {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return fmt.Errorf("connecting to session bus: %w", err)
+	}
+	obj := conn.Object("org.freedesktop.portal.Desktop", "/org/freedesktop/portal/desktop")
+	call := obj.Call("org.freedesktop.portal.OpenURI.OpenURI", 0,
+		"", u, map[string]dbus.Variant{})
+	return call.Err
+}
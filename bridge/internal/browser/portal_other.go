@@ -0,0 +1,9 @@
+//go:build !linux
+
+package browser
+
+import "fmt"
+
+func portalOpen(string) error {
+	return fmt.Errorf("OpenURI portal is Linux-only")
+}
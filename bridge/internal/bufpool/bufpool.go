@@ -0,0 +1,32 @@
+// Package bufpool provides sync.Pool-backed byte buffers for request and
+// response bodies, cutting per-request allocations and GC pauses during
+// heavy embedding workloads.
+package bufpool
+
+import (
+	"bytes"
+	"sync"
+)
+
+// maxPooledSize keeps oversized buffers out of the pool so one huge
+// response does not pin memory forever.
+const maxPooledSize = 1 << 20 // 1 MiB
+
+var pool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// Get returns an empty buffer from the pool.
+func Get() *bytes.Buffer {
+	return pool.Get().(*bytes.Buffer)
+}
+
+// Put resets b and returns it to the pool. Buffers that grew past
+// maxPooledSize are dropped for the GC instead.
+func Put(b *bytes.Buffer) {
+	if b == nil || b.Cap() > maxPooledSize {
+		return
+	}
+	b.Reset()
+	pool.Put(b)
+}
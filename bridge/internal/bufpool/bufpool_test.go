@@ -0,0 +1,24 @@
+package bufpool
+
+import "testing"
+
+func TestGetPutReuse(t *testing.T) {
+	b := Get()
+	b.WriteString("hello")
+	Put(b)
+	b2 := Get()
+	if b2.Len() != 0 {
+		t.Fatalf("pooled buffer not reset: %q", b2.String())
+	}
+}
+
+func TestOversizedBufferDropped(t *testing.T) {
+	b := Get()
+	b.Grow(maxPooledSize + 1)
+	// Must not panic; the buffer is simply dropped.
+	Put(b)
+}
+
+func TestPutNil(t *testing.T) {
+	Put(nil)
+}
@@ -0,0 +1,63 @@
+// Package buildinfo extracts build metadata embedded by the Go toolchain
+// (VCS revision, build date, Go version, module dependencies) so support
+// can correlate bug reports with exact builds.
+package buildinfo
+
+import (
+	"runtime/debug"
+)
+
+// Module is one dependency of the build.
+type Module struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+}
+
+// Info is the build metadata of the running binary.
+type Info struct {
+	Commit    string   `json:"commit,omitempty"`
+	BuildDate string   `json:"build_date,omitempty"`
+	Dirty     bool     `json:"dirty,omitempty"`
+	GoVersion string   `json:"go_version"`
+	Modules   []Module `json:"modules,omitempty"`
+}
+
+// Get reads the binary's embedded build information. withModules also
+// includes the dependency list (for verbose output).
+func Get(withModules bool) Info {
+	info := Info{}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.GoVersion = bi.GoVersion
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.Commit = s.Value
+		case "vcs.time":
+			info.BuildDate = s.Value
+		case "vcs.modified":
+			info.Dirty = s.Value == "true"
+		}
+	}
+	if withModules {
+		for _, dep := range bi.Deps {
+			info.Modules = append(info.Modules, Module{Path: dep.Path, Version: dep.Version})
+		}
+	}
+	return info
+}
+
+// ShortCommit returns the commit truncated to 12 characters, with a
+// "-dirty" suffix for modified trees. Empty for non-VCS builds.
+func (i Info) ShortCommit() string {
+	c := i.Commit
+	if len(c) > 12 {
+		c = c[:12]
+	}
+	if i.Dirty && c != "" {
+		c += "-dirty"
+	}
+	return c
+}
@@ -0,0 +1,51 @@
+// Package clipboard copies text to the system clipboard through the
+// platform's standard utility, so login URLs and pairing codes can be
+// pasted into a browser — including the local browser of a
+// remote-desktop session.
+package clipboard
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Copy places text on the system clipboard. It is best-effort: on
+// headless systems without a clipboard utility an error is returned and
+// callers should fall back to printing the text.
+func Copy(text string) error {
+	cmd, err := command()
+	if err != nil {
+		return err
+	}
+	cmd.Stdin = strings.NewReader(text)
+	return cmd.Run()
+}
+
+func command() (*exec.Cmd, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("pbcopy"), nil
+	case "windows":
+		return exec.Command("clip"), nil
+	default:
+		if os.Getenv("WAYLAND_DISPLAY") != "" {
+			if path, err := exec.LookPath("wl-copy"); err == nil {
+				return exec.Command(path), nil
+			}
+		}
+		if path, err := exec.LookPath("xclip"); err == nil {
+			return exec.Command(path, "-selection", "clipboard"), nil
+		}
+		if path, err := exec.LookPath("xsel"); err == nil {
+			return exec.Command(path, "--clipboard", "--input"), nil
+		}
+		// WSL can reach the Windows clipboard.
+		if path, err := exec.LookPath("clip.exe"); err == nil {
+			return exec.Command(path), nil
+		}
+		return nil, fmt.Errorf("no clipboard utility found (wl-copy, xclip or xsel)")
+	}
+}
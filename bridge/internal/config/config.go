@@ -0,0 +1,1084 @@
+// Package config loads and validates the bridge configuration from
+// bridge.yaml in the CloudToLocalLLM config directory.
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/sandbox"
+)
+
+// Config is the top-level bridge configuration, loaded from bridge.yaml.
+type Config struct {
+	Bridge        BridgeConfig        `yaml:"bridge"`
+	Cloud         CloudConfig         `yaml:"cloud"`
+	Ollama        OllamaConfig        `yaml:"ollama"`
+	Filter        FilterConfig        `yaml:"filter"`
+	Quotas        QuotasConfig        `yaml:"quotas"`
+	Access        AccessConfig        `yaml:"access"`
+	Anomaly       AnomalyConfig       `yaml:"anomaly"`
+	Attestation   AttestationConfig   `yaml:"attestation"`
+	Logging       LoggingConfig       `yaml:"logging"`
+	Resources     ResourcesConfig     `yaml:"resources"`
+	History       HistoryConfig       `yaml:"history"`
+	Transcripts   TranscriptsConfig   `yaml:"transcripts"`
+	Telemetry     TelemetryConfig     `yaml:"telemetry"`
+	Mirror        MirrorConfig        `yaml:"mirror"`
+	Headers       HeadersConfig       `yaml:"headers"`
+	Limits        LimitsConfig        `yaml:"limits"`
+	Hardening     HardeningConfig     `yaml:"hardening"`
+	Rag           RagConfig           `yaml:"rag"`
+	Power         PowerConfig         `yaml:"power"`
+	Notifications NotificationsConfig `yaml:"notifications"`
+	Hooks         HooksConfig         `yaml:"hooks"`
+	TLS           TLSConfig           `yaml:"tls"`
+	Backends      []BackendConfig     `yaml:"backends"`
+}
+
+// BridgeConfig holds settings for the bridge process itself.
+type BridgeConfig struct {
+	// Port is the local status/control HTTP port.
+	Port int `yaml:"port"`
+	// BindAddr is the interface the status/control server and dashboard
+	// listen on. The default 127.0.0.1 keeps them off shared networks;
+	// set 0.0.0.0 (or an interface address) to expose them on the LAN
+	// deliberately. The OAuth callback listener is deliberately not
+	// covered: its redirect URI is registered with the identity provider
+	// as http://localhost and the browser always runs on this machine,
+	// so it stays loopback-only whatever bind_addr says.
+	BindAddr string `yaml:"bind_addr"`
+	// PortRange is how many consecutive ports are tried when Port is
+	// taken by another process (default 10). 1 pins the configured port
+	// and fails instead of falling back. The port actually bound is
+	// written to bridge.port next to the config for the CLI to read.
+	PortRange int `yaml:"port_range"`
+	// LogLevel is one of debug, info, warn, error.
+	LogLevel string `yaml:"log_level"`
+	// Profile selects a tuning preset: "low-resource" shrinks buffers,
+	// serializes forwarding and stretches poll intervals for small ARM
+	// boxes bridging to a separate GPU server. Empty keeps the regular
+	// defaults. See ApplyProfile.
+	Profile string `yaml:"profile"`
+	// Group names the fleet this bridge belongs to, for organizations
+	// running many bridges; it is sent with every registration so the
+	// cloud side can filter and route by group.
+	Group string `yaml:"group"`
+	// Tags are free-form labels sent alongside Group, conventionally
+	// key=value pairs such as "gpu=a6000" or "site=lab3".
+	Tags []string `yaml:"tags"`
+	// RedactPatterns are extra Go regular expressions whose matches are
+	// masked in log output and diagnostics, on top of the built-in
+	// masking of bearer tokens and JWTs.
+	RedactPatterns []string `yaml:"redact_patterns"`
+	// DataDir overrides the default data directory.
+	DataDir string `yaml:"data_dir"`
+	// IdleTimeout disconnects the tunnel after this long with no tunneled
+	// requests; zero disables idle handling.
+	IdleTimeout time.Duration `yaml:"idle_timeout"`
+	// IdleAction is what to do on idle timeout: "disconnect" (default)
+	// keeps the process alive and wakes on demand, "exit" terminates so a
+	// service manager can relaunch the bridge when needed.
+	IdleAction string `yaml:"idle_action"`
+	// AuthGrace is how long the bridge keeps retrying after token refresh
+	// starts failing (revoked or rotated refresh token) before exiting
+	// with a distinct code so supervisors surface the re-login
+	// requirement. Zero retries forever.
+	AuthGrace time.Duration `yaml:"auth_grace"`
+	// Tray shows a status icon in the desktop notification area when the
+	// desktop supports one; without support the bridge runs headless and
+	// points at the local status dashboard instead.
+	Tray bool `yaml:"tray"`
+	// PauseHotkey binds a global shortcut (portal notation, e.g.
+	// "CTRL+ALT+p") that toggles request forwarding. Empty disables the
+	// hotkey.
+	PauseHotkey string `yaml:"pause_hotkey"`
+	// TrayIconTheme picks the icon variant: "auto" (default) follows the
+	// desktop's light/dark preference, "light" and "dark" force the
+	// variant drawn for that panel background.
+	TrayIconTheme string `yaml:"tray_icon_theme"`
+}
+
+// CloudConfig holds settings for the cloud relay and Auth0.
+type CloudConfig struct {
+	// BaseURL is the cloud API base, e.g. https://api.cloudtolocalllm.online.
+	BaseURL string `yaml:"base_url"`
+	// Auth0Domain is the Auth0 tenant domain.
+	Auth0Domain string `yaml:"auth0_domain"`
+	// Auth0ClientID is the native client ID used for the PKCE flow.
+	Auth0ClientID string `yaml:"auth0_client_id"`
+	// Auth0Audience is the API audience requested for access tokens.
+	Auth0Audience string `yaml:"auth0_audience"`
+	// Auth0ManagementAudience, when set, is a separate audience used for
+	// the management API (registration, feature flags) so those calls
+	// carry a least-privilege token distinct from the relay WebSocket
+	// token. Empty reuses Auth0Audience for everything.
+	Auth0ManagementAudience string `yaml:"auth0_management_audience"`
+	// WSReadBufferSize and WSWriteBufferSize tune the WebSocket connection
+	// buffers; large embedding payloads fragment with the library defaults.
+	WSReadBufferSize  int `yaml:"ws_read_buffer_size"`
+	WSWriteBufferSize int `yaml:"ws_write_buffer_size"`
+	// WSMaxMessageSize bounds a single incoming frame from the relay;
+	// zero means unlimited.
+	WSMaxMessageSize int64 `yaml:"ws_max_message_size"`
+	// WSCompression enables per-message compression when the relay
+	// supports it.
+	WSCompression bool `yaml:"ws_compression"`
+	// WriteQueueSize bounds each priority level of the outbound write
+	// queue, in frames; zero uses the built-in default.
+	WriteQueueSize int `yaml:"write_queue_size"`
+	// WriteOverflow is what happens when a stream or bulk queue fills:
+	// "block" (default) makes the sender wait, "drop" discards the frame
+	// and surfaces an error to the request being served.
+	WriteOverflow string `yaml:"write_overflow"`
+	// APIPrefix is prepended to every relay API path (registration,
+	// wake, health, feature flags, telemetry) and the WebSocket path,
+	// for self-hosted relays that mount the API under a subpath. Must
+	// start with "/"; empty matches the hosted relay's root layout.
+	APIPrefix string `yaml:"api_prefix"`
+	// WSPath overrides the bridge WebSocket path; empty means
+	// "/ws/bridge".
+	WSPath string `yaml:"ws_path"`
+	// Insecure permits a plain-HTTP relay on non-loopback hosts and
+	// disables TLS certificate verification, for development against a
+	// self-hosted relay with self-signed certificates. Never set it
+	// across an untrusted network.
+	Insecure bool `yaml:"insecure"`
+	// Registration controls the relay's registration endpoint:
+	// "optional" (default) retries failures with backoff and keeps
+	// serving in a degraded state, "required" treats exhausted retries
+	// as a connection failure, and "off" skips the endpoint entirely for
+	// self-hosted relays that don't implement it.
+	Registration string `yaml:"registration"`
+}
+
+// OllamaConfig holds settings for the local Ollama instance.
+type OllamaConfig struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+	// Timeout bounds a single forwarded request.
+	Timeout time.Duration `yaml:"timeout"`
+	// UserAgent overrides the User-Agent sent on forwarded requests so
+	// reverse proxies in front of Ollama can attribute bridge traffic.
+	UserAgent string `yaml:"user_agent"`
+	// ExtraHeaders are added to every forwarded request.
+	ExtraHeaders map[string]string `yaml:"extra_headers"`
+	// ForwardClientInfo adds X-Forwarded-For (cloud client IP) and
+	// X-CloudToLocalLLM-User (cloud user ID) headers when the relay
+	// supplies them.
+	ForwardClientInfo bool `yaml:"forward_client_info"`
+	// MaxIdleConns caps kept-alive connections to Ollama; zero uses the
+	// built-in default. Raise it for embedding-heavy workloads that fan
+	// out many concurrent requests.
+	MaxIdleConns int `yaml:"max_idle_conns"`
+	// IdleConnTimeout closes idle Ollama connections after this long;
+	// zero uses the built-in default.
+	IdleConnTimeout time.Duration `yaml:"idle_conn_timeout"`
+	// HTTP2 attempts HTTP/2 when Ollama sits behind a TLS proxy.
+	HTTP2 bool `yaml:"http2"`
+	// RestartCommand, when set, is run through the shell when the
+	// stuck-request watchdog fires, to restart a wedged managed Ollama
+	// process (e.g. "systemctl --user restart ollama"). At most one
+	// restart is attempted per five minutes.
+	RestartCommand string `yaml:"restart_command"`
+	// WakeMAC, when set, makes the bridge send a Wake-on-LAN magic packet
+	// to this hardware address if forwarding fails, for Ollama hosts that
+	// sleep between sessions.
+	WakeMAC string `yaml:"wake_mac"`
+	// WakeTimeout is how long the woken host is given to come up; during
+	// this window failed requests are answered with a "warming up" status
+	// instead of a plain error (default 90s).
+	WakeTimeout time.Duration `yaml:"wake_timeout"`
+	// AllowPrefetch lets the relay push prefetch hints that pull or warm
+	// models in the background before first use. Off by default: a pull
+	// writes gigabytes to disk on the relay's say-so.
+	AllowPrefetch bool `yaml:"allow_prefetch"`
+	// AllowRemoteModelManagement lets the relay pull, delete and copy
+	// models through explicit model_op messages. Off by default: a
+	// compromised relay account could otherwise delete local models or
+	// fill the disk.
+	AllowRemoteModelManagement bool `yaml:"allow_remote_model_management"`
+	// PullMinFreeBytes refuses remote pulls when the models volume has
+	// less than this many bytes free (default 10 GiB; zero disables the
+	// check).
+	PullMinFreeBytes int64 `yaml:"pull_min_free_bytes"`
+}
+
+// FilterConfig configures the content filtering rules engine applied to
+// request bodies before they reach the local model.
+type FilterConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// MaxPromptLength rejects request bodies longer than this many bytes.
+	// Zero means no limit.
+	MaxPromptLength int `yaml:"max_prompt_length"`
+	// BlockedTopicsFile is an optional file with one keyword per line;
+	// requests containing any keyword are denied.
+	BlockedTopicsFile string `yaml:"blocked_topics_file"`
+	// Rules are evaluated in order; the first deny wins, redactions stack.
+	Rules []FilterRule `yaml:"rules"`
+}
+
+// FilterRule is a single content rule. Exactly one of Match (regex) or
+// Keywords should be set.
+type FilterRule struct {
+	Name     string   `yaml:"name"`
+	Match    string   `yaml:"match"`
+	Keywords []string `yaml:"keywords"`
+	// Action is allow, deny or redact. Allow short-circuits later rules.
+	Action string `yaml:"action"`
+	// Replace is the redaction replacement text (default "[redacted]").
+	Replace string `yaml:"replace"`
+}
+
+// QuotasConfig configures per-user rate limits and concurrency caps for
+// bridges shared by multiple cloud users. Requests without user identity
+// metadata are not subject to quotas.
+type QuotasConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Default applies to any user without an explicit entry in Users.
+	Default UserQuota `yaml:"default"`
+	// Users maps cloud user IDs to their individual limits.
+	Users map[string]UserQuota `yaml:"users"`
+}
+
+// UserQuota is the set of limits applied to one cloud user. Zero values
+// mean unlimited.
+type UserQuota struct {
+	RequestsPerMinute int `yaml:"requests_per_minute"`
+	MaxConcurrent     int `yaml:"max_concurrent"`
+}
+
+// AccessConfig is a local allow-list for sharing a bridge with specific
+// cloud users without handing out the owner's account. When enabled,
+// tunneled requests are checked against Users by the user identity the
+// relay attaches; requests from unlisted users (or carrying no identity)
+// follow Default. Per-user rate limits live in the quotas section and
+// combine with this list.
+type AccessConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Default decides requests from users without an entry in Users:
+	// "deny" (the default) or "allow".
+	Default string `yaml:"default"`
+	// Users maps cloud user IDs or emails to their permissions.
+	Users map[string]AccessRule `yaml:"users"`
+}
+
+// AccessRule is one listed user's permissions. A zero rule grants full
+// access.
+type AccessRule struct {
+	// Deny blocks the user outright, useful with default: allow.
+	Deny bool `yaml:"deny"`
+	// Models restricts the user to these model names; empty allows any.
+	Models []string `yaml:"models"`
+	// Paths restricts the user to these API path prefixes, e.g.
+	// /api/chat; empty allows any path.
+	Paths []string `yaml:"paths"`
+}
+
+// AnomalyConfig configures the local abuse tripwires: request-rate
+// spikes, paths outside the known API surface and repeated denied
+// requests. Alerts raise a desktop notification and the on_anomaly
+// hook; auto_pause additionally stops forwarding until resumed.
+type AnomalyConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// RateFactor flags a minute whose request count exceeds this many
+	// times the trailing ten-minute average (default 5).
+	RateFactor float64 `yaml:"rate_factor"`
+	// MinBurst is the per-minute request count below which a spike is
+	// never flagged, so quiet bridges do not alert on the first burst
+	// (default 30).
+	MinBurst int `yaml:"min_burst"`
+	// DeniedThreshold flags this many denied requests within ten
+	// minutes (default 10).
+	DeniedThreshold int `yaml:"denied_threshold"`
+	// AutoPause pauses forwarding when a tripwire fires; resume from
+	// the tray or control API after investigating.
+	AutoPause bool `yaml:"auto_pause"`
+}
+
+// LoggingConfig tunes per-route request logging. Routes are matched by
+// longest path prefix; unmatched requests log at debug without bodies.
+type LoggingConfig struct {
+	Routes []RouteLogConfig `yaml:"routes"`
+}
+
+// RouteLogConfig sets the logging verbosity for one path prefix. It
+// balances debuggability against privacy and log volume: chat traffic
+// can log bodies while debugging, embeddings can be silenced entirely.
+type RouteLogConfig struct {
+	// Path is the request path prefix this entry covers, e.g. /api/chat.
+	Path string `yaml:"path"`
+	// Level is "debug" (the default), "info", or "off" to log nothing
+	// for matching requests.
+	Level string `yaml:"level"`
+	// Bodies also logs text request bodies (truncated, after the
+	// configured redaction patterns apply). Only routes that opt in
+	// here ever log bodies.
+	Bodies bool `yaml:"bodies"`
+}
+
+// ResourcesConfig sets minimum free-resource thresholds checked before
+// resource-heavy remote operations — model pulls and submitted jobs or
+// batches — so a remote caller cannot fill the disk or starve the
+// machine. Zero disables a check; checks whose measurement fails on
+// this platform (no NVIDIA GPU, say) are skipped, not failed.
+type ResourcesConfig struct {
+	// MinFreeDiskBytes refuses pulls when the models volume has less
+	// free space than this.
+	MinFreeDiskBytes int64 `yaml:"min_free_disk_bytes"`
+	// MinFreeRAMBytes refuses pulls, jobs and batches when available
+	// physical memory is below this.
+	MinFreeRAMBytes int64 `yaml:"min_free_ram_bytes"`
+	// MinFreeVRAMBytes refuses pulls, jobs and batches when free GPU
+	// memory (summed across NVIDIA devices) is below this.
+	MinFreeVRAMBytes int64 `yaml:"min_free_vram_bytes"`
+}
+
+// AttestationConfig enables signed response attestations: each response
+// message carries the request/response digests, model and timestamp
+// signed with a bridge-held Ed25519 key, so the cloud UI can prove an
+// answer came from this machine. The key is generated on first use as
+// attestation.key next to the config.
+type AttestationConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// HistoryConfig configures the SQLite-backed request history.
+type HistoryConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// MaxEntries bounds the retained history; zero keeps everything.
+	MaxEntries int `yaml:"max_entries"`
+	// RecordBodies also stores request bodies, not just metadata.
+	RecordBodies bool `yaml:"record_bodies"`
+}
+
+// TranscriptsConfig configures the opt-in encrypted transcript store. It
+// captures prompts and generated responses, unlike history which records
+// request metadata only.
+type TranscriptsConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Retention bounds how long transcripts are kept (default 30 days);
+	// zero keeps them forever.
+	Retention time.Duration `yaml:"retention"`
+}
+
+// NotificationsConfig selects which events raise desktop notifications
+// and an optional nightly do-not-disturb window.
+type NotificationsConfig struct {
+	// Per-event switches; see DefaultNotifications for the defaults.
+	Connect    bool `yaml:"connect"`
+	Disconnect bool `yaml:"disconnect"`
+	Errors     bool `yaml:"errors"`
+	Auth       bool `yaml:"auth"`
+	Updates    bool `yaml:"updates"`
+	// DNDStart and DNDEnd suppress event notifications between these
+	// local times ("22:00", "07:00"); a window ending before it starts
+	// spans midnight. Both empty disables the schedule.
+	DNDStart string `yaml:"dnd_start"`
+	DNDEnd   string `yaml:"dnd_end"`
+}
+
+// HooksConfig lists user commands run on auth lifecycle events, for
+// integrating the bridge's auth state with external automation. Commands
+// run through the system shell with a sanitized environment carrying
+// BRIDGE_* event variables; tokens themselves are never exposed.
+type HooksConfig struct {
+	OnLogin        string `yaml:"on_login"`
+	OnLogout       string `yaml:"on_logout"`
+	OnTokenRefresh string `yaml:"on_token_refresh"`
+	// OnAuthRequired fires when token refresh fails and the user must log
+	// in again, so headless deployments can page whoever runs the bridge.
+	OnAuthRequired string `yaml:"on_auth_required"`
+	// OnAnomaly fires when an anomaly tripwire raises an alert, so a
+	// webhook or pager can follow suspected abuse of a shared bridge.
+	OnAnomaly string `yaml:"on_anomaly"`
+}
+
+// TLSConfig tunes outbound TLS for locked-down corporate environments.
+// It applies to every HTTPS client the bridge runs: the relay WebSocket
+// dialer, the Ollama client, and the Auth0 clients.
+type TLSConfig struct {
+	// MinVersion is the lowest TLS version offered: "1.2" (the Go
+	// default) or "1.3".
+	MinVersion string `yaml:"min_version"`
+	// CipherSuites restricts TLS 1.2 suites by their standard names
+	// (e.g. TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384); empty keeps the Go
+	// defaults. TLS 1.3 suites are fixed by the protocol.
+	CipherSuites []string `yaml:"cipher_suites"`
+	// CABundle is a PEM file appended to the system roots, for relays
+	// behind corporate middleboxes with private CAs.
+	CABundle string `yaml:"ca_bundle"`
+	// Listener terminates TLS on the local status/control listener, for
+	// deployments exposing it to the LAN via bridge.bind_addr.
+	Listener ListenerTLSConfig `yaml:"listener"`
+}
+
+// ListenerTLSConfig enables TLS on the status/control listener so LAN
+// clients don't get self-signed certificate warnings.
+type ListenerTLSConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// CertFile and KeyFile serve a user-provided certificate, e.g. one
+	// maintained by an external ACME DNS-01 client such as certbot or
+	// lego. The files are re-read when they change, so renewals are
+	// picked up without restarting the bridge.
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// ACMEDomain obtains and renews a Let's Encrypt certificate for
+	// this domain automatically via the TLS-ALPN-01 challenge. The
+	// domain must resolve to this host and the listener must be
+	// reachable on port 443 from the internet; for LAN-only hosts use
+	// CertFile/KeyFile with a DNS-01 client instead. Certificates are
+	// cached in acme-certs under the data directory.
+	ACMEDomain string `yaml:"acme_domain"`
+	// ACMEEmail is the account contact for expiry notices.
+	ACMEEmail string `yaml:"acme_email"`
+}
+
+// ClientConfig builds the tls.Config these settings describe; nil means
+// everything is at its default and callers should keep theirs.
+func (t TLSConfig) ClientConfig() (*tls.Config, error) {
+	if t.MinVersion == "" && len(t.CipherSuites) == 0 && t.CABundle == "" {
+		return nil, nil
+	}
+	conf := &tls.Config{}
+	switch t.MinVersion {
+	case "", "1.2":
+		conf.MinVersion = tls.VersionTLS12
+	case "1.3":
+		conf.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("tls.min_version %q invalid (want 1.2 or 1.3)", t.MinVersion)
+	}
+	if len(t.CipherSuites) > 0 {
+		ids, err := cipherSuiteIDs(t.CipherSuites)
+		if err != nil {
+			return nil, err
+		}
+		conf.CipherSuites = ids
+	}
+	if t.CABundle != "" {
+		pem, err := os.ReadFile(t.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("reading tls.ca_bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("tls.ca_bundle %s holds no usable certificates", t.CABundle)
+		}
+		conf.RootCAs = pool
+	}
+	return conf, nil
+}
+
+// cipherSuiteIDs resolves standard cipher suite names to their IDs,
+// accepting only suites Go considers secure.
+func cipherSuiteIDs(names []string) ([]uint16, error) {
+	byName := map[string]uint16{}
+	for _, s := range tls.CipherSuites() {
+		byName[s.Name] = s.ID
+	}
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("tls.cipher_suites: unknown or insecure suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// DefaultNotifications returns the notification policy used when the
+// config has no notifications section: everything except connect events,
+// which are too chatty on flaky networks.
+func DefaultNotifications() NotificationsConfig {
+	return NotificationsConfig{
+		Disconnect: true,
+		Errors:     true,
+		Auth:       true,
+		Updates:    true,
+	}
+}
+
+// PowerConfig configures battery and thermal aware throttling for bridges
+// running on laptops. Readings come from Linux sysfs; on other platforms
+// the monitor is inert.
+type PowerConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// PauseOnBattery suspends request forwarding entirely while the
+	// machine is discharging.
+	PauseOnBattery bool `yaml:"pause_on_battery"`
+	// MaxConcurrentOnBattery caps concurrent requests while on battery;
+	// zero leaves concurrency unlimited.
+	MaxConcurrentOnBattery int `yaml:"max_concurrent_on_battery"`
+	// MaxTempCelsius pauses forwarding while the hottest thermal zone is
+	// at or above this temperature; zero disables the thermal check.
+	MaxTempCelsius int `yaml:"max_temp_celsius"`
+	// CheckInterval between samples (default 30s).
+	CheckInterval time.Duration `yaml:"check_interval"`
+}
+
+// TelemetryConfig configures the opt-in upload of aggregated usage stats
+// and anonymized error summaries to the user's cloud account. Disabled by
+// default; nothing leaves the machine unless enabled is set.
+type TelemetryConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Interval between uploads (default 15m).
+	Interval time.Duration `yaml:"interval"`
+	// IncludeErrors also uploads redacted error summaries.
+	IncludeErrors bool `yaml:"include_errors"`
+	// RedactPatterns are extra regexps masked from error summaries before
+	// upload, in addition to the built-in token redactions.
+	RedactPatterns []string `yaml:"redact_patterns"`
+}
+
+// MirrorConfig configures shadow traffic mirroring: a sample of forwarded
+// requests is duplicated to a secondary backend and latency/output diffs
+// are appended to mirror.jsonl in the data directory. Mirrored responses
+// never reach the cloud client.
+type MirrorConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// BaseURL is the secondary backend, e.g. http://localhost:11435.
+	BaseURL string `yaml:"base_url"`
+	// Percent of requests to mirror, 1-100 (default 10).
+	Percent int `yaml:"percent"`
+}
+
+// HeadersConfig controls sanitization of backend response headers
+// before they go back through the tunnel, so a reverse proxy or
+// backend in front of Ollama doesn't leak internal details to cloud
+// clients.
+type HeadersConfig struct {
+	// Sanitize (default true) strips hop-by-hop headers and
+	// infrastructure-identifying ones (Server, X-Powered-By, Via,
+	// X-Real-IP, X-Forwarded-For) from responses.
+	Sanitize bool `yaml:"sanitize"`
+	// Strip removes these additional response headers, case-insensitive.
+	Strip []string `yaml:"strip"`
+	// Set forces response header values after stripping, e.g.
+	// Cache-Control: no-store.
+	Set map[string]string `yaml:"set"`
+	// RequestStrip removes these additional relay-supplied request
+	// headers before forwarding, case-insensitive. Hop-by-hop headers,
+	// Host and Content-Length are always dropped when Sanitize is on.
+	RequestStrip []string `yaml:"request_strip"`
+	// RequestSet forces request header values after stripping; it wins
+	// over both relay-supplied headers and ollama.extra_headers.
+	RequestSet map[string]string `yaml:"request_set"`
+}
+
+// LimitsConfig bounds incoming tunnel request bodies before they are
+// forwarded, protecting the bridge process from memory abuse by a
+// compromised relay account. Requests past a limit get a 413 answer.
+type LimitsConfig struct {
+	// MaxBodyBytes rejects request bodies larger than this after
+	// decoding; zero disables the check (the WebSocket frame limit
+	// still applies).
+	MaxBodyBytes int64 `yaml:"max_body_bytes"`
+	// MaxJSONDepth rejects JSON request bodies nested deeper than this
+	// many levels; zero disables the check.
+	MaxJSONDepth int `yaml:"max_json_depth"`
+	// BatchConcurrency caps how many sub-requests of one batch message
+	// are forwarded at once, whatever the message asks for (default 2).
+	BatchConcurrency int `yaml:"batch_concurrency"`
+}
+
+// HardeningConfig configures opt-in process sandboxing for users exposing
+// their workstation to cloud traffic. All options are best-effort and
+// Linux-only; unsupported platforms log and continue.
+type HardeningConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Umask for files the bridge creates, as an octal string (default
+	// "077" when hardening is enabled).
+	Umask string `yaml:"umask"`
+	// NoNewPrivs prevents the process and its children from gaining
+	// privileges (setuid binaries, capabilities).
+	NoNewPrivs bool `yaml:"no_new_privs"`
+	// Landlock confines filesystem writes to the config/data directories
+	// on kernels with Landlock support (5.13+).
+	Landlock bool `yaml:"landlock"`
+}
+
+// RagConfig configures the optional on-device document retrieval helper.
+// Watched folders are chunked and embedded via the local Ollama embedding
+// model into rag.db in the data directory; the cloud chat queries it
+// through the tunnel's /api/retrieve endpoint.
+type RagConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Folders to watch for indexable documents (txt, md, rst, csv, json).
+	Folders []string `yaml:"folders"`
+	// EmbedModel is the Ollama embedding model (default nomic-embed-text).
+	EmbedModel string `yaml:"embed_model"`
+	// ChunkSize is the target chunk length in characters (default 1000).
+	ChunkSize int `yaml:"chunk_size"`
+	// TopK is the default number of chunks returned per query (default 5).
+	TopK int `yaml:"top_k"`
+	// RescanInterval between folder scans (default 5m).
+	RescanInterval time.Duration `yaml:"rescan_interval"`
+}
+
+// BackendConfig routes tunneled request paths to an additional local
+// backend beside Ollama, e.g. a whisper.cpp server handling
+// /v1/audio/transcriptions for voice input processed on-device. The
+// longest matching route prefix wins; unmatched paths go to Ollama.
+type BackendConfig struct {
+	Name    string `yaml:"name"`
+	BaseURL string `yaml:"base_url"`
+	// Kind selects the adapter: "http" (default) proxies requests
+	// directly; "comfyui" submits jobs to a ComfyUI queue and polls until
+	// the generated image is ready.
+	Kind string `yaml:"kind"`
+	// Routes are path prefixes served by this backend.
+	Routes []string `yaml:"routes"`
+	// Rules are wildcard or regex route rules served by this backend,
+	// checked in order before the prefix Routes of all backends.
+	Rules []RouteRule `yaml:"rules"`
+	// Timeout bounds a single request (default: ollama.timeout). Polling
+	// backends use it as the overall job deadline.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// RouteRule matches tunneled request paths by wildcard or regular
+// expression and optionally rewrites them before forwarding, e.g.
+// path "/lmstudio/*" with rewrite "/v1/$1" strips the routing prefix
+// for an LM Studio backend.
+type RouteRule struct {
+	// Path matches the whole request path; each "*" matches any run of
+	// characters and is available to Rewrite as $1, $2, ...
+	Path string `yaml:"path"`
+	// Regex is a Go regular expression matched against the whole path,
+	// as an alternative to Path for rules wildcards can't express.
+	// Capture groups are available to Rewrite.
+	Regex string `yaml:"regex"`
+	// Rewrite, when set, is the forwarded path, with $1, $2, ...
+	// expanded from the match. Empty forwards the path unchanged.
+	Rewrite string `yaml:"rewrite"`
+}
+
+// Compile returns the rule's matcher, anchored to the whole path.
+func (r *RouteRule) Compile() (*regexp.Regexp, error) {
+	expr := r.Regex
+	if r.Path != "" {
+		var b strings.Builder
+		for i, part := range strings.Split(r.Path, "*") {
+			if i > 0 {
+				b.WriteString("(.*)")
+			}
+			b.WriteString(regexp.QuoteMeta(part))
+		}
+		expr = b.String()
+	}
+	return regexp.Compile("^(?:" + expr + ")$")
+}
+
+// Default returns the built-in configuration matching the hosted
+// CloudToLocalLLM service.
+func Default() *Config {
+	return &Config{
+		Bridge: BridgeConfig{
+			Port:      3025,
+			PortRange: 10,
+			BindAddr:  "127.0.0.1",
+			LogLevel:  "info",
+			AuthGrace: time.Hour,
+		},
+		Cloud: CloudConfig{
+			BaseURL:           "https://api.cloudtolocalllm.online",
+			Auth0Domain:       "dev-xafu7oedkd5wlrbo.us.auth0.com",
+			Auth0ClientID:     "",
+			Auth0Audience:     "https://app.cloudtolocalllm.online",
+			WSReadBufferSize:  32 * 1024,
+			WSWriteBufferSize: 32 * 1024,
+			WSMaxMessageSize:  32 * 1024 * 1024,
+		},
+		Ollama: OllamaConfig{
+			Host:             "localhost",
+			Port:             11434,
+			Timeout:          120 * time.Second,
+			UserAgent:        "CloudToLocalLLM-Bridge",
+			WakeTimeout:      90 * time.Second,
+			PullMinFreeBytes: 10 << 30,
+		},
+		History: HistoryConfig{
+			MaxEntries: 1000,
+		},
+		Transcripts: TranscriptsConfig{
+			Retention: 30 * 24 * time.Hour,
+		},
+		Mirror: MirrorConfig{
+			Percent: 10,
+		},
+		Headers: HeadersConfig{
+			Sanitize: true,
+		},
+		Limits: LimitsConfig{
+			MaxJSONDepth:     128,
+			BatchConcurrency: 2,
+		},
+		Power: PowerConfig{
+			CheckInterval: 30 * time.Second,
+		},
+		Notifications: DefaultNotifications(),
+		Rag: RagConfig{
+			EmbedModel:     "nomic-embed-text",
+			ChunkSize:      1000,
+			TopK:           5,
+			RescanInterval: 5 * time.Minute,
+		},
+	}
+}
+
+// Dir returns the CloudToLocalLLM config directory, creating it if needed.
+func Dir() (string, error) {
+	var dir string
+	switch {
+	case sandbox.Snap():
+		// Strict Snap confinement only grants writable storage under the
+		// snap's own user data directory.
+		dir = filepath.Join(os.Getenv("SNAP_USER_DATA"), ".cloudtolocalllm")
+	case sandbox.Flatpak():
+		// Flatpak redirects XDG_CONFIG_HOME to the app's private area;
+		// dotfiles directly under $HOME are not accessible.
+		base, err := os.UserConfigDir()
+		if err != nil {
+			return "", fmt.Errorf("resolving config directory: %w", err)
+		}
+		dir = filepath.Join(base, "cloudtolocalllm")
+	default:
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("resolving home directory: %w", err)
+		}
+		dir = filepath.Join(home, ".cloudtolocalllm")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("creating config directory: %w", err)
+	}
+	return dir, nil
+}
+
+// AuditPermissions tightens overly-permissive modes on the config
+// directory and bridge.yaml, warning when that is not possible. Token
+// file auditing lives with the token store, which can refuse to load.
+func AuditPermissions() {
+	dir, err := Dir()
+	if err != nil {
+		return
+	}
+	tighten := func(path string, want os.FileMode) {
+		fi, err := os.Stat(path)
+		if err != nil || fi.Mode().Perm()&^want == 0 {
+			return
+		}
+		if err := os.Chmod(path, want); err == nil {
+			slog.Warn("Tightened permissions", "path", path, "was", fi.Mode().Perm())
+		} else {
+			slog.Warn("Loose permissions could not be repaired",
+				"path", path, "mode", fi.Mode().Perm(), "error", err)
+		}
+	}
+	tighten(dir, 0o700)
+	tighten(filepath.Join(dir, "bridge.yaml"), 0o600)
+}
+
+// Path returns the path of bridge.yaml inside the config directory.
+func Path() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "bridge.yaml"), nil
+}
+
+// DataDir returns the directory for bridge state files (databases,
+// counters), honouring the bridge.data_dir override.
+func (c *Config) DataDir() (string, error) {
+	if c.Bridge.DataDir != "" {
+		if err := os.MkdirAll(c.Bridge.DataDir, 0o700); err != nil {
+			return "", fmt.Errorf("creating data directory: %w", err)
+		}
+		return c.Bridge.DataDir, nil
+	}
+	return Dir()
+}
+
+// Load reads bridge.yaml from path, applying defaults for missing values.
+// A missing file is not an error; defaults are returned.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate checks the configuration for values that cannot work.
+func (c *Config) Validate() error {
+	if c.Bridge.Port <= 0 || c.Bridge.Port > 65535 {
+		return fmt.Errorf("bridge.port %d out of range", c.Bridge.Port)
+	}
+	if a := c.Bridge.BindAddr; a != "" && a != "localhost" && net.ParseIP(a) == nil {
+		return fmt.Errorf("bridge.bind_addr %q is not an IP address or localhost", a)
+	}
+	if c.Bridge.PortRange < 0 {
+		return fmt.Errorf("bridge.port_range must not be negative")
+	}
+	for _, tag := range c.Bridge.Tags {
+		if strings.TrimSpace(tag) == "" {
+			return fmt.Errorf("bridge.tags must not contain empty entries")
+		}
+	}
+	switch c.Bridge.Profile {
+	case "", "low-resource":
+	default:
+		return fmt.Errorf("bridge.profile %q invalid (want low-resource)", c.Bridge.Profile)
+	}
+	if c.Ollama.Port <= 0 || c.Ollama.Port > 65535 {
+		return fmt.Errorf("ollama.port %d out of range", c.Ollama.Port)
+	}
+	switch c.Bridge.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("bridge.log_level %q invalid (want debug, info, warn or error)", c.Bridge.LogLevel)
+	}
+	switch c.Bridge.IdleAction {
+	case "", "disconnect", "exit":
+	default:
+		return fmt.Errorf("bridge.idle_action %q invalid (want disconnect or exit)", c.Bridge.IdleAction)
+	}
+	for _, expr := range c.Bridge.RedactPatterns {
+		if _, err := regexp.Compile(expr); err != nil {
+			return fmt.Errorf("bridge.redact_patterns entry %q: %w", expr, err)
+		}
+	}
+	if c.Bridge.AuthGrace < 0 {
+		return fmt.Errorf("bridge.auth_grace must not be negative")
+	}
+	switch c.Access.Default {
+	case "", "allow", "deny":
+	default:
+		return fmt.Errorf("access.default %q invalid (want allow or deny)", c.Access.Default)
+	}
+	if c.Anomaly.RateFactor < 0 || c.Anomaly.MinBurst < 0 || c.Anomaly.DeniedThreshold < 0 {
+		return fmt.Errorf("anomaly thresholds must not be negative")
+	}
+	for _, r := range c.Logging.Routes {
+		if r.Path == "" {
+			return fmt.Errorf("logging.routes entries require a path")
+		}
+		switch r.Level {
+		case "", "debug", "info", "off":
+		default:
+			return fmt.Errorf("logging.routes entry %q: level %q invalid (want debug, info or off)", r.Path, r.Level)
+		}
+	}
+	if c.Ollama.MaxIdleConns < 0 {
+		return fmt.Errorf("ollama.max_idle_conns must not be negative")
+	}
+	if c.Ollama.IdleConnTimeout < 0 {
+		return fmt.Errorf("ollama.idle_conn_timeout must not be negative")
+	}
+	switch c.Cloud.WriteOverflow {
+	case "", "block", "drop":
+	default:
+		return fmt.Errorf("cloud.write_overflow %q invalid (want block or drop)", c.Cloud.WriteOverflow)
+	}
+	switch c.Cloud.Registration {
+	case "", "optional", "required", "off":
+	default:
+		return fmt.Errorf("cloud.registration %q invalid (want optional, required or off)", c.Cloud.Registration)
+	}
+	if p := c.Cloud.APIPrefix; p != "" && (p[0] != '/' || strings.HasSuffix(p, "/")) {
+		return fmt.Errorf("cloud.api_prefix %q must start with '/' and not end with one", p)
+	}
+	if p := c.Cloud.WSPath; p != "" && p[0] != '/' {
+		return fmt.Errorf("cloud.ws_path %q must start with '/'", p)
+	}
+	if strings.HasPrefix(c.Cloud.BaseURL, "http://") && !c.Cloud.Insecure && !loopbackBase(c.Cloud.BaseURL) {
+		return fmt.Errorf("cloud.base_url %q is plain HTTP; set cloud.insecure to allow it on a trusted development network", c.Cloud.BaseURL)
+	}
+	switch c.TLS.MinVersion {
+	case "", "1.2", "1.3":
+	default:
+		return fmt.Errorf("tls.min_version %q invalid (want 1.2 or 1.3)", c.TLS.MinVersion)
+	}
+	if _, err := cipherSuiteIDs(c.TLS.CipherSuites); err != nil {
+		return err
+	}
+	if l := c.TLS.Listener; l.Enabled {
+		if (l.CertFile == "") != (l.KeyFile == "") {
+			return fmt.Errorf("tls.listener: cert_file and key_file must be set together")
+		}
+		if l.CertFile == "" && l.ACMEDomain == "" {
+			return fmt.Errorf("tls.listener: cert_file/key_file or acme_domain required")
+		}
+	}
+	for i := range c.Backends {
+		b := &c.Backends[i]
+		if b.Name == "" || b.BaseURL == "" {
+			return fmt.Errorf("backend %d: name and base_url required", i)
+		}
+		if len(b.Routes) == 0 && len(b.Rules) == 0 {
+			return fmt.Errorf("backend %q: routes or rules required", b.Name)
+		}
+		for j := range b.Rules {
+			r := &b.Rules[j]
+			if (r.Path == "") == (r.Regex == "") {
+				return fmt.Errorf("backend %q: rule %d needs exactly one of path or regex", b.Name, j)
+			}
+			if _, err := r.Compile(); err != nil {
+				return fmt.Errorf("backend %q: rule %d: %w", b.Name, j, err)
+			}
+		}
+		switch b.Kind {
+		case "", "http", "comfyui":
+		default:
+			return fmt.Errorf("backend %q: kind %q invalid (want http or comfyui)", b.Name, b.Kind)
+		}
+	}
+	if c.Transcripts.Retention < 0 {
+		return fmt.Errorf("transcripts.retention must not be negative")
+	}
+	if c.Power.MaxTempCelsius < 0 || c.Power.MaxConcurrentOnBattery < 0 {
+		return fmt.Errorf("power thresholds must not be negative")
+	}
+	if c.Ollama.PullMinFreeBytes < 0 {
+		return fmt.Errorf("ollama.pull_min_free_bytes must not be negative")
+	}
+	if c.Resources.MinFreeDiskBytes < 0 || c.Resources.MinFreeRAMBytes < 0 || c.Resources.MinFreeVRAMBytes < 0 {
+		return fmt.Errorf("resources thresholds must not be negative")
+	}
+	if c.Limits.MaxBodyBytes < 0 || c.Limits.MaxJSONDepth < 0 || c.Limits.BatchConcurrency < 0 {
+		return fmt.Errorf("limits must not be negative")
+	}
+	switch c.Bridge.TrayIconTheme {
+	case "", "auto", "light", "dark":
+	default:
+		return fmt.Errorf("bridge.tray_icon_theme %q invalid (want auto, light or dark)", c.Bridge.TrayIconTheme)
+	}
+	for _, at := range []string{c.Notifications.DNDStart, c.Notifications.DNDEnd} {
+		if at == "" {
+			continue
+		}
+		if _, err := time.Parse("15:04", at); err != nil {
+			return fmt.Errorf("notifications DND time %q invalid (want HH:MM)", at)
+		}
+	}
+	if c.Ollama.WakeMAC != "" {
+		if _, err := net.ParseMAC(c.Ollama.WakeMAC); err != nil {
+			return fmt.Errorf("ollama.wake_mac %q invalid: %w", c.Ollama.WakeMAC, err)
+		}
+	}
+	if c.Rag.Enabled && len(c.Rag.Folders) == 0 {
+		return fmt.Errorf("rag.folders required when rag is enabled")
+	}
+	if c.Hardening.Umask != "" {
+		if _, err := strconv.ParseUint(c.Hardening.Umask, 8, 12); err != nil {
+			return fmt.Errorf("hardening.umask %q invalid (want octal like 077)", c.Hardening.Umask)
+		}
+	}
+	if c.Mirror.Enabled {
+		if c.Mirror.BaseURL == "" {
+			return fmt.Errorf("mirror.base_url required when mirroring is enabled")
+		}
+		if c.Mirror.Percent < 1 || c.Mirror.Percent > 100 {
+			return fmt.Errorf("mirror.percent %d out of range (want 1-100)", c.Mirror.Percent)
+		}
+	}
+	for i := range c.Filter.Rules {
+		r := &c.Filter.Rules[i]
+		switch r.Action {
+		case "allow", "deny", "redact":
+		default:
+			return fmt.Errorf("filter rule %q: action %q invalid (want allow, deny or redact)", r.Name, r.Action)
+		}
+		if r.Match == "" && len(r.Keywords) == 0 {
+			return fmt.Errorf("filter rule %q: needs match or keywords", r.Name)
+		}
+	}
+	return nil
+}
+
+// StatusAddr returns the listen address for the status/control server
+// and dashboard, combining bridge.bind_addr (default 127.0.0.1) with
+// bridge.port.
+func (c *Config) StatusAddr() string {
+	host := c.Bridge.BindAddr
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, strconv.Itoa(c.Bridge.Port))
+}
+
+// OllamaBaseURL returns the base URL of the local Ollama server.
+func (c *Config) OllamaBaseURL() string {
+	return fmt.Sprintf("http://%s:%d", c.Ollama.Host, c.Ollama.Port)
+}
+
+// CloudBase returns the cloud API base including the configured path
+// prefix; every relay URL is derived from it.
+func (c *Config) CloudBase() string {
+	return c.Cloud.BaseURL + c.Cloud.APIPrefix
+}
+
+// CloudURL joins a relay API path onto CloudBase.
+func (c *Config) CloudURL(path string) string {
+	return c.CloudBase() + path
+}
+
+// WebSocketURL returns the relay bridge WebSocket endpoint derived from
+// the cloud base URL, honoring the API prefix and ws_path overrides.
+func (c *Config) WebSocketURL() string {
+	u := c.CloudBase()
+	switch {
+	case strings.HasPrefix(u, "https://"):
+		u = "wss://" + u[8:]
+	case strings.HasPrefix(u, "http://"):
+		u = "ws://" + u[7:]
+	}
+	path := c.Cloud.WSPath
+	if path == "" {
+		path = "/ws/bridge"
+	}
+	return u + path
+}
+
+// loopbackBase reports whether the URL's host is a loopback address; the
+// mock relay and local development listen there over plain HTTP.
+func loopbackBase(raw string) bool {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	host := parsed.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ApplyProfile applies the bridge.profile preset to every setting the
+// user left at its stock default, so explicit configuration always wins
+// over the profile. The "low-resource" profile trims memory use for
+// small ARM boxes: smaller WebSocket buffers and frame limit, a tiny
+// Ollama connection pool, a shorter history and relaxed poll intervals.
+func (c *Config) ApplyProfile() {
+	if c.Bridge.Profile != "low-resource" {
+		return
+	}
+	def := Default()
+	if c.Cloud.WSReadBufferSize == def.Cloud.WSReadBufferSize {
+		c.Cloud.WSReadBufferSize = 8 * 1024
+	}
+	if c.Cloud.WSWriteBufferSize == def.Cloud.WSWriteBufferSize {
+		c.Cloud.WSWriteBufferSize = 8 * 1024
+	}
+	if c.Cloud.WSMaxMessageSize == def.Cloud.WSMaxMessageSize {
+		c.Cloud.WSMaxMessageSize = 8 * 1024 * 1024
+	}
+	if c.Ollama.MaxIdleConns == def.Ollama.MaxIdleConns {
+		c.Ollama.MaxIdleConns = 2
+	}
+	if c.History.MaxEntries == def.History.MaxEntries {
+		c.History.MaxEntries = 200
+	}
+	if c.Power.CheckInterval == def.Power.CheckInterval {
+		c.Power.CheckInterval = 2 * time.Minute
+	}
+	if c.Rag.RescanInterval == def.Rag.RescanInterval {
+		c.Rag.RescanInterval = 30 * time.Minute
+	}
+}
+
+// LowMemoryMachine reports whether the host has little physical memory
+// (under roughly 1.5 GiB), so `bridge run` can suggest the low-resource
+// profile. It reads /proc/meminfo and reports false where that does not
+// exist.
+func LowMemoryMachine() bool {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			kb, err := strconv.ParseInt(fields[1], 10, 64)
+			return err == nil && kb > 0 && kb < 1536*1024
+		}
+	}
+	return false
+}
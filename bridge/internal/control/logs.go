@@ -0,0 +1,108 @@
+package control
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/logging"
+)
+
+// handleLogsTail serves captured log records as JSON. Query parameters:
+// after (sequence number of the last record already shown), level
+// (minimum level) and q (case-insensitive substring filter).
+func (s *Server) handleLogsTail(w http.ResponseWriter, r *http.Request) {
+	after, _ := strconv.ParseInt(r.URL.Query().Get("after"), 10, 64)
+	minLevel := logging.ParseLevel(r.URL.Query().Get("level"))
+	if r.URL.Query().Get("level") == "" {
+		minLevel = logging.ParseLevel("debug")
+	}
+	entries, last := logging.Buffer.Tail(after, minLevel, r.URL.Query().Get("q"))
+	if entries == nil {
+		entries = []logging.Entry{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"entries": entries, "last": last})
+}
+
+// handleStatusLogs serves the last n in-memory log records as JSON, for
+// headless users and the diagnostics bundle. Query parameter n bounds
+// how many lines come back (default 200).
+func (s *Server) handleStatusLogs(w http.ResponseWriter, r *http.Request) {
+	n, _ := strconv.Atoi(r.URL.Query().Get("n"))
+	if n <= 0 {
+		n = 200
+	}
+	entries := logging.Buffer.Last(n)
+	if entries == nil {
+		entries = []logging.Entry{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"entries": entries})
+}
+
+// handleLogs serves the log viewer page: a live tail of the in-memory
+// ring with level filtering and search, so support threads don't need to
+// walk users through finding log files.
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(logViewerHTML))
+}
+
+const logViewerHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>CloudToLocalLLM Bridge Logs</title>
+<style>
+body { font-family: monospace; margin: 0; background: #1e1e1e; color: #ddd; }
+header { position: sticky; top: 0; padding: 8px; background: #2a2a2a; }
+header input, header select { font-family: inherit; background: #1e1e1e; color: #ddd; border: 1px solid #555; padding: 4px; }
+#log { padding: 8px; white-space: pre-wrap; word-break: break-word; }
+.debug { color: #888; } .info { color: #ddd; } .warn { color: #e6c150; } .error { color: #e06060; }
+</style>
+</head>
+<body>
+<header>
+  <select id="level">
+    <option value="debug">debug</option>
+    <option value="info" selected>info</option>
+    <option value="warn">warn</option>
+    <option value="error">error</option>
+  </select>
+  <input id="q" placeholder="search" size="30">
+  <label><input type="checkbox" id="follow" checked> follow</label>
+</header>
+<div id="log"></div>
+<script>
+let after = 0;
+const log = document.getElementById('log');
+function reset() { after = 0; log.textContent = ''; }
+document.getElementById('level').addEventListener('change', reset);
+document.getElementById('q').addEventListener('change', reset);
+async function poll() {
+  const level = document.getElementById('level').value;
+  const q = encodeURIComponent(document.getElementById('q').value);
+  try {
+    const resp = await fetch('/logs/tail?after=' + after + '&level=' + level + '&q=' + q);
+    const data = await resp.json();
+    for (const e of data.entries) {
+      const line = document.createElement('div');
+      line.className = e.level;
+      let attrs = '';
+      for (const k in (e.attrs || {})) attrs += ' ' + k + '=' + e.attrs[k];
+      line.textContent = e.time.replace('T', ' ').slice(0, 19) + ' ' + e.level.toUpperCase() + ' ' + e.message + attrs;
+      log.appendChild(line);
+    }
+    after = data.last;
+    if (data.entries.length && document.getElementById('follow').checked) {
+      window.scrollTo(0, document.body.scrollHeight);
+    }
+  } catch (err) { /* bridge restarting; keep polling */ }
+  setTimeout(poll, 2000);
+}
+poll();
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package control
+
+import "net/http"
+
+// servePipe is Windows-only; on other platforms the TCP listener (plus
+// systemd socket activation) is the whole control surface.
+func (s *Server) servePipe(http.Handler) {}
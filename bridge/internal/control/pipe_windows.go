@@ -0,0 +1,63 @@
+package control
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+
+	winio "github.com/Microsoft/go-winio"
+	"golang.org/x/sys/windows"
+)
+
+// PipePath is the named pipe the control API additionally listens on,
+// so CLI subcommands and the tray reach a running bridge without going
+// through the TCP listener (and whatever firewall policy applies to it).
+const PipePath = `\\.\pipe\cloudtolocalllm-bridge`
+
+// servePipe serves the control API on the named pipe, ACLed to the
+// current user (and SYSTEM), mirroring the 0600 permissions a Unix
+// socket would carry. Failures are logged and non-fatal; the TCP
+// listener remains the primary interface.
+func (s *Server) servePipe(handler http.Handler) {
+	sddl, err := currentUserSDDL()
+	if err != nil {
+		slog.Warn("Control pipe disabled: resolving current user failed", "error", err)
+		return
+	}
+	ln, err := winio.ListenPipe(PipePath, &winio.PipeConfig{SecurityDescriptor: sddl})
+	if err != nil {
+		slog.Warn("Control pipe disabled", "path", PipePath, "error", err)
+		return
+	}
+	slog.Info("Control named pipe listening", "path", PipePath)
+	go func() {
+		if err := http.Serve(ln, handler); err != nil {
+			slog.Warn("Control pipe server failed", "error", err)
+		}
+	}()
+}
+
+// currentUserSDDL builds a security descriptor granting full pipe
+// access to the current user and SYSTEM only.
+func currentUserSDDL() (string, error) {
+	tu, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("D:P(A;;GA;;;SY)(A;;GA;;;%s)", tu.User.Sid.String()), nil
+}
+
+// PipeClient returns an HTTP client that talks to the control API over
+// the named pipe. URLs still use the http://localhost form; only the
+// underlying connection goes through the pipe.
+func PipeClient() *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return winio.DialPipeContext(ctx, PipePath)
+			},
+		},
+	}
+}
@@ -0,0 +1,45 @@
+package control
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+)
+
+// portFileName is the runtime file next to the config recording which
+// port the status server actually bound, so the CLI still finds the
+// bridge after a port-conflict fallback.
+const portFileName = "bridge.port"
+
+// writePortFile persists the bound status port for ActivePort.
+func writePortFile(port int) error {
+	dir, err := config.Dir()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, portFileName), []byte(strconv.Itoa(port)+"\n"), 0o644)
+}
+
+// ActivePort returns the port the running bridge's status server is
+// bound to: the one recorded in bridge.port when that file exists and
+// parses, otherwise the configured bridge.port. A stale file from a
+// crashed bridge at worst points the CLI at a closed port, which fails
+// the same way the configured port would.
+func ActivePort(cfg *config.Config) int {
+	dir, err := config.Dir()
+	if err != nil {
+		return cfg.Bridge.Port
+	}
+	data, err := os.ReadFile(filepath.Join(dir, portFileName))
+	if err != nil {
+		return cfg.Bridge.Port
+	}
+	port, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || port < 1 || port > 65535 {
+		return cfg.Bridge.Port
+	}
+	return port
+}
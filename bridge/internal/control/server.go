@@ -0,0 +1,369 @@
+// Package control serves the local status HTTP API used by the CLI, tray
+// and monitoring tools.
+package control
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/lockdown"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/logging"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/systemd"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/tunnel"
+)
+
+// Server is the local status/control HTTP server.
+type Server struct {
+	addr   string
+	tunnel *tunnel.Tunnel
+	// Token, when set, must accompany /control/* and /debug/* requests
+	// in the TokenHeader header; see LoadOrCreateToken.
+	Token string
+	// StatusToken, when set, grants read-only access to the status and
+	// log endpoints for non-loopback clients; see LoadOrCreateStatusToken.
+	// It never authorizes control actions.
+	StatusToken string
+	// TLS, when set, terminates TLS on the listener; see ListenerTLS.
+	TLS *tls.Config
+	// PortRange is how many consecutive ports Run tries when the
+	// configured one is taken; values below 1 try only the configured
+	// port. The bound port is persisted for ActivePort.
+	PortRange int
+	// activePort is the port the listener actually bound.
+	activePort int
+	// OnShutdown, when set, is invoked by POST /control/shutdown to stop
+	// the bridge gracefully (used by `bridge run --takeover`).
+	OnShutdown func()
+}
+
+// NewServer returns a Server exposing the given tunnel's state on the
+// given listen address (host:port).
+func NewServer(addr string, t *tunnel.Tunnel) *Server {
+	return &Server{addr: addr, tunnel: t}
+}
+
+// Run serves the status API until the listener fails.
+func (s *Server) Run() error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", s.handleStatus)
+	mux.HandleFunc("/status/logs", s.handleStatusLogs)
+	mux.HandleFunc("/logs", s.handleLogs)
+	mux.HandleFunc("/logs/tail", s.handleLogsTail)
+	mux.HandleFunc("/control/ollama", s.handleSetOllama)
+	mux.HandleFunc("/control/requests", s.handleRequests)
+	mux.HandleFunc("/control/loglevel", s.handleLogLevel)
+	mux.HandleFunc("/control/requests/cancel", s.handleCancelRequests)
+	mux.HandleFunc("/control/wake", s.handleWake)
+	mux.HandleFunc("/control/lockdown", s.handleLockdown)
+	mux.HandleFunc("/control/shutdown", s.handleShutdown)
+	if os.Getenv("BRIDGE_CHAOS") == "1" {
+		slog.Warn("Chaos endpoint enabled (BRIDGE_CHAOS=1); do not use in production")
+		mux.HandleFunc("/debug/chaos", s.handleChaos)
+	}
+	handler := s.protect(mux)
+	s.servePipe(handler)
+	if ln, ok := systemd.ActivationListener(); ok {
+		slog.Info("Status server using socket-activated listener", "addr", ln.Addr())
+		if s.TLS != nil {
+			ln = tls.NewListener(ln, s.TLS)
+		}
+		return http.Serve(ln, handler)
+	}
+	ln, err := s.listen()
+	if err != nil {
+		return err
+	}
+	if s.TLS != nil {
+		ln = tls.NewListener(ln, s.TLS)
+	}
+	slog.Info("Status server listening", "addr", ln.Addr(), "tls", s.TLS != nil)
+	return http.Serve(ln, handler)
+}
+
+// listen binds the configured address, falling back through PortRange
+// consecutive ports when the configured one is occupied by another
+// process. The chosen port is written to bridge.port so the CLI finds
+// the running bridge; see ActivePort.
+func (s *Server) listen() (net.Listener, error) {
+	host, portStr, err := net.SplitHostPort(s.addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid listen address %q: %w", s.addr, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid listen port %q: %w", portStr, err)
+	}
+	attempts := s.PortRange
+	if attempts < 1 {
+		attempts = 1
+	}
+	var lastErr error
+	for i := 0; i < attempts && port+i <= 65535; i++ {
+		ln, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port+i)))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if i > 0 {
+			slog.Warn("Configured status port is taken; using fallback",
+				"configured", port, "port", port+i)
+		}
+		s.activePort = port + i
+		if err := writePortFile(s.activePort); err != nil {
+			slog.Warn("Persisting active port failed", "error", err)
+		}
+		return ln, nil
+	}
+	return nil, fmt.Errorf("no free port in %d-%d: %w", port, port+attempts-1, lastErr)
+}
+
+// protect rejects cross-origin browser requests and unauthenticated
+// control requests. Any request carrying a non-loopback Origin header
+// comes from a web page, which has no business here; /control/* and
+// /debug/* additionally require the control token so pages that forge
+// or omit Origin still cannot drive the bridge. Read-only endpoints
+// stay open to loopback clients (the dashboard runs in a browser) but
+// need the status or control token once the server is bound to a LAN
+// interface.
+func (s *Server) protect(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if o := r.Header.Get("Origin"); o != "" && !loopbackOrigin(o) {
+			http.Error(w, "cross-origin request rejected", http.StatusForbidden)
+			return
+		}
+		got := r.Header.Get(TokenHeader)
+		if strings.HasPrefix(r.URL.Path, "/control/") || strings.HasPrefix(r.URL.Path, "/debug/") {
+			// Control actions require the control token specifically;
+			// the read-only status token does not escalate.
+			if s.Token != "" && !tokenMatches(got, s.Token) {
+				http.Error(w, "missing or invalid control token", http.StatusUnauthorized)
+				return
+			}
+		} else if !loopbackAddr(r.RemoteAddr) {
+			if s.Token != "" || s.StatusToken != "" {
+				if !tokenMatches(got, s.StatusToken) && !tokenMatches(got, s.Token) {
+					http.Error(w, "missing or invalid status token", http.StatusUnauthorized)
+					return
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// tokenMatches compares a presented token against a configured one in
+// constant time; an unset configured token never matches.
+func tokenMatches(got, want string) bool {
+	return want != "" && subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		tunnel.Status
+		// Port is the status port actually bound, which differs from
+		// the configured one after a port-conflict fallback.
+		Port int `json:"port,omitempty"`
+	}{s.tunnel.Status(), s.activePort})
+}
+
+// handleWake reconnects an idle tunnel immediately.
+func (s *Server) handleWake(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.tunnel.Wake()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"state": string(s.tunnel.Status().State)})
+}
+
+// handleLockdown engages or lifts the emergency lockdown: engaging
+// cancels all in-flight requests, pauses forwarding, persists the
+// lockdown across restarts and asks the relay to revoke this bridge's
+// session. A body of {"unlock": true} lifts it again.
+func (s *Server) handleLockdown(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		Unlock bool `json:"unlock"`
+	}
+	// An empty body means engage.
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if req.Unlock {
+		if err := lockdown.Lift(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.tunnel.Unlock()
+		json.NewEncoder(w).Encode(map[string]string{"state": "unlocked"})
+		return
+	}
+	cancelled := s.tunnel.Lockdown()
+	if err := lockdown.Engage(); err != nil {
+		// The in-memory lockdown holds either way; a restart would lose it.
+		slog.Error("Persisting lockdown marker failed", "error", err)
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	if err := s.tunnel.RevokeSession(ctx); err != nil {
+		slog.Warn("Relay session revocation failed; lockdown holds locally", "error", err)
+	}
+	json.NewEncoder(w).Encode(map[string]any{"state": "locked", "cancelled": cancelled})
+}
+
+// handleChaos reads or replaces the fault injection configuration. Only
+// registered when BRIDGE_CHAOS=1 is set in the environment.
+func (s *Server) handleChaos(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var cfg tunnel.ChaosConfig
+		if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		s.tunnel.SetChaos(cfg)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.tunnel.Chaos())
+}
+
+// handleLogLevel reads or changes the stderr log level at runtime. A
+// POST with minutes set enables debug logging that reverts itself, for
+// support sessions.
+func (s *Server) handleLogLevel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req struct {
+			Level   string `json:"level"`
+			Minutes int    `json:"minutes"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		if req.Minutes > 0 {
+			logging.DebugFor(time.Duration(req.Minutes) * time.Minute)
+			break
+		}
+		switch strings.ToLower(req.Level) {
+		case "debug", "info", "warn", "error":
+			logging.SetLevel(req.Level)
+			slog.Info("Log level changed via control API", "level", req.Level)
+		default:
+			http.Error(w, "level must be debug, info, warn or error", http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"level": logging.Level()})
+}
+
+// handleRequests lists requests currently being forwarded, oldest first.
+func (s *Server) handleRequests(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"requests": s.tunnel.InflightRequests()})
+}
+
+// handleCancelRequests aborts one in-flight request by ID, every request
+// from a cloud user, or all of them — the kill-switch for a runaway
+// generation hogging the machine.
+func (s *Server) handleCancelRequests(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		ID   string `json:"id"`
+		User string `json:"user"`
+		All  bool   `json:"all"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	var cancelled int
+	switch {
+	case req.ID != "":
+		if s.tunnel.CancelRequest(req.ID) {
+			cancelled = 1
+		}
+	case req.User != "":
+		cancelled = s.tunnel.CancelRequests(req.User)
+	case req.All:
+		cancelled = s.tunnel.CancelRequests("")
+	default:
+		http.Error(w, "set id, user or all", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"cancelled": cancelled})
+}
+
+// handleShutdown stops the bridge gracefully so another instance can take
+// over the instance lock.
+func (s *Server) handleShutdown(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.OnShutdown == nil {
+		http.Error(w, "shutdown not supported", http.StatusNotImplemented)
+		return
+	}
+	slog.Info("Shutdown requested via control API")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"state": "stopping"})
+	go s.OnShutdown()
+}
+
+// handleSetOllama swaps the Ollama target at runtime without dropping the
+// cloud tunnel.
+func (s *Server) handleSetOllama(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		Host string `json:"host"`
+		Port int    `json:"port"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if req.Host == "" || req.Port <= 0 || req.Port > 65535 {
+		http.Error(w, "host and port (1-65535) required", http.StatusBadRequest)
+		return
+	}
+	s.tunnel.SetOllamaTarget(req.Host, req.Port)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"ollama_target": fmt.Sprintf("http://%s:%d", req.Host, req.Port),
+	})
+}
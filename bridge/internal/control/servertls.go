@@ -0,0 +1,85 @@
+package control
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+)
+
+// ListenerTLS builds the server TLS configuration described by
+// tls.listener, or nil when it is disabled. ACME certificates are
+// obtained and renewed by an autocert manager caching under the data
+// directory; file-based certificates are reloaded when they change.
+func ListenerTLS(cfg *config.Config) (*tls.Config, error) {
+	l := cfg.TLS.Listener
+	if !l.Enabled {
+		return nil, nil
+	}
+	if l.ACMEDomain != "" {
+		dir, err := cfg.DataDir()
+		if err != nil {
+			return nil, err
+		}
+		cacheDir := filepath.Join(dir, "acme-certs")
+		if err := os.MkdirAll(cacheDir, 0o700); err != nil {
+			return nil, fmt.Errorf("creating ACME cache: %w", err)
+		}
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(l.ACMEDomain),
+			Cache:      autocert.DirCache(cacheDir),
+			Email:      l.ACMEEmail,
+		}
+		return m.TLSConfig(), nil
+	}
+	loader := &certReloader{certFile: l.CertFile, keyFile: l.KeyFile}
+	if _, err := loader.load(); err != nil {
+		return nil, fmt.Errorf("loading listener certificate: %w", err)
+	}
+	return &tls.Config{GetCertificate: loader.get}, nil
+}
+
+// certReloader serves a certificate pair from disk, re-reading it when
+// the cert file's modification time changes so external renewal (e.g. a
+// DNS-01 client) takes effect without a restart.
+type certReloader struct {
+	certFile, keyFile string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+func (c *certReloader) get(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.load()
+}
+
+func (c *certReloader) load() (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	info, err := os.Stat(c.certFile)
+	if err == nil && c.cert != nil && info.ModTime().Equal(c.modTime) {
+		return c.cert, nil
+	}
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		if c.cert != nil {
+			// Keep serving the previous pair through a half-written
+			// renewal; the next handshake retries.
+			return c.cert, nil
+		}
+		return nil, err
+	}
+	c.cert = &cert
+	if info != nil {
+		c.modTime = info.ModTime()
+	}
+	return c.cert, nil
+}
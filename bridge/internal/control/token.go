@@ -0,0 +1,81 @@
+package control
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+)
+
+// TokenHeader carries the control API token on requests to /control/*
+// and /debug/* endpoints.
+const TokenHeader = "X-Bridge-Token"
+
+// LoadOrCreateToken returns the per-install control API token, creating
+// control.token next to bridge.yaml on first use. The token stops web
+// pages from driving the bridge through localhost requests: a browser
+// will happily POST to http://localhost:3025, but it cannot read the
+// token file.
+func LoadOrCreateToken() (string, error) {
+	return loadOrCreateToken("control.token")
+}
+
+// LoadOrCreateStatusToken returns the read-only status API token from
+// status.token. It grants access to the /status and /logs endpoints
+// when the server is exposed beyond loopback, but not to /control/*
+// actions — hand it to monitoring agents instead of the control token.
+func LoadOrCreateStatusToken() (string, error) {
+	return loadOrCreateToken("status.token")
+}
+
+func loadOrCreateToken(name string) (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, name)
+	if b, err := os.ReadFile(path); err == nil && len(b) > 0 {
+		return string(b), nil
+	}
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generating control token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+	if err := os.WriteFile(path, []byte(token), 0o600); err != nil {
+		return "", fmt.Errorf("writing control token: %w", err)
+	}
+	return token, nil
+}
+
+// loopbackOrigin reports whether a request Origin header names a
+// loopback host. Cross-site requests (including DNS-rebinding pages,
+// whose Origin keeps the attacker's hostname) fail this check.
+func loopbackOrigin(origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// loopbackAddr reports whether a host:port remote address is loopback,
+// e.g. a client on the same machine rather than the LAN.
+func loopbackAddr(remote string) bool {
+	host, _, err := net.SplitHostPort(remote)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,9 @@
+// Package disk reports free space on the volume holding a path, used to
+// guard disk-heavy operations like remote model pulls.
+package disk
+
+// FreeBytes returns the bytes available to the current user on the
+// volume containing path.
+func FreeBytes(path string) (int64, error) {
+	return freeBytes(path)
+}
@@ -0,0 +1,191 @@
+// Package doctor runs the diagnostic checks behind `bridge doctor`,
+// covering configuration, the local Ollama server, the cloud relay, Auth0
+// and stored tokens.
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/auth"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/ollama"
+)
+
+// Result is the outcome of a single check.
+type Result struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+	// Hint suggests how to fix a failing check.
+	Hint string `json:"hint,omitempty"`
+}
+
+// maxClockSkew is how far the local clock may drift from the relay's
+// before token expiry handling becomes unreliable.
+const maxClockSkew = 30 * time.Second
+
+// Run executes all checks and returns their results in a stable order.
+func Run(ctx context.Context, cfg *config.Config, store *auth.TokenStore) []Result {
+	checks := []func(context.Context, *config.Config, *auth.TokenStore) Result{
+		checkConfig,
+		checkOllama,
+		checkRelayDNS,
+		checkRelayWebSocket,
+		checkAuth0Discovery,
+		checkToken,
+		checkStatusPort,
+		checkClockSkew,
+	}
+	results := make([]Result, 0, len(checks))
+	for _, c := range checks {
+		results = append(results, c(ctx, cfg, store))
+	}
+	return results
+}
+
+func checkConfig(_ context.Context, cfg *config.Config, _ *auth.TokenStore) Result {
+	if err := cfg.Validate(); err != nil {
+		return Result{Name: "config", Detail: err.Error(),
+			Hint: "fix the reported field in bridge.yaml"}
+	}
+	return Result{Name: "config", OK: true, Detail: "bridge.yaml valid"}
+}
+
+func checkOllama(ctx context.Context, cfg *config.Config, _ *auth.TokenStore) Result {
+	client := ollama.NewClient(cfg.OllamaBaseURL(), 5*time.Second)
+	version, err := client.Version(ctx)
+	if err != nil {
+		return Result{Name: "ollama", Detail: err.Error(),
+			Hint: fmt.Sprintf("is Ollama running at %s? Try `ollama serve`", cfg.OllamaBaseURL())}
+	}
+	return Result{Name: "ollama", OK: true, Detail: "reachable, " + strings.TrimSpace(version)}
+}
+
+func relayHost(cfg *config.Config) string {
+	host := strings.TrimPrefix(strings.TrimPrefix(cfg.Cloud.BaseURL, "https://"), "http://")
+	return strings.SplitN(host, "/", 2)[0]
+}
+
+func checkRelayDNS(ctx context.Context, cfg *config.Config, _ *auth.TokenStore) Result {
+	host := relayHost(cfg)
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return Result{Name: "relay-dns", Detail: err.Error(),
+			Hint: "check your network connection and DNS settings"}
+	}
+	return Result{Name: "relay-dns", OK: true,
+		Detail: fmt.Sprintf("%s resolves to %s", host, strings.Join(addrs, ", "))}
+}
+
+func checkRelayWebSocket(ctx context.Context, cfg *config.Config, _ *auth.TokenStore) Result {
+	dialCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	// Without a token the relay refuses the upgrade; any HTTP-level answer
+	// still proves DNS, TLS and the WebSocket endpoint are working.
+	conn, resp, err := websocket.DefaultDialer.DialContext(dialCtx, cfg.WebSocketURL(), nil)
+	if conn != nil {
+		conn.Close()
+		return Result{Name: "relay-websocket", OK: true, Detail: "handshake succeeded"}
+	}
+	if resp != nil {
+		return Result{Name: "relay-websocket", OK: true,
+			Detail: fmt.Sprintf("endpoint reachable (handshake refused with %d, expected without token)", resp.StatusCode)}
+	}
+	return Result{Name: "relay-websocket", Detail: err.Error(),
+		Hint: "check firewall/proxy settings for WebSocket (wss) traffic"}
+}
+
+func checkAuth0Discovery(ctx context.Context, cfg *config.Config, _ *auth.TokenStore) Result {
+	url := fmt.Sprintf("https://%s/.well-known/openid-configuration", cfg.Cloud.Auth0Domain)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Result{Name: "auth0-discovery", Detail: err.Error()}
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return Result{Name: "auth0-discovery", Detail: err.Error(),
+			Hint: "check that cloud.auth0_domain is correct and reachable"}
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Result{Name: "auth0-discovery", Detail: fmt.Sprintf("returned %d", resp.StatusCode),
+			Hint: "check that cloud.auth0_domain is correct"}
+	}
+	return Result{Name: "auth0-discovery", OK: true, Detail: "OpenID configuration available"}
+}
+
+func checkToken(_ context.Context, _ *config.Config, store *auth.TokenStore) Result {
+	tokens, err := store.Load()
+	if err != nil {
+		return Result{Name: "token", Detail: err.Error(),
+			Hint: "token store unreadable; re-run `bridge login`"}
+	}
+	if tokens == nil {
+		return Result{Name: "token", Detail: "no stored tokens",
+			Hint: "run `bridge login`"}
+	}
+	if !tokens.Valid() {
+		if tokens.RefreshToken != "" {
+			return Result{Name: "token", OK: true,
+				Detail: "access token expired but refresh token present"}
+		}
+		return Result{Name: "token", Detail: "access token expired, no refresh token",
+			Hint: "run `bridge login`"}
+	}
+	return Result{Name: "token", OK: true,
+		Detail: fmt.Sprintf("valid until %s", tokens.ExpiresAt.Local().Format(time.RFC3339))}
+}
+
+func checkStatusPort(_ context.Context, cfg *config.Config, _ *auth.TokenStore) Result {
+	ln, err := net.Listen("tcp", cfg.StatusAddr())
+	if err == nil {
+		ln.Close()
+		return Result{Name: "status-port", OK: true,
+			Detail: fmt.Sprintf("port %d free", cfg.Bridge.Port)}
+	}
+	// The port being taken by a running bridge is fine; anything else is not.
+	resp, herr := (&http.Client{Timeout: 2 * time.Second}).Get(
+		fmt.Sprintf("http://localhost:%d/status", cfg.Bridge.Port))
+	if herr == nil {
+		resp.Body.Close()
+		return Result{Name: "status-port", OK: true,
+			Detail: fmt.Sprintf("port %d in use by a running bridge", cfg.Bridge.Port)}
+	}
+	return Result{Name: "status-port", Detail: err.Error(),
+		Hint: fmt.Sprintf("another process is using port %d; change bridge.port", cfg.Bridge.Port)}
+}
+
+func checkClockSkew(ctx context.Context, cfg *config.Config, _ *auth.TokenStore) Result {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cfg.CloudURL("/health"), nil)
+	if err != nil {
+		return Result{Name: "clock-skew", Detail: err.Error()}
+	}
+	before := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return Result{Name: "clock-skew", Detail: "relay unreachable: " + err.Error(),
+			Hint: "skipped; fix relay connectivity first"}
+	}
+	defer resp.Body.Close()
+	serverTime, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return Result{Name: "clock-skew", Detail: "relay sent no Date header"}
+	}
+	// Split the round trip evenly; good enough to detect minute-level drift.
+	local := before.Add(time.Since(before) / 2)
+	skew := local.Sub(serverTime)
+	if math.Abs(skew.Seconds()) > maxClockSkew.Seconds() {
+		return Result{Name: "clock-skew", Detail: fmt.Sprintf("local clock off by about %s", skew.Round(time.Second)),
+			Hint: "enable NTP time synchronization; token expiry will misbehave otherwise"}
+	}
+	return Result{Name: "clock-skew", OK: true,
+		Detail: fmt.Sprintf("within %s of relay time", maxClockSkew)}
+}
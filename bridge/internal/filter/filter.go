@@ -0,0 +1,153 @@
+// Package filter implements the content filtering rules engine applied to
+// request bodies before they are forwarded to the local model. Rules come
+// from the filter section of bridge.yaml and an optional blocked topics
+// file, and support allow, deny and redact actions.
+package filter
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+)
+
+// Action is the outcome of evaluating a body against the rules.
+type Action int
+
+const (
+	// Allow passes the body through, possibly redacted.
+	Allow Action = iota
+	// Deny rejects the request before it reaches the model.
+	Deny
+)
+
+// Result describes what the engine decided for one request body.
+type Result struct {
+	Action Action
+	// Rule is the name of the rule that denied the request, if any.
+	Rule string
+	// Body is the (possibly redacted) body to forward when allowed.
+	Body []byte
+	// Redacted reports whether any redaction rule fired.
+	Redacted bool
+}
+
+type compiledRule struct {
+	name    string
+	re      *regexp.Regexp
+	action  string
+	replace string
+}
+
+// Engine evaluates configured rules against request bodies.
+type Engine struct {
+	enabled   bool
+	maxLength int
+	rules     []compiledRule
+}
+
+// New compiles the configured rules into an Engine. Keyword lists are
+// compiled into case-insensitive word-boundary regexps; the blocked topics
+// file contributes one deny keyword per non-empty, non-comment line.
+func New(cfg config.FilterConfig) (*Engine, error) {
+	e := &Engine{enabled: cfg.Enabled, maxLength: cfg.MaxPromptLength}
+	for _, r := range cfg.Rules {
+		cr, err := compile(r)
+		if err != nil {
+			return nil, err
+		}
+		e.rules = append(e.rules, cr)
+	}
+	if cfg.BlockedTopicsFile != "" {
+		topics, err := loadTopics(cfg.BlockedTopicsFile)
+		if err != nil {
+			return nil, err
+		}
+		if len(topics) > 0 {
+			cr, err := compile(config.FilterRule{
+				Name:     "blocked-topics",
+				Keywords: topics,
+				Action:   "deny",
+			})
+			if err != nil {
+				return nil, err
+			}
+			e.rules = append(e.rules, cr)
+		}
+	}
+	return e, nil
+}
+
+func compile(r config.FilterRule) (compiledRule, error) {
+	pattern := r.Match
+	if pattern == "" {
+		escaped := make([]string, len(r.Keywords))
+		for i, kw := range r.Keywords {
+			escaped[i] = regexp.QuoteMeta(kw)
+		}
+		pattern = `(?i)\b(?:` + strings.Join(escaped, "|") + `)\b`
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return compiledRule{}, fmt.Errorf("filter rule %q: %w", r.Name, err)
+	}
+	replace := r.Replace
+	if replace == "" {
+		replace = "[redacted]"
+	}
+	return compiledRule{name: r.Name, re: re, action: r.Action, replace: replace}, nil
+}
+
+func loadTopics(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening blocked topics file: %w", err)
+	}
+	defer f.Close()
+
+	var topics []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		topics = append(topics, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading blocked topics file: %w", err)
+	}
+	return topics, nil
+}
+
+// Enabled reports whether filtering is active.
+func (e *Engine) Enabled() bool { return e != nil && e.enabled }
+
+// Apply evaluates body against the engine's rules.
+func (e *Engine) Apply(body []byte) Result {
+	if !e.Enabled() {
+		return Result{Action: Allow, Body: body}
+	}
+	if e.maxLength > 0 && len(body) > e.maxLength {
+		return Result{Action: Deny, Rule: "max-prompt-length"}
+	}
+	res := Result{Action: Allow, Body: body}
+	for _, r := range e.rules {
+		if !r.re.Match(res.Body) {
+			continue
+		}
+		switch r.action {
+		case "allow":
+			return res
+		case "deny":
+			return Result{Action: Deny, Rule: r.name}
+		case "redact":
+			res.Body = r.re.ReplaceAll(res.Body, []byte(r.replace))
+			res.Redacted = true
+		}
+	}
+	return res
+}
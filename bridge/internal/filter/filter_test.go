@@ -0,0 +1,103 @@
+package filter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+)
+
+func TestApplyDisabled(t *testing.T) {
+	e, err := New(config.FilterConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := []byte(`{"prompt":"anything"}`)
+	res := e.Apply(body)
+	if res.Action != Allow || string(res.Body) != string(body) {
+		t.Fatalf("disabled engine modified request: %+v", res)
+	}
+}
+
+func TestApplyDenyKeyword(t *testing.T) {
+	e, err := New(config.FilterConfig{
+		Enabled: true,
+		Rules: []config.FilterRule{
+			{Name: "no-gambling", Keywords: []string{"poker", "roulette"}, Action: "deny"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := e.Apply([]byte(`{"prompt":"teach me Poker strategy"}`))
+	if res.Action != Deny || res.Rule != "no-gambling" {
+		t.Fatalf("expected deny by no-gambling, got %+v", res)
+	}
+	res = e.Apply([]byte(`{"prompt":"teach me chess"}`))
+	if res.Action != Allow {
+		t.Fatalf("expected allow, got %+v", res)
+	}
+}
+
+func TestApplyRedact(t *testing.T) {
+	e, err := New(config.FilterConfig{
+		Enabled: true,
+		Rules: []config.FilterRule{
+			{Name: "ssn", Match: `\d{3}-\d{2}-\d{4}`, Action: "redact", Replace: "XXX"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := e.Apply([]byte(`{"prompt":"my ssn is 123-45-6789"}`))
+	if res.Action != Allow || !res.Redacted {
+		t.Fatalf("expected redacted allow, got %+v", res)
+	}
+	if string(res.Body) != `{"prompt":"my ssn is XXX"}` {
+		t.Fatalf("unexpected redacted body: %s", res.Body)
+	}
+}
+
+func TestApplyMaxPromptLength(t *testing.T) {
+	e, err := New(config.FilterConfig{Enabled: true, MaxPromptLength: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := e.Apply([]byte(`{"prompt":"this is way past the limit"}`))
+	if res.Action != Deny || res.Rule != "max-prompt-length" {
+		t.Fatalf("expected length deny, got %+v", res)
+	}
+}
+
+func TestBlockedTopicsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "topics.txt")
+	if err := os.WriteFile(path, []byte("# comment\n\nweapons\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	e, err := New(config.FilterConfig{Enabled: true, BlockedTopicsFile: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := e.Apply([]byte(`{"prompt":"tell me about WEAPONS"}`))
+	if res.Action != Deny || res.Rule != "blocked-topics" {
+		t.Fatalf("expected blocked-topics deny, got %+v", res)
+	}
+}
+
+func TestAllowShortCircuits(t *testing.T) {
+	e, err := New(config.FilterConfig{
+		Enabled: true,
+		Rules: []config.FilterRule{
+			{Name: "trusted", Keywords: []string{"homework"}, Action: "allow"},
+			{Name: "blocked", Keywords: []string{"homework"}, Action: "deny"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := e.Apply([]byte(`{"prompt":"help with homework"}`))
+	if res.Action != Allow {
+		t.Fatalf("allow rule should short-circuit deny, got %+v", res)
+	}
+}
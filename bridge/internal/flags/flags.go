@@ -0,0 +1,102 @@
+// Package flags is a lightweight feature flag client. Account-level flags
+// are fetched from the relay after registration and cached locally, so
+// experimental code paths can be staged across the bridge install base
+// and the last known flags survive offline starts.
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Client caches feature flags fetched from the relay.
+type Client struct {
+	path string
+
+	mu     sync.Mutex
+	values map[string]bool
+}
+
+// NewClient returns a Client backed by the cache file at path, loading
+// any previously fetched flags.
+func NewClient(path string) *Client {
+	c := &Client{path: path, values: make(map[string]bool)}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		var cached map[string]bool
+		if json.Unmarshal(data, &cached) == nil {
+			c.values = cached
+		}
+	}
+	return c
+}
+
+// Enabled reports whether the named flag is on. Unknown flags are off.
+func (c *Client) Enabled(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[name]
+}
+
+// All returns a copy of the current flag set.
+func (c *Client) All() map[string]bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]bool, len(c.values))
+	for k, v := range c.values {
+		out[k] = v
+	}
+	return out
+}
+
+// Fetch retrieves the account's flags from the relay and updates the
+// local cache. Relays without the endpoint leave the cached flags as-is.
+func (c *Client) Fetch(ctx context.Context, baseURL, token string) error {
+	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, baseURL+"/api/bridge/flags", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetching feature flags: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("feature flag endpoint returned %d", resp.StatusCode)
+	}
+	var body struct {
+		Flags map[string]bool `json:"flags"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("parsing feature flags: %w", err)
+	}
+
+	c.mu.Lock()
+	c.values = body.Flags
+	c.mu.Unlock()
+	c.persist(body.Flags)
+	slog.Debug("Feature flags updated", "count", len(body.Flags))
+	return nil
+}
+
+func (c *Client) persist(values map[string]bool) {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return
+	}
+	if err := os.WriteFile(c.path, data, 0o600); err != nil {
+		slog.Warn("Caching feature flags failed", "error", err)
+	}
+}
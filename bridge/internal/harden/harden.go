@@ -0,0 +1,43 @@
+// Package harden applies opt-in process sandboxing: restrictive umask,
+// no-new-privileges and a Landlock filesystem confinement. Everything is
+// best-effort; missing kernel support degrades to a log line rather than
+// a startup failure.
+package harden
+
+import (
+	"log/slog"
+	"strconv"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+)
+
+// Apply enables the configured hardening measures. writableDirs are the
+// directories the bridge must keep write access to (config and data
+// directories) when Landlock confinement is enabled.
+func Apply(cfg config.HardeningConfig, writableDirs []string) {
+	if !cfg.Enabled {
+		return
+	}
+	umask := cfg.Umask
+	if umask == "" {
+		umask = "077"
+	}
+	mask, err := strconv.ParseUint(umask, 8, 12)
+	if err == nil {
+		setUmask(int(mask))
+	}
+	if cfg.NoNewPrivs {
+		if err := setNoNewPrivs(); err != nil {
+			slog.Warn("Enabling no_new_privs failed", "error", err)
+		} else {
+			slog.Info("Hardening: no_new_privs enabled")
+		}
+	}
+	if cfg.Landlock {
+		if err := applyLandlock(writableDirs); err != nil {
+			slog.Warn("Applying Landlock confinement failed; continuing unconfined", "error", err)
+		} else {
+			slog.Info("Hardening: Landlock filesystem confinement active", "writable", writableDirs)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !linux
+
+package harden
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+func setUmask(mask int) {
+	slog.Debug("Umask hardening not supported on this platform")
+}
+
+func setNoNewPrivs() error {
+	return fmt.Errorf("no_new_privs is Linux-only")
+}
+
+func applyLandlock(writableDirs []string) error {
+	return fmt.Errorf("Landlock is Linux-only")
+}
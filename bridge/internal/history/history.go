@@ -0,0 +1,213 @@
+// Package history persists tunneled request metadata to a local SQLite
+// database so users can analyze their bridge usage with `bridge history`.
+package history
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Entry is one recorded request.
+type Entry struct {
+	ID         string    `json:"id"`
+	Timestamp  time.Time `json:"timestamp"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Model      string    `json:"model,omitempty"`
+	UserID     string    `json:"user_id,omitempty"`
+	StatusCode int       `json:"status_code"`
+	DurationMS int64     `json:"duration_ms"`
+	Body       string    `json:"body,omitempty"`
+}
+
+// Filter narrows List and export queries. Zero values match everything.
+type Filter struct {
+	Since  time.Time
+	Model  string
+	Status int
+	Limit  int
+}
+
+// Store is a SQLite-backed request history with a bounded length.
+type Store struct {
+	db         *sql.DB
+	maxEntries int
+}
+
+// Open opens (creating if necessary) the history database at path.
+// maxEntries bounds the retained history; zero keeps everything.
+func Open(path string, maxEntries int) (*Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("opening history database: %w", err)
+	}
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS requests (
+			id          TEXT PRIMARY KEY,
+			ts          INTEGER NOT NULL,
+			method      TEXT NOT NULL,
+			path        TEXT NOT NULL,
+			model       TEXT NOT NULL DEFAULT '',
+			user_id     TEXT NOT NULL DEFAULT '',
+			status_code INTEGER NOT NULL,
+			duration_ms INTEGER NOT NULL,
+			body        TEXT NOT NULL DEFAULT ''
+		);
+		CREATE INDEX IF NOT EXISTS requests_ts ON requests(ts);
+	`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating history schema: %w", err)
+	}
+	return &Store{db: db, maxEntries: maxEntries}, nil
+}
+
+// Close closes the underlying database.
+func (s *Store) Close() error { return s.db.Close() }
+
+// Record inserts one entry and prunes the oldest rows past the configured
+// maximum.
+func (s *Store) Record(e Entry) error {
+	_, err := s.db.Exec(
+		`INSERT OR REPLACE INTO requests (id, ts, method, path, model, user_id, status_code, duration_ms, body)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.ID, e.Timestamp.UnixMilli(), e.Method, e.Path, e.Model, e.UserID, e.StatusCode, e.DurationMS, e.Body)
+	if err != nil {
+		return fmt.Errorf("recording request: %w", err)
+	}
+	if s.maxEntries > 0 {
+		_, err = s.db.Exec(
+			`DELETE FROM requests WHERE id NOT IN
+			 (SELECT id FROM requests ORDER BY ts DESC LIMIT ?)`, s.maxEntries)
+		if err != nil {
+			return fmt.Errorf("pruning history: %w", err)
+		}
+	}
+	return nil
+}
+
+func (f Filter) where() (string, []any) {
+	clause := "WHERE 1=1"
+	var args []any
+	if !f.Since.IsZero() {
+		clause += " AND ts >= ?"
+		args = append(args, f.Since.UnixMilli())
+	}
+	if f.Model != "" {
+		clause += " AND model = ?"
+		args = append(args, f.Model)
+	}
+	if f.Status != 0 {
+		clause += " AND status_code = ?"
+		args = append(args, f.Status)
+	}
+	return clause, args
+}
+
+// List returns entries matching the filter, newest first. Bodies are not
+// included; use Get for a single full entry.
+func (s *Store) List(f Filter) ([]Entry, error) {
+	clause, args := f.where()
+	q := `SELECT id, ts, method, path, model, user_id, status_code, duration_ms
+	      FROM requests ` + clause + ` ORDER BY ts DESC`
+	if f.Limit > 0 {
+		q += " LIMIT " + strconv.Itoa(f.Limit)
+	}
+	rows, err := s.db.Query(q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying history: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Entry
+	for rows.Next() {
+		var e Entry
+		var ts int64
+		if err := rows.Scan(&e.ID, &ts, &e.Method, &e.Path, &e.Model, &e.UserID, &e.StatusCode, &e.DurationMS); err != nil {
+			return nil, err
+		}
+		e.Timestamp = time.UnixMilli(ts)
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// Get returns the full entry for one request ID, including the body when
+// bodies were recorded.
+func (s *Store) Get(id string) (*Entry, error) {
+	var e Entry
+	var ts int64
+	err := s.db.QueryRow(
+		`SELECT id, ts, method, path, model, user_id, status_code, duration_ms, body
+		 FROM requests WHERE id = ?`, id).
+		Scan(&e.ID, &ts, &e.Method, &e.Path, &e.Model, &e.UserID, &e.StatusCode, &e.DurationMS, &e.Body)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("no history entry with id %s", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	e.Timestamp = time.UnixMilli(ts)
+	return &e, nil
+}
+
+// ExportCSV writes matching entries to w as CSV, newest first.
+func (s *Store) ExportCSV(w io.Writer, f Filter) error {
+	entries, err := s.List(f)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"id", "timestamp", "method", "path", "model", "user_id", "status_code", "duration_ms"}); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := cw.Write([]string{
+			e.ID,
+			e.Timestamp.UTC().Format(time.RFC3339),
+			e.Method,
+			e.Path,
+			e.Model,
+			e.UserID,
+			strconv.Itoa(e.StatusCode),
+			strconv.FormatInt(e.DurationMS, 10),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ExportJSONL writes matching entries to w as one JSON object per line.
+func (s *Store) ExportJSONL(w io.Writer, f Filter) error {
+	entries, err := s.List(f)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ModelFromBody extracts the "model" field from a JSON request body, used
+// to tag history entries.
+func ModelFromBody(body []byte) string {
+	var partial struct {
+		Model string `json:"model"`
+	}
+	if err := json.Unmarshal(body, &partial); err != nil {
+		return ""
+	}
+	return partial.Model
+}
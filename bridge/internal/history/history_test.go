@@ -0,0 +1,124 @@
+package history
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T, maxEntries int) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "history.db"), maxEntries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func entry(id string, ts time.Time, model string, status int) Entry {
+	return Entry{
+		ID:         id,
+		Timestamp:  ts,
+		Method:     "POST",
+		Path:       "/api/generate",
+		Model:      model,
+		StatusCode: status,
+		DurationMS: 42,
+	}
+}
+
+func TestRecordAndList(t *testing.T) {
+	s := openTestStore(t, 0)
+	now := time.Now()
+	for i, m := range []string{"llama3", "mistral", "llama3"} {
+		if err := s.Record(entry(string(rune('a'+i)), now.Add(time.Duration(i)*time.Second), m, 200)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	all, err := s.List(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 || all[0].ID != "c" {
+		t.Fatalf("expected 3 entries newest first, got %+v", all)
+	}
+	byModel, err := s.List(Filter{Model: "llama3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byModel) != 2 {
+		t.Fatalf("expected 2 llama3 entries, got %d", len(byModel))
+	}
+	since, err := s.List(Filter{Since: now.Add(1500 * time.Millisecond)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(since) != 1 || since[0].ID != "c" {
+		t.Fatalf("expected only newest entry, got %+v", since)
+	}
+}
+
+func TestPruneToMaxEntries(t *testing.T) {
+	s := openTestStore(t, 2)
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := s.Record(entry(string(rune('a'+i)), now.Add(time.Duration(i)*time.Second), "m", 200)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	all, err := s.List(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 || all[0].ID != "e" || all[1].ID != "d" {
+		t.Fatalf("expected newest 2 entries, got %+v", all)
+	}
+}
+
+func TestGetIncludesBody(t *testing.T) {
+	s := openTestStore(t, 0)
+	e := entry("x", time.Now(), "llama3", 200)
+	e.Body = `{"model":"llama3","prompt":"hi"}`
+	if err := s.Record(e); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Get("x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Body != e.Body {
+		t.Fatalf("body not round-tripped: %q", got.Body)
+	}
+}
+
+func TestExportFormats(t *testing.T) {
+	s := openTestStore(t, 0)
+	if err := s.Record(entry("x", time.Now(), "llama3", 200)); err != nil {
+		t.Fatal(err)
+	}
+	var csvBuf, jsonlBuf bytes.Buffer
+	if err := s.ExportCSV(&csvBuf, Filter{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(csvBuf.String(), "llama3") {
+		t.Fatalf("csv export missing entry: %s", csvBuf.String())
+	}
+	if err := s.ExportJSONL(&jsonlBuf, Filter{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(jsonlBuf.String(), `"model":"llama3"`) {
+		t.Fatalf("jsonl export missing entry: %s", jsonlBuf.String())
+	}
+}
+
+func TestModelFromBody(t *testing.T) {
+	if m := ModelFromBody([]byte(`{"model":"llama3","prompt":"hi"}`)); m != "llama3" {
+		t.Fatalf("got %q", m)
+	}
+	if m := ModelFromBody([]byte("not json")); m != "" {
+		t.Fatalf("got %q", m)
+	}
+}
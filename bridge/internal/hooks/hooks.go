@@ -0,0 +1,131 @@
+// Package hooks executes user-configured commands on auth lifecycle
+// events, so external automation (unlocking a reverse proxy, rotating
+// firewall rules) can follow the bridge's auth state.
+package hooks
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+)
+
+// hookTimeout bounds a single hook invocation.
+const hookTimeout = 30 * time.Second
+
+var (
+	mu  sync.Mutex
+	cfg config.HooksConfig
+)
+
+// Set installs the configured hook commands.
+func Set(c config.HooksConfig) {
+	mu.Lock()
+	cfg = c
+	mu.Unlock()
+}
+
+// Login fires the on_login hook after a successful browser login.
+func Login(expiresAt time.Time) {
+	mu.Lock()
+	command := cfg.OnLogin
+	mu.Unlock()
+	fire("login", command, map[string]string{
+		"BRIDGE_TOKEN_EXPIRES_AT": expiresAt.Format(time.RFC3339),
+	})
+}
+
+// Logout fires the on_logout hook after stored tokens are removed.
+func Logout() {
+	mu.Lock()
+	command := cfg.OnLogout
+	mu.Unlock()
+	fire("logout", command, nil)
+}
+
+// TokenRefresh fires the on_token_refresh hook after a silent token
+// renewal.
+func TokenRefresh(audience string, expiresAt time.Time) {
+	mu.Lock()
+	command := cfg.OnTokenRefresh
+	mu.Unlock()
+	fire("token_refresh", command, map[string]string{
+		"BRIDGE_TOKEN_AUDIENCE":   audience,
+		"BRIDGE_TOKEN_EXPIRES_AT": expiresAt.Format(time.RFC3339),
+	})
+}
+
+// AuthRequired fires the on_auth_required hook when token refresh fails
+// and re-login is needed.
+func AuthRequired(reason string) {
+	mu.Lock()
+	command := cfg.OnAuthRequired
+	mu.Unlock()
+	fire("auth_required", command, map[string]string{
+		"BRIDGE_AUTH_REASON": reason,
+	})
+}
+
+// Anomaly fires the on_anomaly hook when an abuse tripwire raises an
+// alert.
+func Anomaly(kind, detail string) {
+	mu.Lock()
+	command := cfg.OnAnomaly
+	mu.Unlock()
+	fire("anomaly", command, map[string]string{
+		"BRIDGE_ANOMALY_KIND":   kind,
+		"BRIDGE_ANOMALY_DETAIL": detail,
+	})
+}
+
+// fire runs command through the system shell with a sanitized
+// environment. Failures are logged, never fatal: auth itself must not
+// depend on user automation.
+func fire(event, command string, extra map[string]string) {
+	if command == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "/bin/sh", "-c", command)
+	}
+	cmd.Env = sanitizedEnv(event, extra)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		slog.Warn("Auth hook failed", "event", event, "error", err,
+			"output", strings.TrimSpace(string(out)))
+		return
+	}
+	slog.Debug("Auth hook completed", "event", event)
+}
+
+// sanitizedEnv builds the hook environment from a small allowlist of the
+// parent environment plus the BRIDGE_* event variables. Token values are
+// deliberately absent.
+func sanitizedEnv(event string, extra map[string]string) []string {
+	allow := []string{
+		"PATH", "HOME", "USER", "LOGNAME", "SHELL", "LANG", "TMPDIR",
+		"SystemRoot", "ComSpec", "USERPROFILE", "TEMP",
+	}
+	env := make([]string, 0, len(allow)+len(extra)+1)
+	for _, k := range allow {
+		if v, ok := os.LookupEnv(k); ok {
+			env = append(env, k+"="+v)
+		}
+	}
+	env = append(env, "BRIDGE_AUTH_EVENT="+event)
+	for k, v := range extra {
+		env = append(env, k+"="+v)
+	}
+	return env
+}
@@ -0,0 +1,31 @@
+package hooks
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizedEnv(t *testing.T) {
+	t.Setenv("SECRET_API_KEY", "hunter2")
+	env := sanitizedEnv("login", map[string]string{"BRIDGE_TOKEN_AUDIENCE": "aud"})
+	var sawEvent, sawExtra bool
+	for _, kv := range env {
+		if strings.HasPrefix(kv, "SECRET_API_KEY=") {
+			t.Error("non-allowlisted variable leaked into hook environment")
+		}
+		if kv == "BRIDGE_AUTH_EVENT=login" {
+			sawEvent = true
+		}
+		if kv == "BRIDGE_TOKEN_AUDIENCE=aud" {
+			sawExtra = true
+		}
+	}
+	if !sawEvent || !sawExtra {
+		t.Fatalf("event variables missing from environment: %v", env)
+	}
+}
+
+func TestFireEmptyCommandIsNoop(t *testing.T) {
+	// Must return without executing anything.
+	fire("login", "", nil)
+}
@@ -0,0 +1,5 @@
+// Package hotkey binds a global keyboard shortcut that toggles request
+// forwarding, so the GPU can be reclaimed instantly without opening the
+// tray menu. Linux uses the desktop's GlobalShortcuts portal; other
+// platforms have no implementation yet.
+package hotkey
@@ -0,0 +1,116 @@
+//go:build linux
+
+package hotkey
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	portalDest  = "org.freedesktop.portal.Desktop"
+	portalPath  = "/org/freedesktop/portal/desktop"
+	portalIface = "org.freedesktop.portal.GlobalShortcuts"
+	shortcutID  = "toggle-pause"
+)
+
+// Run registers the shortcut with the GlobalShortcuts portal and calls
+// onToggle on each activation until the context is canceled. trigger is a
+// preferred binding in portal notation, e.g. "CTRL+ALT+p"; the desktop
+// may let the user rebind it.
+func Run(ctx context.Context, trigger string, onToggle func()) error {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return fmt.Errorf("connecting to session bus: %w", err)
+	}
+
+	if err := conn.AddMatchSignal(
+		dbus.WithMatchInterface("org.freedesktop.portal.Request"),
+		dbus.WithMatchMember("Response"),
+	); err != nil {
+		return fmt.Errorf("subscribing to portal responses: %w", err)
+	}
+	if err := conn.AddMatchSignal(
+		dbus.WithMatchInterface(portalIface),
+		dbus.WithMatchMember("Activated"),
+	); err != nil {
+		return fmt.Errorf("subscribing to shortcut activations: %w", err)
+	}
+	signals := make(chan *dbus.Signal, 16)
+	conn.Signal(signals)
+
+	portal := conn.Object(portalDest, portalPath)
+	err = portal.Call(portalIface+".CreateSession", 0, map[string]dbus.Variant{
+		"handle_token":         dbus.MakeVariant("cloudtolocalllm_req"),
+		"session_handle_token": dbus.MakeVariant("cloudtolocalllm"),
+	}).Err
+	if err != nil {
+		return fmt.Errorf("creating portal session (is a shortcuts portal running?): %w", err)
+	}
+
+	session, err := awaitResponse(ctx, signals, "session_handle")
+	if err != nil {
+		return fmt.Errorf("waiting for portal session: %w", err)
+	}
+
+	shortcuts := []struct {
+		ID    string
+		Props map[string]dbus.Variant
+	}{{
+		ID: shortcutID,
+		Props: map[string]dbus.Variant{
+			"description":       dbus.MakeVariant("Toggle CloudToLocalLLM bridge pause"),
+			"preferred_trigger": dbus.MakeVariant(trigger),
+		},
+	}}
+	err = portal.Call(portalIface+".BindShortcuts", 0,
+		dbus.ObjectPath(session), shortcuts, "",
+		map[string]dbus.Variant{"handle_token": dbus.MakeVariant("cloudtolocalllm_bind")}).Err
+	if err != nil {
+		return fmt.Errorf("binding shortcut: %w", err)
+	}
+	slog.Info("Global pause hotkey registered", "trigger", trigger)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case sig := <-signals:
+			if sig == nil || sig.Name != portalIface+".Activated" || len(sig.Body) < 2 {
+				continue
+			}
+			if id, ok := sig.Body[1].(string); ok && id == shortcutID {
+				onToggle()
+			}
+		}
+	}
+}
+
+// awaitResponse waits for the next portal Request.Response signal and
+// extracts the named result entry.
+func awaitResponse(ctx context.Context, signals chan *dbus.Signal, key string) (string, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case sig := <-signals:
+			if sig == nil || sig.Name != "org.freedesktop.portal.Request.Response" || len(sig.Body) < 2 {
+				continue
+			}
+			code, _ := sig.Body[0].(uint32)
+			if code != 0 {
+				return "", fmt.Errorf("portal request denied (code %d)", code)
+			}
+			results, _ := sig.Body[1].(map[string]dbus.Variant)
+			if v, ok := results[key]; ok {
+				if s, ok := v.Value().(string); ok {
+					return s, nil
+				}
+			}
+			return "", fmt.Errorf("portal response missing %q", key)
+		}
+	}
+}
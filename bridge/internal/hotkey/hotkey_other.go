@@ -0,0 +1,13 @@
+//go:build !linux
+
+package hotkey
+
+import (
+	"context"
+	"fmt"
+)
+
+// Run is unavailable without a shortcuts portal.
+func Run(ctx context.Context, trigger string, onToggle func()) error {
+	return fmt.Errorf("global hotkeys are not implemented on this platform")
+}
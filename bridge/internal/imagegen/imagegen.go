@@ -0,0 +1,150 @@
+// Package imagegen adapts local image generation servers to the tunnel's
+// request/response forwarding interface. ComfyUI queues generation jobs
+// and expects the caller to poll for the result, which does not map onto
+// the tunnel's single request/response exchange; the adapter hides the
+// queue by submitting the job, polling until the image is rendered and
+// returning the binary image as the response body.
+package imagegen
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/ollama"
+)
+
+// pollInterval is how often the adapter checks a queued ComfyUI job.
+const pollInterval = 2 * time.Second
+
+// Comfy forwards requests to a ComfyUI server. POST /prompt submissions
+// are turned into a blocking generate-and-fetch exchange; every other
+// path is proxied unchanged so the UI can list models, samplers and
+// queue state directly.
+type Comfy struct {
+	client  *ollama.Client
+	timeout time.Duration
+}
+
+// NewComfy returns an adapter for the ComfyUI server at baseURL. timeout
+// bounds the whole job from submission to the finished image.
+func NewComfy(baseURL string, timeout time.Duration) *Comfy {
+	return &Comfy{
+		// The job outlives any single poll request, so the underlying
+		// client gets a short per-request timeout and the adapter
+		// enforces the overall deadline itself.
+		client:  ollama.NewClient(baseURL, 30*time.Second),
+		timeout: timeout,
+	}
+}
+
+// Forward proxies the request to ComfyUI. Prompt submissions block until
+// the queued job finishes and answer with the generated image.
+func (c *Comfy) Forward(ctx context.Context, method, path string, headers map[string]string, body []byte) (*ollama.Response, error) {
+	if method == http.MethodPost && path == "/prompt" {
+		return c.generate(ctx, headers, body)
+	}
+	return c.client.Forward(ctx, method, path, headers, body)
+}
+
+// ForwardStream proxies a streaming request unchanged. ComfyUI has no
+// NDJSON endpoints, but the relay may still mark a request as streaming.
+func (c *Comfy) ForwardStream(ctx context.Context, method, path string, headers map[string]string, body []byte,
+	onStart func(status int, headers map[string]string), onChunk func(chunk []byte, binary bool) error) error {
+	return c.client.ForwardStream(ctx, method, path, headers, body, onStart, onChunk)
+}
+
+// generate submits the workflow, polls the job history until an output
+// image appears and fetches it.
+func (c *Comfy) generate(ctx context.Context, headers map[string]string, body []byte) (*ollama.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.client.Forward(ctx, http.MethodPost, "/prompt", headers, body)
+	if err != nil {
+		return nil, fmt.Errorf("submitting generation job: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		// Validation errors (bad workflow, unknown node) pass through so
+		// the UI can show them.
+		return resp, nil
+	}
+	var submitted struct {
+		PromptID string `json:"prompt_id"`
+	}
+	err = json.Unmarshal(resp.Body, &submitted)
+	resp.Release()
+	if err != nil || submitted.PromptID == "" {
+		return nil, fmt.Errorf("parsing job submission response: %w", err)
+	}
+	slog.Debug("Image generation job queued", "prompt_id", submitted.PromptID)
+
+	img, err := c.waitForImage(ctx, submitted.PromptID)
+	if err != nil {
+		return nil, err
+	}
+	return c.fetchImage(ctx, img)
+}
+
+// comfyImage locates one output image on the ComfyUI server.
+type comfyImage struct {
+	Filename  string `json:"filename"`
+	Subfolder string `json:"subfolder"`
+	Type      string `json:"type"`
+}
+
+// waitForImage polls /history until the job's first output image is
+// recorded.
+func (c *Comfy) waitForImage(ctx context.Context, promptID string) (*comfyImage, error) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for image generation job %s: %w", promptID, ctx.Err())
+		case <-ticker.C:
+		}
+
+		resp, err := c.client.Forward(ctx, http.MethodGet, "/history/"+promptID, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("polling image generation job: %w", err)
+		}
+		// The history entry appears once the job completes; an empty
+		// object means it is still queued or running.
+		var history map[string]struct {
+			Outputs map[string]struct {
+				Images []comfyImage `json:"images"`
+			} `json:"outputs"`
+		}
+		err = json.Unmarshal(resp.Body, &history)
+		resp.Release()
+		if err != nil {
+			return nil, fmt.Errorf("parsing job history: %w", err)
+		}
+		for _, entry := range history {
+			for _, out := range entry.Outputs {
+				if len(out.Images) > 0 {
+					img := out.Images[0]
+					return &img, nil
+				}
+			}
+		}
+	}
+}
+
+// fetchImage downloads the rendered image through /view.
+func (c *Comfy) fetchImage(ctx context.Context, img *comfyImage) (*ollama.Response, error) {
+	q := url.Values{}
+	q.Set("filename", img.Filename)
+	q.Set("subfolder", img.Subfolder)
+	q.Set("type", img.Type)
+	resp, err := c.client.Forward(ctx, http.MethodGet, "/view?"+q.Encode(), nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetching generated image: %w", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,85 @@
+// Package localapi exposes a local OpenAI-compatible HTTP endpoint that
+// routes to the local backends through the same policy pipeline as the
+// cloud tunnel, so local tools and cloud traffic share one policy point.
+package localapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/filter"
+)
+
+// OpenAIServer proxies /v1/* requests to Ollama's OpenAI-compatible API,
+// applying the bridge's content filter first.
+type OpenAIServer struct {
+	ollamaBaseURL string
+	filter        *filter.Engine
+	proxy         *httputil.ReverseProxy
+}
+
+// NewOpenAIServer returns a server forwarding to the Ollama instance at
+// ollamaBaseURL.
+func NewOpenAIServer(ollamaBaseURL string, fe *filter.Engine) (*OpenAIServer, error) {
+	target, err := url.Parse(ollamaBaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing ollama base URL: %w", err)
+	}
+	return &OpenAIServer{
+		ollamaBaseURL: ollamaBaseURL,
+		filter:        fe,
+		proxy:         httputil.NewSingleHostReverseProxy(target),
+	}, nil
+}
+
+// openAIError mirrors the OpenAI API error envelope so SDK clients show a
+// sensible message.
+func openAIError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{
+			"message": message,
+			"type":    "invalid_request_error",
+		},
+	})
+}
+
+// ServeHTTP implements http.Handler for the /v1 API surface.
+func (s *OpenAIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if len(r.URL.Path) < 4 || r.URL.Path[:4] != "/v1/" {
+		openAIError(w, http.StatusNotFound, "unknown path; only /v1/* is served")
+		return
+	}
+	if r.Body != nil && s.filter.Enabled() {
+		body, err := io.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			openAIError(w, http.StatusBadRequest, "reading request body failed")
+			return
+		}
+		res := s.filter.Apply(body)
+		if res.Action == filter.Deny {
+			slog.Info("Local OpenAI request denied by content filter",
+				"path", r.URL.Path, "rule", res.Rule)
+			openAIError(w, http.StatusForbidden,
+				fmt.Sprintf("blocked by content filter rule %q", res.Rule))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(res.Body))
+		r.ContentLength = int64(len(res.Body))
+	}
+	s.proxy.ServeHTTP(w, r)
+}
+
+// Run serves the OpenAI-compatible API on addr until the listener fails.
+func (s *OpenAIServer) Run(addr string) error {
+	slog.Info("OpenAI-compatible server listening", "addr", addr, "backend", s.ollamaBaseURL)
+	return http.ListenAndServe(addr, s)
+}
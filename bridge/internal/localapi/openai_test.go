@@ -0,0 +1,77 @@
+package localapi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/filter"
+)
+
+func newTestServer(t *testing.T, backend http.Handler, fcfg config.FilterConfig) *OpenAIServer {
+	t.Helper()
+	upstream := httptest.NewServer(backend)
+	t.Cleanup(upstream.Close)
+	engine, err := filter.New(fcfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := NewOpenAIServer(upstream.URL, engine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv
+}
+
+func TestProxiesToBackend(t *testing.T) {
+	srv := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("unexpected backend path %s", r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte("echo:" + string(body)))
+	}), config.FilterConfig{})
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+		strings.NewReader(`{"model":"llama3"}`))
+	rr := httptest.NewRecorder()
+	srv.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `echo:{"model":"llama3"}`) {
+		t.Fatalf("unexpected response %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestFilterDenies(t *testing.T) {
+	srv := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("backend should not be reached for denied requests")
+	}), config.FilterConfig{
+		Enabled: true,
+		Rules: []config.FilterRule{
+			{Name: "blocked", Keywords: []string{"forbidden"}, Action: "deny"},
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/completions",
+		strings.NewReader(`{"prompt":"forbidden topic"}`))
+	rr := httptest.NewRecorder()
+	srv.ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rr.Code)
+	}
+}
+
+func TestNonV1PathRejected(t *testing.T) {
+	srv := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("backend should not be reached")
+	}), config.FilterConfig{})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/tags", nil)
+	rr := httptest.NewRecorder()
+	srv.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rr.Code)
+	}
+}
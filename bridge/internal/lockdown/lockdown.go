@@ -0,0 +1,62 @@
+// Package lockdown persists the emergency lockdown marker. While the
+// marker exists the bridge refuses to forward or reconnect, across
+// restarts, until the user explicitly unlocks — the big red button for
+// a suspected account compromise.
+package lockdown
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+)
+
+// fileName is the marker file in the config directory.
+const fileName = "lockdown"
+
+func path() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, fileName), nil
+}
+
+// Engage writes the lockdown marker. The file records when lockdown was
+// engaged, for the curious; only its existence matters.
+func Engage() error {
+	p, err := path()
+	if err != nil {
+		return err
+	}
+	content := "engaged " + time.Now().Format(time.RFC3339) + "\n"
+	if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+		return fmt.Errorf("writing lockdown marker: %w", err)
+	}
+	return nil
+}
+
+// Lift removes the lockdown marker; lifting an absent lockdown is not
+// an error.
+func Lift() error {
+	p, err := path()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing lockdown marker: %w", err)
+	}
+	return nil
+}
+
+// Active reports whether the lockdown marker exists.
+func Active() bool {
+	p, err := path()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(p)
+	return err == nil
+}
@@ -0,0 +1,80 @@
+// Package lockfile enforces a single running bridge per config directory
+// via a PID lock file, so two processes cannot fight over the token store
+// and relay registration.
+package lockfile
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// HeldError reports that another live bridge process holds the lock.
+type HeldError struct {
+	PID int
+}
+
+func (e *HeldError) Error() string {
+	return fmt.Sprintf("another bridge instance is running (pid %d)", e.PID)
+}
+
+// Is lets errors.Is match any *HeldError.
+func (e *HeldError) Is(target error) bool { _, ok := target.(*HeldError); return ok }
+
+// Lock is a held instance lock; call Release on shutdown.
+type Lock struct {
+	path string
+}
+
+// Acquire takes the instance lock at path. A lock left behind by a dead
+// process is removed and re-acquired; a lock held by a live process
+// returns *HeldError.
+func Acquire(path string) (*Lock, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			return &Lock{path: path}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("creating lock file: %w", err)
+		}
+		pid, readErr := readPID(path)
+		if readErr == nil && alive(pid) {
+			return nil, &HeldError{PID: pid}
+		}
+		// Stale lock from a crashed process; remove and retry once.
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("removing stale lock file: %w", err)
+		}
+	}
+	return nil, fmt.Errorf("lock file %s contested", path)
+}
+
+// Release removes the lock file.
+func (l *Lock) Release() {
+	os.Remove(l.path)
+}
+
+func readPID(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+// alive reports whether a process with the given PID exists.
+func alive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return proc.Signal(syscall.Signal(0)) == nil
+}
@@ -0,0 +1,105 @@
+// Package logging configures the shared slog logger for the bridge.
+package logging
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// threshold is the stderr handler's dynamic level, adjustable at runtime
+// via SetLevel and DebugFor without restarting the bridge.
+var threshold = new(slog.LevelVar)
+
+// revertMu guards the temporary-debug revert state.
+var (
+	revertMu    sync.Mutex
+	revertTimer *time.Timer
+	revertTo    slog.Level
+)
+
+// Setup installs a text handler at the given level as the default logger
+// and returns it. Records are also captured in Buffer for the local log
+// viewer, regardless of the stderr level.
+func Setup(level string) *slog.Logger {
+	threshold.Set(ParseLevel(level))
+	text := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: threshold,
+	})
+	tee := &teeHandler{handlers: []slog.Handler{text, &ringHandler{ring: Buffer}}}
+	logger := slog.New(&redactHandler{inner: tee})
+	slog.SetDefault(logger)
+	return logger
+}
+
+// SetLevel changes the stderr handler's threshold at runtime, cancelling
+// any pending temporary-debug revert.
+func SetLevel(level string) {
+	revertMu.Lock()
+	if revertTimer != nil {
+		revertTimer.Stop()
+		revertTimer = nil
+	}
+	threshold.Set(ParseLevel(level))
+	revertMu.Unlock()
+}
+
+// Level returns the current stderr threshold as a config string.
+func Level() string {
+	return levelName(threshold.Level())
+}
+
+// DebugFor switches the stderr handler to debug for the given duration
+// and then reverts to the previous level on its own; calling it again
+// restarts the window. Meant for support sessions where a restart would
+// destroy the state being debugged.
+func DebugFor(d time.Duration) {
+	revertMu.Lock()
+	if revertTimer == nil {
+		revertTo = threshold.Level()
+	} else {
+		revertTimer.Stop()
+	}
+	threshold.Set(slog.LevelDebug)
+	revertTimer = time.AfterFunc(d, func() {
+		revertMu.Lock()
+		threshold.Set(revertTo)
+		revertTimer = nil
+		prev := revertTo
+		revertMu.Unlock()
+		slog.Info("Temporary debug logging ended", "level", levelName(prev))
+	})
+	revertMu.Unlock()
+	slog.Info("Debug logging enabled temporarily", "duration", d)
+}
+
+// ParseLevel maps a config log level string to a slog.Level, defaulting
+// to info for unknown values.
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// levelName maps a slog.Level back to its config string.
+func levelName(l slog.Level) string {
+	switch {
+	case l <= slog.LevelDebug:
+		return "debug"
+	case l <= slog.LevelInfo:
+		return "info"
+	case l <= slog.LevelWarn:
+		return "warn"
+	default:
+		return "error"
+	}
+}
@@ -0,0 +1,135 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// redactedPlaceholder replaces masked secrets in log output.
+const redactedPlaceholder = "[redacted]"
+
+// Secret-shaped strings masked in every record regardless of
+// configuration: Authorization-style bearer credentials and JWTs, which
+// otherwise leak through debug-logged dial errors and header dumps.
+var (
+	bearerRe = regexp.MustCompile(`(?i)\b(bearer)[ \t]+[A-Za-z0-9._~+/=-]{8,}`)
+	jwtRe    = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+)
+
+var (
+	redactMu sync.RWMutex
+	// secretValues are exact strings registered at runtime (access and
+	// refresh tokens as they are loaded or issued).
+	secretValues []string
+	// secretPatterns are user-configured expressions from
+	// bridge.redact_patterns.
+	secretPatterns []*regexp.Regexp
+)
+
+// RegisterSecret masks an exact value in all subsequent log output.
+// Short values are ignored: masking them would mangle ordinary text.
+func RegisterSecret(value string) {
+	if len(value) < 8 {
+		return
+	}
+	redactMu.Lock()
+	defer redactMu.Unlock()
+	for _, s := range secretValues {
+		if s == value {
+			return
+		}
+	}
+	secretValues = append(secretValues, value)
+}
+
+// SetRedactPatterns replaces the configured secret patterns. Each
+// expression is a Go regexp whose matches are masked in log output.
+func SetRedactPatterns(exprs []string) error {
+	compiled := make([]*regexp.Regexp, 0, len(exprs))
+	for _, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("redact pattern %q: %w", expr, err)
+		}
+		compiled = append(compiled, re)
+	}
+	redactMu.Lock()
+	secretPatterns = compiled
+	redactMu.Unlock()
+	return nil
+}
+
+// Redact masks registered secret values, configured patterns and
+// secret-shaped strings (bearer credentials, JWTs) in s.
+func Redact(s string) string {
+	redactMu.RLock()
+	values := secretValues
+	patterns := secretPatterns
+	redactMu.RUnlock()
+	for _, v := range values {
+		s = strings.ReplaceAll(s, v, redactedPlaceholder)
+	}
+	s = jwtRe.ReplaceAllString(s, redactedPlaceholder)
+	s = bearerRe.ReplaceAllString(s, "$1 "+redactedPlaceholder)
+	for _, re := range patterns {
+		s = re.ReplaceAllString(s, redactedPlaceholder)
+	}
+	return s
+}
+
+// redactHandler rewrites records through Redact before the real handlers
+// see them, so neither stderr nor the in-memory ring ever stores a
+// secret.
+type redactHandler struct {
+	inner slog.Handler
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, rec slog.Record) error {
+	out := slog.NewRecord(rec.Time, rec.Level, Redact(rec.Message), rec.PC)
+	rec.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(redactAttr(a))
+		return true
+	})
+	return h.inner.Handle(ctx, out)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = redactAttr(a)
+	}
+	return &redactHandler{inner: h.inner.WithAttrs(redacted)}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{inner: h.inner.WithGroup(name)}
+}
+
+// redactAttr masks string-valued attributes, recursing into groups.
+// Errors and other formatted values are stringified first; their text is
+// what would have reached the log line anyway.
+func redactAttr(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Redact(v.String()))
+	case slog.KindGroup:
+		attrs := v.Group()
+		out := make([]slog.Attr, len(attrs))
+		for i, ga := range attrs {
+			out[i] = redactAttr(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(out...)}
+	case slog.KindAny:
+		return slog.String(a.Key, Redact(fmt.Sprint(v.Any())))
+	}
+	return a
+}
@@ -0,0 +1,182 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is one captured log record for the local log viewer.
+type Entry struct {
+	Seq     int64             `json:"seq"`
+	Time    time.Time         `json:"time"`
+	Level   string            `json:"level"`
+	Message string            `json:"message"`
+	Attrs   map[string]string `json:"attrs,omitempty"`
+}
+
+// Ring keeps the most recent log records in memory so the log viewer can
+// tail them without users digging through config directories.
+type Ring struct {
+	mu      sync.Mutex
+	entries []Entry
+	size    int
+	seq     int64
+}
+
+// defaultRingSize is how many records the viewer can look back on.
+const defaultRingSize = 2000
+
+// Buffer is the ring the default logger writes into.
+var Buffer = NewRing(defaultRingSize)
+
+// NewRing returns a ring holding the last size records.
+func NewRing(size int) *Ring {
+	return &Ring{size: size}
+}
+
+func (r *Ring) add(e Entry) {
+	r.mu.Lock()
+	r.seq++
+	e.Seq = r.seq
+	r.entries = append(r.entries, e)
+	if len(r.entries) > r.size {
+		r.entries = r.entries[len(r.entries)-r.size:]
+	}
+	r.mu.Unlock()
+}
+
+// Tail returns records with sequence numbers greater than after that are
+// at or above minLevel and contain query (case-insensitive) in message or
+// attributes. The second result is the newest sequence number seen, to be
+// passed as after on the next poll.
+func (r *Ring) Tail(after int64, minLevel slog.Level, query string) ([]Entry, int64) {
+	query = strings.ToLower(query)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []Entry
+	for _, e := range r.entries {
+		if e.Seq <= after || ParseLevel(e.Level) < minLevel {
+			continue
+		}
+		if query != "" && !entryMatches(e, query) {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out, r.seq
+}
+
+// Last returns up to n of the most recent records, oldest first. Zero or
+// negative n returns everything the ring holds.
+func (r *Ring) Last(n int) []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	start := 0
+	if n > 0 && len(r.entries) > n {
+		start = len(r.entries) - n
+	}
+	out := make([]Entry, len(r.entries)-start)
+	copy(out, r.entries[start:])
+	return out
+}
+
+func entryMatches(e Entry, query string) bool {
+	if strings.Contains(strings.ToLower(e.Message), query) {
+		return true
+	}
+	for k, v := range e.Attrs {
+		if strings.Contains(strings.ToLower(k), query) || strings.Contains(strings.ToLower(v), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// ringHandler is a slog.Handler feeding the ring. It captures every
+// level regardless of the stderr handler's threshold, so the viewer can
+// show debug records without restarting the bridge.
+type ringHandler struct {
+	ring  *Ring
+	attrs []slog.Attr
+}
+
+func (h *ringHandler) Enabled(ctx context.Context, level slog.Level) bool { return true }
+
+func (h *ringHandler) Handle(ctx context.Context, rec slog.Record) error {
+	attrs := make(map[string]string, rec.NumAttrs()+len(h.attrs))
+	for _, a := range h.attrs {
+		attrs[a.Key] = a.Value.String()
+	}
+	rec.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value.String()
+		return true
+	})
+	h.ring.add(Entry{
+		Time:    rec.Time,
+		Level:   strings.ToLower(rec.Level.String()),
+		Message: rec.Message,
+		Attrs:   attrs,
+	})
+	return nil
+}
+
+func (h *ringHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	merged := make([]slog.Attr, 0, len(h.attrs)+len(attrs))
+	merged = append(merged, h.attrs...)
+	merged = append(merged, attrs...)
+	return &ringHandler{ring: h.ring, attrs: merged}
+}
+
+func (h *ringHandler) WithGroup(name string) slog.Handler {
+	// Groups are flattened; the viewer only needs readable key/values.
+	prefixed := make([]slog.Attr, len(h.attrs))
+	copy(prefixed, h.attrs)
+	return &ringHandler{ring: h.ring, attrs: prefixed}
+}
+
+// teeHandler fans records out to the stderr handler and the ring.
+type teeHandler struct {
+	handlers []slog.Handler
+}
+
+func (t *teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range t.handlers {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *teeHandler) Handle(ctx context.Context, rec slog.Record) error {
+	var firstErr error
+	for _, h := range t.handlers {
+		if !h.Enabled(ctx, rec.Level) {
+			continue
+		}
+		if err := h.Handle(ctx, rec.Clone()); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("log handler: %w", err)
+		}
+	}
+	return firstErr
+}
+
+func (t *teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make([]slog.Handler, len(t.handlers))
+	for i, h := range t.handlers {
+		out[i] = h.WithAttrs(attrs)
+	}
+	return &teeHandler{handlers: out}
+}
+
+func (t *teeHandler) WithGroup(name string) slog.Handler {
+	out := make([]slog.Handler, len(t.handlers))
+	for i, h := range t.handlers {
+		out[i] = h.WithGroup(name)
+	}
+	return &teeHandler{handlers: out}
+}
@@ -0,0 +1,120 @@
+// Package mirror duplicates a sample of forwarded requests to a secondary
+// Ollama backend and records latency and output differences, so users can
+// evaluate a model or quantization upgrade against real traffic before
+// switching over.
+package mirror
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/ollama"
+)
+
+// Primary describes the primary backend's result for one mirrored request.
+type Primary struct {
+	StatusCode int
+	BodySHA256 string
+	BodyBytes  int
+	Duration   time.Duration
+}
+
+// record is one JSONL line in the mirror diff log.
+type record struct {
+	Timestamp     string `json:"ts"`
+	Path          string `json:"path"`
+	PrimaryStatus int    `json:"primary_status"`
+	MirrorStatus  int    `json:"mirror_status"`
+	PrimaryMS     int64  `json:"primary_ms"`
+	MirrorMS      int64  `json:"mirror_ms"`
+	PrimaryBytes  int    `json:"primary_bytes"`
+	MirrorBytes   int    `json:"mirror_bytes"`
+	BodiesEqual   bool   `json:"bodies_equal"`
+	Error         string `json:"error,omitempty"`
+}
+
+// Mirror sends a percentage of requests to a secondary backend.
+type Mirror struct {
+	client  *ollama.Client
+	percent int
+
+	mu  sync.Mutex
+	out *os.File
+	rng *rand.Rand
+}
+
+// New returns a Mirror forwarding to baseURL and appending diff records
+// to logPath. percent is the sampling rate in [1,100].
+func New(baseURL string, percent int, timeout time.Duration, logPath string) (*Mirror, error) {
+	out, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("opening mirror log: %w", err)
+	}
+	return &Mirror{
+		client:  ollama.NewClient(baseURL, timeout),
+		percent: percent,
+		out:     out,
+		rng:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}, nil
+}
+
+// Close closes the diff log.
+func (m *Mirror) Close() error { return m.out.Close() }
+
+// Sample reports whether the next request should be mirrored.
+func (m *Mirror) Sample() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rng.Intn(100) < m.percent
+}
+
+// BodyDigest hashes a response body for comparison without retaining it.
+func BodyDigest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// Run replays one request against the secondary backend and records the
+// diff against the primary result. It is meant to run in its own
+// goroutine; failures are logged, never surfaced to the cloud client.
+func (m *Mirror) Run(ctx context.Context, method, path string, headers map[string]string, body []byte, primary Primary) {
+	rec := record{
+		Timestamp:     time.Now().UTC().Format(time.RFC3339),
+		Path:          path,
+		PrimaryStatus: primary.StatusCode,
+		PrimaryMS:     primary.Duration.Milliseconds(),
+		PrimaryBytes:  primary.BodyBytes,
+	}
+	start := time.Now()
+	resp, err := m.client.Forward(ctx, method, path, headers, body)
+	rec.MirrorMS = time.Since(start).Milliseconds()
+	if err != nil {
+		rec.Error = err.Error()
+	} else {
+		rec.MirrorStatus = resp.StatusCode
+		rec.MirrorBytes = len(resp.Body)
+		rec.BodiesEqual = BodyDigest(resp.Body) == primary.BodySHA256
+		resp.Release()
+	}
+	m.write(&rec)
+}
+
+func (m *Mirror) write(rec *record) {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, err := m.out.Write(append(line, '\n')); err != nil {
+		slog.Warn("Writing mirror record failed", "error", err)
+	}
+}
@@ -0,0 +1,130 @@
+// Package notify shows desktop notifications for events that need the
+// user's attention while the bridge runs minimized, such as expired
+// logins. Each platform has its own best-effort implementation; failures
+// are logged and never interrupt the bridge. Which events actually reach
+// the desktop is governed by the configured policy and mute state.
+package notify
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+)
+
+// Event classifies a notification for the per-event policy. The zero
+// value marks direct feedback to a user action, which bypasses the
+// per-event settings and the do-not-disturb schedule (but not mute).
+type Event string
+
+const (
+	EventConnect    Event = "connect"
+	EventDisconnect Event = "disconnect"
+	EventError      Event = "errors"
+	EventAuth       Event = "auth"
+	EventUpdate     Event = "updates"
+)
+
+// Notification is one message for the user. Action, when supported by
+// the platform (Windows toasts), adds a button that runs the given
+// command.
+type Notification struct {
+	Title string
+	Body  string
+	// Event selects the policy entry gating this notification.
+	Event Event
+	// ActionLabel and ActionCommand add an actionable button, e.g.
+	// "Login now" running `bridge login`. Ignored on platforms without
+	// actionable notifications.
+	ActionLabel   string
+	ActionCommand string
+}
+
+var (
+	policyMu sync.Mutex
+	policy   = config.DefaultNotifications()
+	muted    bool
+)
+
+// SetPolicy installs the configured notification policy.
+func SetPolicy(cfg config.NotificationsConfig) {
+	policyMu.Lock()
+	policy = cfg
+	policyMu.Unlock()
+}
+
+// SetMuted suppresses all notifications while set, regardless of policy.
+func SetMuted(m bool) {
+	policyMu.Lock()
+	muted = m
+	policyMu.Unlock()
+}
+
+// Muted reports whether notifications are currently muted.
+func Muted() bool {
+	policyMu.Lock()
+	defer policyMu.Unlock()
+	return muted
+}
+
+// Show displays the notification when the policy allows it, logging on
+// failure.
+func Show(n Notification) {
+	if !allowed(n.Event, time.Now()) {
+		slog.Debug("Notification suppressed by policy", "title", n.Title, "event", string(n.Event))
+		return
+	}
+	if err := show(n); err != nil {
+		slog.Debug("Showing desktop notification failed", "title", n.Title, "error", err)
+	}
+}
+
+// allowed applies mute, the per-event settings and the DND schedule.
+func allowed(e Event, now time.Time) bool {
+	policyMu.Lock()
+	cfg, m := policy, muted
+	policyMu.Unlock()
+	if m {
+		return false
+	}
+	if e == "" {
+		return true
+	}
+	if inDND(cfg, now) {
+		return false
+	}
+	switch e {
+	case EventConnect:
+		return cfg.Connect
+	case EventDisconnect:
+		return cfg.Disconnect
+	case EventError:
+		return cfg.Errors
+	case EventAuth:
+		return cfg.Auth
+	case EventUpdate:
+		return cfg.Updates
+	}
+	return true
+}
+
+// inDND reports whether now falls inside the do-not-disturb window. A
+// window ending before it starts spans midnight.
+func inDND(cfg config.NotificationsConfig, now time.Time) bool {
+	if cfg.DNDStart == "" || cfg.DNDEnd == "" {
+		return false
+	}
+	start, err1 := time.Parse("15:04", cfg.DNDStart)
+	end, err2 := time.Parse("15:04", cfg.DNDEnd)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	minutes := now.Hour()*60 + now.Minute()
+	s := start.Hour()*60 + start.Minute()
+	e := end.Hour()*60 + end.Minute()
+	if s <= e {
+		return minutes >= s && minutes < e
+	}
+	return minutes >= s || minutes < e
+}
@@ -0,0 +1,43 @@
+//go:build linux
+
+package notify
+
+import (
+	"fmt"
+	"os/exec"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/sandbox"
+)
+
+// show uses notify-send, present on practically every desktop Linux
+// install. Actions need a notification daemon round trip that notify-send
+// cannot do, so only title and body are shown. Confined builds (Snap,
+// Flatpak) cannot exec host binaries and use the Notification portal
+// instead.
+func show(n Notification) error {
+	if sandbox.Confined() {
+		return portalShow(n)
+	}
+	path, err := exec.LookPath("notify-send")
+	if err != nil {
+		return fmt.Errorf("notify-send not found: %w", err)
+	}
+	return exec.Command(path, "--app-name=CloudToLocalLLM", n.Title, n.Body).Run()
+}
+
+// portalShow posts the notification through the desktop's Notification
+// portal, which mediates access for sandboxed apps.
+func portalShow(n Notification) error {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return fmt.Errorf("connecting to session bus: %w", err)
+	}
+	obj := conn.Object("org.freedesktop.portal.Desktop", "/org/freedesktop/portal/desktop")
+	call := obj.Call("org.freedesktop.portal.Notification.AddNotification", 0,
+		"cloudtolocalllm-bridge", map[string]dbus.Variant{
+			"title": dbus.MakeVariant(n.Title),
+			"body":  dbus.MakeVariant(n.Body),
+		})
+	return call.Err
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !windows
+
+package notify
+
+import "log/slog"
+
+// show logs the notification on platforms without a desktop integration.
+func show(n Notification) error {
+	slog.Info("Notification", "title", n.Title, "body", n.Body)
+	return nil
+}
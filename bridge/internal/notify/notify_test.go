@@ -0,0 +1,41 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+)
+
+func at(hour, min int) time.Time {
+	return time.Date(2026, 1, 10, hour, min, 0, 0, time.Local)
+}
+
+func TestInDNDSameDayWindow(t *testing.T) {
+	cfg := config.NotificationsConfig{DNDStart: "13:00", DNDEnd: "15:00"}
+	if !inDND(cfg, at(14, 0)) {
+		t.Fatal("14:00 should be inside 13:00-15:00")
+	}
+	if inDND(cfg, at(15, 0)) {
+		t.Fatal("15:00 should be outside 13:00-15:00")
+	}
+}
+
+func TestInDNDMidnightWindow(t *testing.T) {
+	cfg := config.NotificationsConfig{DNDStart: "22:00", DNDEnd: "07:00"}
+	if !inDND(cfg, at(23, 30)) {
+		t.Fatal("23:30 should be inside 22:00-07:00")
+	}
+	if !inDND(cfg, at(6, 59)) {
+		t.Fatal("06:59 should be inside 22:00-07:00")
+	}
+	if inDND(cfg, at(12, 0)) {
+		t.Fatal("12:00 should be outside 22:00-07:00")
+	}
+}
+
+func TestInDNDDisabled(t *testing.T) {
+	if inDND(config.NotificationsConfig{}, at(3, 0)) {
+		t.Fatal("empty schedule should never match")
+	}
+}
@@ -0,0 +1,78 @@
+//go:build windows
+
+package notify
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// show raises a proper toast through the WinRT notification API via
+// PowerShell, so the message lands in the Action Center instead of being
+// lost. When an action is configured the toast gets a button that runs
+// the command (e.g. "Login now" launching the browser flow).
+func show(n Notification) error {
+	toast, err := toastXML(n)
+	if err != nil {
+		return err
+	}
+	script := fmt.Sprintf(`
+[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+[Windows.Data.Xml.Dom.XmlDocument, Windows.Data.Xml.Dom.XmlDocument, ContentType = WindowsRuntime] | Out-Null
+$xml = New-Object Windows.Data.Xml.Dom.XmlDocument
+$xml.LoadXml('%s')
+$toast = New-Object Windows.UI.Notifications.ToastNotification $xml
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('CloudToLocalLLM Bridge').Show($toast)
+`, strings.ReplaceAll(toast, "'", "''"))
+	return exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script).Run()
+}
+
+// toastXML builds the toast payload, escaping user-visible text.
+func toastXML(n Notification) (string, error) {
+	var b strings.Builder
+	b.WriteString(`<toast`)
+	if n.ActionCommand != "" {
+		b.WriteString(` activationType="protocol"`)
+	}
+	b.WriteString(`><visual><binding template="ToastGeneric">`)
+	if err := writeTextElement(&b, n.Title); err != nil {
+		return "", err
+	}
+	if err := writeTextElement(&b, n.Body); err != nil {
+		return "", err
+	}
+	b.WriteString(`</binding></visual>`)
+	if n.ActionLabel != "" && n.ActionCommand != "" {
+		label, err := escapeAttr(n.ActionLabel)
+		if err != nil {
+			return "", err
+		}
+		command, err := escapeAttr(n.ActionCommand)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, `<actions><action content="%s" activationType="protocol" arguments="%s"/></actions>`,
+			label, command)
+	}
+	b.WriteString(`</toast>`)
+	return b.String(), nil
+}
+
+func writeTextElement(b *strings.Builder, text string) error {
+	b.WriteString("<text>")
+	if err := xml.EscapeText(b, []byte(text)); err != nil {
+		return err
+	}
+	b.WriteString("</text>")
+	return nil
+}
+
+func escapeAttr(s string) (string, error) {
+	var b strings.Builder
+	if err := xml.EscapeText(&b, []byte(s)); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,233 @@
+// Package ollama is a thin HTTP client for the local Ollama server used
+// by the tunnel to forward cloud-originated requests.
+package ollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/bufpool"
+)
+
+// Client forwards requests to a local Ollama instance.
+type Client struct {
+	baseURL string
+	http    *http.Client
+}
+
+// Response is the materialized result of a forwarded request. Body is
+// backed by a pooled buffer; call Release once the body is no longer
+// referenced.
+type Response struct {
+	StatusCode int
+	Headers    map[string]string
+	Body       []byte
+
+	buf *bytes.Buffer
+}
+
+// Release returns the response's pooled buffer. The Body slice must not
+// be used afterwards.
+func (r *Response) Release() {
+	if r.buf != nil {
+		bufpool.Put(r.buf)
+		r.buf = nil
+		r.Body = nil
+	}
+}
+
+// TransportOptions tune connection reuse to the local server. The
+// defaults matter: Go's stock two idle connections per host makes
+// embedding-heavy workloads churn through ephemeral ports.
+type TransportOptions struct {
+	// MaxIdleConns caps idle kept-alive connections to Ollama; zero
+	// means 32.
+	MaxIdleConns int
+	// IdleConnTimeout closes idle connections after this long; zero
+	// means 90 seconds.
+	IdleConnTimeout time.Duration
+	// ForceHTTP2 attempts HTTP/2 when Ollama sits behind a TLS proxy
+	// that supports it.
+	ForceHTTP2 bool
+}
+
+// NewClient returns a Client for the Ollama server at baseURL, e.g.
+// http://localhost:11434, with default connection pooling.
+func NewClient(baseURL string, timeout time.Duration) *Client {
+	return NewTunedClient(baseURL, timeout, TransportOptions{})
+}
+
+// NewTunedClient returns a Client with explicit transport tuning; see
+// TransportOptions for the defaults applied to zero values.
+func NewTunedClient(baseURL string, timeout time.Duration, opts TransportOptions) *Client {
+	if opts.MaxIdleConns <= 0 {
+		opts.MaxIdleConns = 32
+	}
+	if opts.IdleConnTimeout <= 0 {
+		opts.IdleConnTimeout = 90 * time.Second
+	}
+	// Clone the default transport so the global TLS policy carries over.
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.MaxIdleConns = opts.MaxIdleConns
+	tr.MaxIdleConnsPerHost = opts.MaxIdleConns
+	tr.IdleConnTimeout = opts.IdleConnTimeout
+	tr.ForceAttemptHTTP2 = opts.ForceHTTP2
+	return &Client{
+		baseURL: baseURL,
+		http:    &http.Client{Timeout: timeout, Transport: tr},
+	}
+}
+
+// BaseURL returns the configured Ollama base URL.
+func (c *Client) BaseURL() string { return c.baseURL }
+
+// CloseIdleConnections drops pooled connections so the next request
+// dials fresh. The stuck-request watchdog calls it after cancelling
+// hung requests, in case the pool holds half-dead connections to a
+// wedged server.
+func (c *Client) CloseIdleConnections() { c.http.CloseIdleConnections() }
+
+// Forward sends the given request to Ollama and returns the full response.
+func (c *Client) Forward(ctx context.Context, method, path string, headers map[string]string, body []byte) (*Response, error) {
+	var reader io.Reader
+	if len(body) > 0 {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("building ollama request: %w", err)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("forwarding to ollama: %w", err)
+	}
+	defer resp.Body.Close()
+
+	buf := bufpool.Get()
+	if _, err := io.Copy(buf, resp.Body); err != nil {
+		bufpool.Put(buf)
+		return nil, fmt.Errorf("reading ollama response: %w", err)
+	}
+	respHeaders := make(map[string]string, len(resp.Header))
+	for k := range resp.Header {
+		respHeaders[k] = resp.Header.Get(k)
+	}
+	return &Response{
+		StatusCode: resp.StatusCode,
+		Headers:    respHeaders,
+		Body:       buf.Bytes(),
+		buf:        buf,
+	}, nil
+}
+
+// maxStreamChunk bounds one streamed NDJSON line from Ollama.
+const maxStreamChunk = 512 * 1024
+
+// binaryChunkSize is how much of a binary stream (TTS audio) travels in
+// one chunk callback.
+const binaryChunkSize = 32 * 1024
+
+// ForwardStream sends the request and pipes the response body to onChunk
+// without materializing it. Text responses (NDJSON, SSE) are delivered
+// line by line with binary false; binary responses such as streamed audio
+// are delivered in fixed-size chunks with binary true. onStart is called
+// once with the response status and headers before the first chunk.
+func (c *Client) ForwardStream(ctx context.Context, method, path string, headers map[string]string, body []byte,
+	onStart func(status int, headers map[string]string), onChunk func(chunk []byte, binary bool) error) error {
+	var reader io.Reader
+	if len(body) > 0 {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("building ollama request: %w", err)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("forwarding to ollama: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respHeaders := make(map[string]string, len(resp.Header))
+	for k := range resp.Header {
+		respHeaders[k] = resp.Header.Get(k)
+	}
+	onStart(resp.StatusCode, respHeaders)
+
+	if !textStream(resp.Header.Get("Content-Type")) {
+		buf := make([]byte, binaryChunkSize)
+		for {
+			n, err := resp.Body.Read(buf)
+			if n > 0 {
+				if cerr := onChunk(buf[:n], true); cerr != nil {
+					return cerr
+				}
+			}
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("reading binary stream: %w", err)
+			}
+		}
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), maxStreamChunk)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := onChunk(line, false); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading ollama stream: %w", err)
+	}
+	return nil
+}
+
+// textStream reports whether a streamed response body is line-oriented
+// text. Anything else (audio from a TTS backend, images) streams as
+// binary chunks.
+func textStream(contentType string) bool {
+	ct := contentType
+	if i := strings.IndexByte(ct, ';'); i >= 0 {
+		ct = ct[:i]
+	}
+	ct = strings.ToLower(strings.TrimSpace(ct))
+	switch {
+	case ct == "", strings.HasPrefix(ct, "text/"):
+		return true
+	case ct == "application/json", ct == "application/x-ndjson":
+		return true
+	}
+	return false
+}
+
+// Version returns the Ollama server version from /api/version.
+func (c *Client) Version(ctx context.Context) (string, error) {
+	resp, err := c.Forward(ctx, http.MethodGet, "/api/version", nil, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Release()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("ollama /api/version returned %d", resp.StatusCode)
+	}
+	return string(resp.Body), nil
+}
@@ -0,0 +1,140 @@
+// Package power monitors battery and thermal state on laptops and
+// throttles the bridge when remote requests would drain an unplugged
+// machine or push it past a temperature limit. Readings come from Linux
+// sysfs; platforms without it simply never report battery or heat.
+package power
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+)
+
+// Controls is the subset of the tunnel the monitor acts on.
+type Controls interface {
+	SetPause(reason string)
+	SetMaxConcurrent(n int)
+}
+
+// Monitor samples power and thermal state on an interval and adjusts the
+// tunnel's pause and concurrency settings.
+type Monitor struct {
+	cfg      config.PowerConfig
+	controls Controls
+
+	// lastReason tracks the applied pause reason so transitions are
+	// logged once, not every tick.
+	lastReason  string
+	lastLimited bool
+}
+
+// NewMonitor returns a monitor applying cfg to the given controls.
+func NewMonitor(cfg config.PowerConfig, controls Controls) *Monitor {
+	return &Monitor{cfg: cfg, controls: controls}
+}
+
+// Run samples until the context is canceled.
+func (m *Monitor) Run(ctx context.Context) {
+	interval := m.cfg.CheckInterval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	m.sample()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.sample()
+		}
+	}
+}
+
+// sample reads the current state and applies the configured policy:
+// over-temperature pauses forwarding entirely, battery power either
+// pauses or caps concurrency.
+func (m *Monitor) sample() {
+	reason := ""
+	onBattery := OnBattery()
+	if temp, ok := MaxTemperature(); ok && m.cfg.MaxTempCelsius > 0 && temp >= m.cfg.MaxTempCelsius {
+		reason = fmt.Sprintf("temperature %d°C above limit %d°C", temp, m.cfg.MaxTempCelsius)
+	} else if onBattery && m.cfg.PauseOnBattery {
+		reason = "running on battery power"
+	}
+
+	if reason != m.lastReason {
+		m.controls.SetPause(reason)
+		if reason != "" {
+			slog.Warn("Pausing request forwarding", "reason", reason)
+		} else {
+			slog.Info("Resuming request forwarding", "was", m.lastReason)
+		}
+		m.lastReason = reason
+	}
+
+	limited := onBattery && m.cfg.MaxConcurrentOnBattery > 0
+	if limited != m.lastLimited {
+		if limited {
+			m.controls.SetMaxConcurrent(m.cfg.MaxConcurrentOnBattery)
+			slog.Info("Limiting concurrency on battery power",
+				"max_concurrent", m.cfg.MaxConcurrentOnBattery)
+		} else {
+			m.controls.SetMaxConcurrent(0)
+			slog.Info("Concurrency limit lifted")
+		}
+		m.lastLimited = limited
+	}
+}
+
+// OnBattery reports whether the machine is discharging a battery. False
+// on desktops, on AC power, or when the platform exposes no battery info.
+func OnBattery() bool {
+	supplies, err := filepath.Glob("/sys/class/power_supply/*")
+	if err != nil {
+		return false
+	}
+	for _, dir := range supplies {
+		kind, err := os.ReadFile(filepath.Join(dir, "type"))
+		if err != nil || strings.TrimSpace(string(kind)) != "Battery" {
+			continue
+		}
+		status, err := os.ReadFile(filepath.Join(dir, "status"))
+		if err == nil && strings.TrimSpace(string(status)) == "Discharging" {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxTemperature returns the hottest thermal zone in whole degrees
+// Celsius, with ok false when the platform exposes no thermal zones.
+func MaxTemperature() (int, bool) {
+	zones, err := filepath.Glob("/sys/class/thermal/thermal_zone*/temp")
+	if err != nil {
+		return 0, false
+	}
+	max, found := 0, false
+	for _, zone := range zones {
+		raw, err := os.ReadFile(zone)
+		if err != nil {
+			continue
+		}
+		milli, err := strconv.Atoi(strings.TrimSpace(string(raw)))
+		if err != nil {
+			continue
+		}
+		if c := milli / 1000; !found || c > max {
+			max, found = c, true
+		}
+	}
+	return max, found
+}
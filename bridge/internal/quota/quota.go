@@ -0,0 +1,135 @@
+// Package quota enforces per-user rate limits and concurrency caps when a
+// single bridge serves multiple cloud users. Limits come from the quotas
+// section of bridge.yaml and are keyed by the cloud user ID the relay
+// attaches to each tunneled request.
+package quota
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+)
+
+// ErrRateLimited is wrapped by Acquire when a request exceeds the user's
+// per-minute rate limit.
+var ErrRateLimited = fmt.Errorf("rate limit exceeded")
+
+// ErrTooManyConcurrent is wrapped by Acquire when a request exceeds the
+// user's concurrency cap.
+var ErrTooManyConcurrent = fmt.Errorf("concurrency limit exceeded")
+
+// Usage is a snapshot of one user's counters for the status API.
+type Usage struct {
+	Requests   uint64 `json:"requests"`
+	Denied     uint64 `json:"denied"`
+	InFlight   int    `json:"in_flight"`
+	LastActive string `json:"last_active,omitempty"`
+}
+
+type userState struct {
+	limits     config.UserQuota
+	inFlight   int
+	windowFrom time.Time
+	windowHits int
+	requests   uint64
+	denied     uint64
+	lastActive time.Time
+}
+
+// Manager tracks per-user quota state.
+type Manager struct {
+	mu    sync.Mutex
+	cfg   config.QuotasConfig
+	users map[string]*userState
+	now   func() time.Time
+}
+
+// NewManager returns a Manager for the given configuration.
+func NewManager(cfg config.QuotasConfig) *Manager {
+	return &Manager{
+		cfg:   cfg,
+		users: make(map[string]*userState),
+		now:   time.Now,
+	}
+}
+
+// Enabled reports whether quota enforcement is active.
+func (m *Manager) Enabled() bool { return m != nil && m.cfg.Enabled }
+
+func (m *Manager) state(userID string) *userState {
+	st, ok := m.users[userID]
+	if !ok {
+		limits := m.cfg.Default
+		if u, ok := m.cfg.Users[userID]; ok {
+			limits = u
+		}
+		st = &userState{limits: limits}
+		m.users[userID] = st
+	}
+	return st
+}
+
+// Acquire records a request attempt for userID, returning an error if the
+// user is over a limit. On success the caller must call Release.
+func (m *Manager) Acquire(userID string) error {
+	if !m.Enabled() {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st := m.state(userID)
+	now := m.now()
+	st.lastActive = now
+
+	if st.limits.MaxConcurrent > 0 && st.inFlight >= st.limits.MaxConcurrent {
+		st.denied++
+		return fmt.Errorf("user %s: %w (max %d)", userID, ErrTooManyConcurrent, st.limits.MaxConcurrent)
+	}
+	if st.limits.RequestsPerMinute > 0 {
+		if now.Sub(st.windowFrom) >= time.Minute {
+			st.windowFrom = now
+			st.windowHits = 0
+		}
+		if st.windowHits >= st.limits.RequestsPerMinute {
+			st.denied++
+			return fmt.Errorf("user %s: %w (max %d/min)", userID, ErrRateLimited, st.limits.RequestsPerMinute)
+		}
+		st.windowHits++
+	}
+	st.inFlight++
+	st.requests++
+	return nil
+}
+
+// Release marks one of userID's in-flight requests as finished.
+func (m *Manager) Release(userID string) {
+	if !m.Enabled() {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if st, ok := m.users[userID]; ok && st.inFlight > 0 {
+		st.inFlight--
+	}
+}
+
+// UsageSnapshot returns per-user counters keyed by cloud user ID.
+func (m *Manager) UsageSnapshot() map[string]Usage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string]Usage, len(m.users))
+	for id, st := range m.users {
+		u := Usage{
+			Requests: st.requests,
+			Denied:   st.denied,
+			InFlight: st.inFlight,
+		}
+		if !st.lastActive.IsZero() {
+			u.LastActive = st.lastActive.UTC().Format(time.RFC3339)
+		}
+		out[id] = u
+	}
+	return out
+}
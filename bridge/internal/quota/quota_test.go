@@ -0,0 +1,93 @@
+package quota
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+)
+
+func TestConcurrencyCap(t *testing.T) {
+	m := NewManager(config.QuotasConfig{
+		Enabled: true,
+		Default: config.UserQuota{MaxConcurrent: 2},
+	})
+	if err := m.Acquire("alice"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Acquire("alice"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Acquire("alice"); !errors.Is(err, ErrTooManyConcurrent) {
+		t.Fatalf("expected concurrency error, got %v", err)
+	}
+	// Another user is unaffected.
+	if err := m.Acquire("bob"); err != nil {
+		t.Fatal(err)
+	}
+	m.Release("alice")
+	if err := m.Acquire("alice"); err != nil {
+		t.Fatalf("expected slot after release, got %v", err)
+	}
+}
+
+func TestRateLimitWindow(t *testing.T) {
+	m := NewManager(config.QuotasConfig{
+		Enabled: true,
+		Default: config.UserQuota{RequestsPerMinute: 2},
+	})
+	now := time.Unix(1000, 0)
+	m.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if err := m.Acquire("alice"); err != nil {
+			t.Fatal(err)
+		}
+		m.Release("alice")
+	}
+	if err := m.Acquire("alice"); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected rate limit error, got %v", err)
+	}
+	now = now.Add(61 * time.Second)
+	if err := m.Acquire("alice"); err != nil {
+		t.Fatalf("expected fresh window, got %v", err)
+	}
+}
+
+func TestPerUserOverride(t *testing.T) {
+	m := NewManager(config.QuotasConfig{
+		Enabled: true,
+		Default: config.UserQuota{MaxConcurrent: 1},
+		Users:   map[string]config.UserQuota{"power": {MaxConcurrent: 3}},
+	})
+	for i := 0; i < 3; i++ {
+		if err := m.Acquire("power"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.Acquire("power"); !errors.Is(err, ErrTooManyConcurrent) {
+		t.Fatalf("expected concurrency error, got %v", err)
+	}
+}
+
+func TestUsageSnapshot(t *testing.T) {
+	m := NewManager(config.QuotasConfig{Enabled: true})
+	if err := m.Acquire("alice"); err != nil {
+		t.Fatal(err)
+	}
+	usage := m.UsageSnapshot()
+	u, ok := usage["alice"]
+	if !ok || u.Requests != 1 || u.InFlight != 1 {
+		t.Fatalf("unexpected usage: %+v", usage)
+	}
+}
+
+func TestDisabledManagerAllowsAll(t *testing.T) {
+	m := NewManager(config.QuotasConfig{})
+	for i := 0; i < 100; i++ {
+		if err := m.Acquire("anyone"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
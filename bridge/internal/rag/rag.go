@@ -0,0 +1,296 @@
+// Package rag implements the optional on-device retrieval helper: files
+// in configured folders are chunked, embedded through the local Ollama
+// embedding model and stored in a SQLite vector table. The cloud chat
+// queries it through the tunnel's /api/retrieve endpoint; documents never
+// leave the machine.
+package rag
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/ollama"
+)
+
+// indexedExtensions are the file types picked up from watched folders.
+var indexedExtensions = map[string]bool{
+	".txt": true, ".md": true, ".rst": true, ".csv": true, ".json": true,
+}
+
+// maxFileSize skips files too large to chunk sensibly.
+const maxFileSize = 8 << 20
+
+// Result is one retrieved chunk.
+type Result struct {
+	Path    string  `json:"path"`
+	Chunk   int     `json:"chunk"`
+	Content string  `json:"content"`
+	Score   float64 `json:"score"`
+}
+
+// Index is the local document index.
+type Index struct {
+	cfg    config.RagConfig
+	ollama *ollama.Client
+	db     *sql.DB
+}
+
+// Open creates or opens the index database and returns an Index using the
+// given Ollama client for embeddings.
+func Open(cfg config.RagConfig, oc *ollama.Client, path string) (*Index, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("opening rag database: %w", err)
+	}
+	const schema = `
+CREATE TABLE IF NOT EXISTS chunks (
+	id        INTEGER PRIMARY KEY,
+	path      TEXT NOT NULL,
+	chunk     INTEGER NOT NULL,
+	content   TEXT NOT NULL,
+	embedding BLOB NOT NULL,
+	mtime     INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS chunks_path ON chunks(path);`
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating rag schema: %w", err)
+	}
+	return &Index{cfg: cfg, ollama: oc, db: db}, nil
+}
+
+// Close closes the index database.
+func (ix *Index) Close() error { return ix.db.Close() }
+
+// Run rescans the watched folders on the configured interval until ctx is
+// cancelled. The initial scan starts immediately.
+func (ix *Index) Run(ctx context.Context) {
+	interval := ix.cfg.RescanInterval
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	for {
+		if err := ix.Scan(ctx); err != nil && ctx.Err() == nil {
+			slog.Warn("RAG scan failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Scan walks the watched folders once, (re-)indexing new and modified
+// files and dropping chunks of deleted ones.
+func (ix *Index) Scan(ctx context.Context) error {
+	seen := make(map[string]bool)
+	for _, folder := range ix.cfg.Folders {
+		err := filepath.Walk(folder, func(path string, fi os.FileInfo, err error) error {
+			if err != nil || fi.IsDir() {
+				return nil
+			}
+			if !indexedExtensions[strings.ToLower(filepath.Ext(path))] || fi.Size() > maxFileSize {
+				return nil
+			}
+			seen[path] = true
+			return ix.indexFile(ctx, path, fi.ModTime())
+		})
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	return ix.pruneDeleted(seen)
+}
+
+// indexFile re-embeds a file when its mtime changed since the last scan.
+func (ix *Index) indexFile(ctx context.Context, path string, mtime time.Time) error {
+	var stored int64
+	err := ix.db.QueryRow(`SELECT mtime FROM chunks WHERE path = ? LIMIT 1`, path).Scan(&stored)
+	if err == nil && stored == mtime.UnixMilli() {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	chunks := splitChunks(string(data), ix.chunkSize())
+	if _, err := ix.db.Exec(`DELETE FROM chunks WHERE path = ?`, path); err != nil {
+		return err
+	}
+	for i, chunk := range chunks {
+		embedding, err := ix.embed(ctx, chunk)
+		if err != nil {
+			return fmt.Errorf("embedding %s: %w", path, err)
+		}
+		if _, err := ix.db.Exec(
+			`INSERT INTO chunks (path, chunk, content, embedding, mtime) VALUES (?, ?, ?, ?, ?)`,
+			path, i, chunk, encodeVector(embedding), mtime.UnixMilli()); err != nil {
+			return err
+		}
+	}
+	slog.Info("Indexed document", "path", path, "chunks", len(chunks))
+	return nil
+}
+
+func (ix *Index) pruneDeleted(seen map[string]bool) error {
+	rows, err := ix.db.Query(`SELECT DISTINCT path FROM chunks`)
+	if err != nil {
+		return err
+	}
+	var stale []string
+	for rows.Next() {
+		var path string
+		if rows.Scan(&path) == nil && !seen[path] {
+			stale = append(stale, path)
+		}
+	}
+	rows.Close()
+	for _, path := range stale {
+		if _, err := ix.db.Exec(`DELETE FROM chunks WHERE path = ?`, path); err != nil {
+			return err
+		}
+		slog.Info("Dropped deleted document from index", "path", path)
+	}
+	return nil
+}
+
+// Retrieve embeds the query and returns the topK most similar chunks by
+// cosine similarity. The scan is brute force, which is fine for the
+// personal-document scale this targets.
+func (ix *Index) Retrieve(ctx context.Context, query string, topK int) ([]Result, error) {
+	if topK <= 0 {
+		topK = ix.cfg.TopK
+	}
+	queryVec, err := ix.embed(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("embedding query: %w", err)
+	}
+	rows, err := ix.db.Query(`SELECT path, chunk, content, embedding FROM chunks`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var results []Result
+	for rows.Next() {
+		var r Result
+		var blob []byte
+		if err := rows.Scan(&r.Path, &r.Chunk, &r.Content, &blob); err != nil {
+			return nil, err
+		}
+		r.Score = cosine(queryVec, decodeVector(blob))
+		results = append(results, r)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if len(results) > topK {
+		results = results[:topK]
+	}
+	return results, rows.Err()
+}
+
+func (ix *Index) chunkSize() int {
+	if ix.cfg.ChunkSize > 0 {
+		return ix.cfg.ChunkSize
+	}
+	return 1000
+}
+
+// embed calls the local Ollama embeddings endpoint.
+func (ix *Index) embed(ctx context.Context, text string) ([]float32, error) {
+	body, _ := json.Marshal(map[string]string{"model": ix.cfg.EmbedModel, "prompt": text})
+	resp, err := ix.ollama.Forward(ctx, http.MethodPost, "/api/embeddings",
+		map[string]string{"Content-Type": "application/json"}, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Release()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ollama embeddings returned %d", resp.StatusCode)
+	}
+	var out struct {
+		Embedding []float32 `json:"embedding"`
+	}
+	if err := json.Unmarshal(resp.Body, &out); err != nil {
+		return nil, fmt.Errorf("parsing embedding: %w", err)
+	}
+	if len(out.Embedding) == 0 {
+		return nil, fmt.Errorf("model %q returned an empty embedding", ix.cfg.EmbedModel)
+	}
+	return out.Embedding, nil
+}
+
+// splitChunks cuts text into pieces of roughly size characters, breaking
+// on paragraph boundaries where possible.
+func splitChunks(text string, size int) []string {
+	var chunks []string
+	var current strings.Builder
+	for _, para := range strings.Split(text, "\n\n") {
+		if current.Len() > 0 && current.Len()+len(para) > size {
+			chunks = append(chunks, current.String())
+			current.Reset()
+		}
+		for len(para) > size {
+			chunks = append(chunks, para[:size])
+			para = para[size:]
+		}
+		if para != "" {
+			if current.Len() > 0 {
+				current.WriteString("\n\n")
+			}
+			current.WriteString(para)
+		}
+	}
+	if current.Len() > 0 {
+		chunks = append(chunks, current.String())
+	}
+	return chunks
+}
+
+func encodeVector(v []float32) []byte {
+	out := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(out[4*i:], math.Float32bits(f))
+	}
+	return out
+}
+
+func decodeVector(b []byte) []float32 {
+	v := make([]float32, len(b)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return v
+}
+
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,48 @@
+package rag
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestSplitChunksParagraphs(t *testing.T) {
+	text := "first paragraph\n\nsecond paragraph\n\nthird paragraph"
+	chunks := splitChunks(text, 35)
+	if len(chunks) < 2 {
+		t.Fatalf("expected multiple chunks, got %d: %q", len(chunks), chunks)
+	}
+	if joined := strings.Join(chunks, "\n\n"); joined != text {
+		t.Fatalf("chunks lost content: %q", joined)
+	}
+}
+
+func TestSplitChunksLongParagraph(t *testing.T) {
+	text := strings.Repeat("x", 2500)
+	chunks := splitChunks(text, 1000)
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+}
+
+func TestVectorRoundTrip(t *testing.T) {
+	v := []float32{0.5, -1.25, 3}
+	got := decodeVector(encodeVector(v))
+	for i := range v {
+		if got[i] != v[i] {
+			t.Fatalf("round trip mismatch at %d: %v != %v", i, got[i], v[i])
+		}
+	}
+}
+
+func TestCosine(t *testing.T) {
+	if c := cosine([]float32{1, 0}, []float32{1, 0}); math.Abs(c-1) > 1e-9 {
+		t.Fatalf("identical vectors: got %v", c)
+	}
+	if c := cosine([]float32{1, 0}, []float32{0, 1}); math.Abs(c) > 1e-9 {
+		t.Fatalf("orthogonal vectors: got %v", c)
+	}
+	if c := cosine([]float32{1, 0}, []float32{1}); c != 0 {
+		t.Fatalf("mismatched lengths: got %v", c)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !windows
+
+package resources
+
+import "errors"
+
+func freeRAM() (int64, error) {
+	return 0, errors.New("free memory reporting is not supported on this platform")
+}
@@ -0,0 +1,33 @@
+//go:build windows
+
+package resources
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// memoryStatusEx mirrors MEMORYSTATUSEX from the Windows API.
+type memoryStatusEx struct {
+	Length               uint32
+	MemoryLoad           uint32
+	TotalPhys            uint64
+	AvailPhys            uint64
+	TotalPageFile        uint64
+	AvailPageFile        uint64
+	TotalVirtual         uint64
+	AvailVirtual         uint64
+	AvailExtendedVirtual uint64
+}
+
+var globalMemoryStatusEx = syscall.NewLazyDLL("kernel32.dll").NewProc("GlobalMemoryStatusEx")
+
+func freeRAM() (int64, error) {
+	var m memoryStatusEx
+	m.Length = uint32(unsafe.Sizeof(m))
+	r, _, err := globalMemoryStatusEx.Call(uintptr(unsafe.Pointer(&m)))
+	if r == 0 {
+		return 0, err
+	}
+	return int64(m.AvailPhys), nil
+}
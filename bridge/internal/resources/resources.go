@@ -0,0 +1,51 @@
+// Package resources reports free system and GPU memory. Together with
+// the disk package it backs the guardrail checks that keep remote
+// operations (model pulls, large jobs) from exhausting the machine.
+package resources
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FreeRAMBytes returns the physical memory currently available to new
+// allocations. An error means the platform offers no measurement;
+// callers should skip their check rather than fail the operation.
+func FreeRAMBytes() (int64, error) {
+	return freeRAM()
+}
+
+// FreeVRAMBytes returns free GPU memory summed across NVIDIA devices,
+// measured via nvidia-smi. An error means it cannot be determined (no
+// NVIDIA GPU, tool missing); callers should skip their check.
+func FreeVRAMBytes() (int64, error) {
+	path, err := exec.LookPath("nvidia-smi")
+	if err != nil {
+		return 0, fmt.Errorf("nvidia-smi not found: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, path,
+		"--query-gpu=memory.free", "--format=csv,noheader,nounits").Output()
+	if err != nil {
+		return 0, fmt.Errorf("running nvidia-smi: %w", err)
+	}
+	var total int64
+	var seen bool
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		mib, err := strconv.ParseInt(strings.TrimSpace(line), 10, 64)
+		if err != nil {
+			continue
+		}
+		total += mib << 20
+		seen = true
+	}
+	if !seen {
+		return 0, fmt.Errorf("nvidia-smi reported no devices")
+	}
+	return total, nil
+}
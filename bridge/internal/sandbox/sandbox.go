@@ -0,0 +1,27 @@
+// Package sandbox detects confined packaging environments (Snap,
+// Flatpak) so filesystem paths and desktop integration can route
+// through the sandbox's interfaces instead of assuming a plain host.
+package sandbox
+
+import "os"
+
+// Snap reports whether the process runs inside a Snap package.
+func Snap() bool {
+	return os.Getenv("SNAP_NAME") != "" && os.Getenv("SNAP_USER_DATA") != ""
+}
+
+// Flatpak reports whether the process runs inside a Flatpak sandbox.
+func Flatpak() bool {
+	if os.Getenv("FLATPAK_ID") != "" {
+		return true
+	}
+	_, err := os.Stat("/.flatpak-info")
+	return err == nil
+}
+
+// Confined reports whether any sandbox confinement was detected.
+// Confined processes cannot exec host binaries like xdg-open or
+// notify-send and should use the XDG desktop portals instead.
+func Confined() bool {
+	return Snap() || Flatpak()
+}
@@ -0,0 +1,52 @@
+package share
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+)
+
+// Register announces a minted share to the relay so it can route guest
+// requests carrying the token to this bridge. The bridge still enforces
+// expiry and revocation locally; relay registration only makes the link
+// usable from the cloud side.
+func Register(ctx context.Context, cfg *config.Config, accessToken string, sh Share) error {
+	body := fmt.Sprintf(`{"token":%q,"expires_at":%q,"label":%q}`,
+		sh.Token, sh.ExpiresAt.Format(time.RFC3339), sh.Label)
+	return post(ctx, cfg.CloudURL("/ollama/bridge/share"), accessToken, body)
+}
+
+// Unregister tells the relay a share was revoked so it stops routing the
+// token immediately instead of waiting for expiry.
+func Unregister(ctx context.Context, cfg *config.Config, accessToken string, sh Share) error {
+	body := fmt.Sprintf(`{"token":%q}`, sh.Token)
+	return post(ctx, cfg.CloudURL("/ollama/bridge/share/revoke"), accessToken, body)
+}
+
+// GuestURL is the link handed to the guest: the cloud web app resolves
+// the embedded token to this bridge.
+func GuestURL(cfg *config.Config, sh Share) string {
+	return cfg.CloudBase() + "/share/" + sh.Token
+}
+
+func post(ctx context.Context, url, accessToken, body string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("building share request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("relay rejected share update with status %d", resp.StatusCode)
+	}
+	return nil
+}
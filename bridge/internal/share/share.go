@@ -0,0 +1,190 @@
+// Package share mints and enforces temporary access links: time-limited
+// guest tokens that let someone use this bridge for a few hours without
+// an entry on the access list. Shares live in shares.json next to the
+// config; the running bridge re-reads the file when it changes, so a
+// revocation from the CLI takes effect without a restart.
+package share
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+)
+
+// fileName is the share store file in the config directory.
+const fileName = "shares.json"
+
+// Share is one minted guest token.
+type Share struct {
+	// ID is the short public identifier used to revoke the share.
+	ID string `json:"id"`
+	// Token is the secret the guest presents; the relay attaches it to
+	// tunneled requests as shareToken.
+	Token string `json:"token"`
+	// Label is an optional note about who the share is for.
+	Label     string    `json:"label,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Revoked   bool      `json:"revoked,omitempty"`
+}
+
+// Expired reports whether the share is past its expiry.
+func (s Share) Expired() bool { return time.Now().After(s.ExpiresAt) }
+
+// Store reads and writes the share file.
+type Store struct {
+	path string
+
+	mu      sync.Mutex
+	shares  []Share
+	modTime time.Time
+}
+
+// NewStore returns a Store backed by shares.json in the config
+// directory.
+func NewStore() (*Store, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return nil, err
+	}
+	return &Store{path: filepath.Join(dir, fileName)}, nil
+}
+
+// Create mints a share valid for ttl and persists it.
+func (st *Store) Create(ttl time.Duration, label string) (Share, error) {
+	if ttl <= 0 {
+		return Share{}, fmt.Errorf("share duration must be positive")
+	}
+	id, err := randomHex(4)
+	if err != nil {
+		return Share{}, err
+	}
+	token, err := randomHex(16)
+	if err != nil {
+		return Share{}, err
+	}
+	now := time.Now()
+	sh := Share{ID: id, Token: token, Label: label, CreatedAt: now, ExpiresAt: now.Add(ttl)}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if err := st.reload(); err != nil {
+		return Share{}, err
+	}
+	st.shares = append(st.shares, sh)
+	if err := st.save(); err != nil {
+		return Share{}, err
+	}
+	return sh, nil
+}
+
+// Revoke marks the share with the given ID revoked. It returns the
+// revoked share so the caller can notify the relay.
+func (st *Store) Revoke(id string) (Share, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if err := st.reload(); err != nil {
+		return Share{}, err
+	}
+	for i := range st.shares {
+		if st.shares[i].ID == id {
+			st.shares[i].Revoked = true
+			if err := st.save(); err != nil {
+				return Share{}, err
+			}
+			return st.shares[i], nil
+		}
+	}
+	return Share{}, fmt.Errorf("no share with id %s", id)
+}
+
+// List returns all shares, including expired and revoked ones, oldest
+// first.
+func (st *Store) List() ([]Share, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if err := st.reload(); err != nil {
+		return nil, err
+	}
+	out := make([]Share, len(st.shares))
+	copy(out, st.shares)
+	return out, nil
+}
+
+// Valid reports whether a presented guest token matches a live share:
+// known, not revoked and not expired. The file is re-read when its
+// modification time changes, so revocations from another process apply
+// to requests already in flight through the tunnel.
+func (st *Store) Valid(token string) bool {
+	if st == nil || token == "" {
+		return false
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if err := st.reload(); err != nil {
+		return false
+	}
+	for _, sh := range st.shares {
+		if subtle.ConstantTimeCompare([]byte(sh.Token), []byte(token)) == 1 {
+			return !sh.Revoked && !sh.Expired()
+		}
+	}
+	return false
+}
+
+// reload re-reads the file if it changed since the last read. A missing
+// file is an empty store.
+func (st *Store) reload() error {
+	info, err := os.Stat(st.path)
+	if os.IsNotExist(err) {
+		st.shares = nil
+		st.modTime = time.Time{}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading share store: %w", err)
+	}
+	if info.ModTime().Equal(st.modTime) {
+		return nil
+	}
+	data, err := os.ReadFile(st.path)
+	if err != nil {
+		return fmt.Errorf("reading share store: %w", err)
+	}
+	var shares []Share
+	if err := json.Unmarshal(data, &shares); err != nil {
+		return fmt.Errorf("parsing %s: %w", st.path, err)
+	}
+	st.shares = shares
+	st.modTime = info.ModTime()
+	return nil
+}
+
+func (st *Store) save() error {
+	data, err := json.MarshalIndent(st.shares, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(st.path, data, 0o600); err != nil {
+		return fmt.Errorf("writing share store: %w", err)
+	}
+	if info, err := os.Stat(st.path); err == nil {
+		st.modTime = info.ModTime()
+	}
+	return nil
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generating share token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,146 @@
+// Package stats persists lifetime tunnel counters to disk so status
+// output survives restarts instead of resetting with every process.
+package stats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// flushInterval is how often accumulated counters hit the disk; a crash
+// loses at most this much history.
+const flushInterval = time.Minute
+
+// Totals are the cumulative counters carried across restarts.
+type Totals struct {
+	// Requests counts every request served, lifetime.
+	Requests uint64 `json:"requests"`
+	// Bytes counts response body bytes returned to the relay.
+	Bytes uint64 `json:"bytes"`
+	// UptimeSeconds is total time spent connected to the relay.
+	UptimeSeconds int64 `json:"uptime_seconds"`
+	// Reconnects counts relay connections established after the first.
+	Reconnects uint64 `json:"reconnects"`
+	// Since is when counting started (first run or last reset).
+	Since time.Time `json:"since"`
+}
+
+// Store accumulates counters in memory and flushes them to a JSON file.
+type Store struct {
+	path string
+
+	mu     sync.Mutex
+	totals Totals
+	dirty  bool
+}
+
+// Open loads the stats file, starting fresh when it does not exist.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		s.totals.Since = time.Now()
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading stats file: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.totals); err != nil {
+		return nil, fmt.Errorf("parsing stats file: %w", err)
+	}
+	if s.totals.Since.IsZero() {
+		s.totals.Since = time.Now()
+	}
+	return s, nil
+}
+
+// AddRequest records one served request and its response body size.
+func (s *Store) AddRequest(bytes uint64) {
+	s.mu.Lock()
+	s.totals.Requests++
+	s.totals.Bytes += bytes
+	s.dirty = true
+	s.mu.Unlock()
+}
+
+// AddReconnect records one re-established relay connection.
+func (s *Store) AddReconnect() {
+	s.mu.Lock()
+	s.totals.Reconnects++
+	s.dirty = true
+	s.mu.Unlock()
+}
+
+// AddUptime adds connected time to the lifetime total.
+func (s *Store) AddUptime(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	s.mu.Lock()
+	s.totals.UptimeSeconds += int64(d / time.Second)
+	s.dirty = true
+	s.mu.Unlock()
+}
+
+// Totals returns a snapshot of the lifetime counters.
+func (s *Store) Totals() Totals {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.totals
+}
+
+// Flush writes the counters to disk when they changed since last flush.
+func (s *Store) Flush() error {
+	s.mu.Lock()
+	if !s.dirty {
+		s.mu.Unlock()
+		return nil
+	}
+	totals := s.totals
+	s.dirty = false
+	s.mu.Unlock()
+	return write(s.path, totals)
+}
+
+// Reset zeroes all counters and rewrites the file.
+func (s *Store) Reset() error {
+	s.mu.Lock()
+	s.totals = Totals{Since: time.Now()}
+	s.dirty = false
+	totals := s.totals
+	s.mu.Unlock()
+	return write(s.path, totals)
+}
+
+// Run flushes on an interval until ctx is done, then flushes once more.
+func (s *Store) Run(ctx context.Context) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.Flush()
+			return
+		case <-ticker.C:
+			s.Flush()
+		}
+	}
+}
+
+// write lands the counters atomically so a crash mid-write cannot
+// corrupt the file.
+func write(path string, totals Totals) error {
+	data, err := json.MarshalIndent(totals, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("writing stats file: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,53 @@
+package stats
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTotalsPersistAcrossOpens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AddRequest(100)
+	s.AddRequest(50)
+	s.AddReconnect()
+	s.AddUptime(90 * time.Second)
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := reopened.Totals()
+	if got.Requests != 2 || got.Bytes != 150 || got.Reconnects != 1 || got.UptimeSeconds != 90 {
+		t.Fatalf("totals not persisted: %+v", got)
+	}
+	if got.Since.IsZero() {
+		t.Fatal("since timestamp missing")
+	}
+}
+
+func TestReset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AddRequest(10)
+	if err := s.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reopened.Totals(); got.Requests != 0 || got.Bytes != 0 {
+		t.Fatalf("reset did not zero counters: %+v", got)
+	}
+}
@@ -0,0 +1,107 @@
+// Package systemd implements the small subset of the systemd integration
+// protocols the bridge needs: LISTEN_FDS socket activation for the local
+// status server, and sd_notify readiness/watchdog pings so a hung bridge
+// is restarted by WatchdogSec.
+package systemd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// listenFDsStart is the first file descriptor passed by socket activation.
+const listenFDsStart = 3
+
+// ActivationListener returns the socket-activated listener when the
+// process was started by systemd with a socket unit, or (nil, false).
+func ActivationListener() (net.Listener, bool) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, false
+	}
+	nfds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || nfds < 1 {
+		return nil, false
+	}
+	file := os.NewFile(uintptr(listenFDsStart), "LISTEN_FD_3")
+	ln, err := net.FileListener(file)
+	file.Close()
+	if err != nil {
+		slog.Warn("Socket activation fd unusable", "error", err)
+		return nil, false
+	}
+	return ln, true
+}
+
+// notify sends one sd_notify datagram; it is a no-op outside systemd.
+func notify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("dialing NOTIFY_SOCKET: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return fmt.Errorf("writing sd_notify state: %w", err)
+	}
+	return nil
+}
+
+// NotifyReady tells systemd the bridge finished starting up.
+func NotifyReady() {
+	if err := notify("READY=1"); err != nil {
+		slog.Warn("sd_notify READY failed", "error", err)
+	}
+}
+
+// NotifyStopping tells systemd the bridge began shutting down.
+func NotifyStopping() {
+	if err := notify("STOPPING=1"); err != nil {
+		slog.Warn("sd_notify STOPPING failed", "error", err)
+	}
+}
+
+// watchdogInterval returns the WatchdogSec interval when the watchdog is
+// armed for this process.
+func watchdogInterval() (time.Duration, bool) {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0, false
+	}
+	if pidStr := os.Getenv("WATCHDOG_PID"); pidStr != "" {
+		if pid, err := strconv.Atoi(pidStr); err == nil && pid != os.Getpid() {
+			return 0, false
+		}
+	}
+	return time.Duration(usec) * time.Microsecond, true
+}
+
+// RunWatchdog sends WATCHDOG=1 pings at half the configured interval until
+// ctx is cancelled. It returns immediately when no watchdog is armed.
+func RunWatchdog(ctx context.Context) {
+	interval, ok := watchdogInterval()
+	if !ok {
+		return
+	}
+	slog.Info("systemd watchdog armed", "interval", interval)
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := notify("WATCHDOG=1"); err != nil {
+				slog.Warn("sd_notify WATCHDOG failed", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Package telemetry implements the opt-in uploader that periodically syncs
+// aggregated usage statistics and anonymized error summaries to the user's
+// cloud account, powering bridge analytics in the web dashboard.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+)
+
+// Report is one upload payload. Counters are cumulative for the current
+// bridge process; the dashboard derives deltas server-side.
+type Report struct {
+	BridgeID        string   `json:"bridge_id,omitempty"`
+	RequestsHandled uint64   `json:"requests_handled"`
+	RequestsDenied  uint64   `json:"requests_denied"`
+	Errors          []string `json:"errors,omitempty"`
+	ReportedAt      string   `json:"reported_at"`
+}
+
+// builtinRedactions always apply, regardless of configuration: bearer
+// tokens and JWT-looking strings must never leave the machine.
+var builtinRedactions = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9._~+/-]+=*`),
+	regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`),
+}
+
+// Uploader periodically posts Reports to the relay.
+type Uploader struct {
+	cfg      config.TelemetryConfig
+	baseURL  string
+	token    func() (string, error)
+	snapshot func() Report
+	redact   []*regexp.Regexp
+	client   *http.Client
+}
+
+// NewUploader returns an Uploader; snapshot provides the current counters
+// and raw error summaries at upload time.
+func NewUploader(cfg config.TelemetryConfig, baseURL string, token func() (string, error), snapshot func() Report) (*Uploader, error) {
+	u := &Uploader{
+		cfg:      cfg,
+		baseURL:  baseURL,
+		token:    token,
+		snapshot: snapshot,
+		redact:   builtinRedactions,
+		client:   &http.Client{Timeout: 15 * time.Second},
+	}
+	for _, p := range cfg.RedactPatterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("telemetry redact pattern %q: %w", p, err)
+		}
+		u.redact = append(u.redact, re)
+	}
+	return u, nil
+}
+
+// Run uploads on the configured interval until ctx is cancelled.
+func (u *Uploader) Run(ctx context.Context) {
+	interval := u.cfg.Interval
+	if interval <= 0 {
+		interval = 15 * time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := u.uploadOnce(ctx); err != nil {
+				slog.Warn("Telemetry upload failed", "error", err)
+			}
+		}
+	}
+}
+
+func (u *Uploader) uploadOnce(ctx context.Context) error {
+	report := u.snapshot()
+	report.ReportedAt = time.Now().UTC().Format(time.RFC3339)
+	if !u.cfg.IncludeErrors {
+		report.Errors = nil
+	}
+	for i, e := range report.Errors {
+		report.Errors[i] = u.Redact(e)
+	}
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	token, err := u.token()
+	if err != nil {
+		return fmt.Errorf("obtaining access token: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		u.baseURL+"/api/bridge/telemetry", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("relay returned %d", resp.StatusCode)
+	}
+	slog.Debug("Telemetry uploaded", "requests", report.RequestsHandled)
+	return nil
+}
+
+// Redact masks secrets and configured patterns in one error summary.
+func (u *Uploader) Redact(s string) string {
+	for _, re := range u.redact {
+		s = re.ReplaceAllString(s, "[redacted]")
+	}
+	return s
+}
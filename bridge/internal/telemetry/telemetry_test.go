@@ -0,0 +1,49 @@
+package telemetry
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+)
+
+func newTestUploader(t *testing.T, cfg config.TelemetryConfig) *Uploader {
+	t.Helper()
+	u, err := NewUploader(cfg, "http://localhost",
+		func() (string, error) { return "token", nil },
+		func() Report { return Report{} })
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestRedactBuiltins(t *testing.T) {
+	u := newTestUploader(t, config.TelemetryConfig{})
+	got := u.Redact("dial failed: Authorization: Bearer abc.def.ghi rejected")
+	if strings.Contains(got, "abc.def.ghi") {
+		t.Fatalf("bearer token not redacted: %s", got)
+	}
+	got = u.Redact("token eyJhbGciOi.eyJzdWIi.sig expired")
+	if strings.Contains(got, "eyJhbGciOi") {
+		t.Fatalf("jwt not redacted: %s", got)
+	}
+}
+
+func TestRedactConfiguredPattern(t *testing.T) {
+	u := newTestUploader(t, config.TelemetryConfig{
+		RedactPatterns: []string{`user-\d+`},
+	})
+	got := u.Redact("quota denied for user-12345")
+	if strings.Contains(got, "user-12345") {
+		t.Fatalf("configured pattern not redacted: %s", got)
+	}
+}
+
+func TestInvalidRedactPattern(t *testing.T) {
+	_, err := NewUploader(config.TelemetryConfig{RedactPatterns: []string{"("}},
+		"http://localhost", nil, nil)
+	if err == nil {
+		t.Fatal("expected error for invalid pattern")
+	}
+}
@@ -0,0 +1,240 @@
+// Package transcript stores prompts and responses passing through the
+// bridge in an encrypted local SQLite database. Capture is opt-in;
+// payloads are sealed with a per-installation key so a copied database
+// file exposes nothing without the key file next to it.
+package transcript
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	_ "modernc.org/sqlite"
+)
+
+// Entry is one captured exchange.
+type Entry struct {
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Model     string    `json:"model,omitempty"`
+	UserID    string    `json:"user_id,omitempty"`
+	Prompt    string    `json:"prompt"`
+	Response  string    `json:"response"`
+}
+
+// payload is the encrypted portion of an entry.
+type payload struct {
+	Prompt   string `json:"prompt"`
+	Response string `json:"response"`
+}
+
+// Store is an encrypted transcript database with time-based retention.
+type Store struct {
+	db        *sql.DB
+	key       [32]byte
+	retention time.Duration
+}
+
+// Open opens (creating if necessary) the transcript database at dbPath.
+// The encryption key is read from keyPath, generated on first use.
+// retention bounds how long entries are kept; zero keeps them forever.
+func Open(dbPath, keyPath string, retention time.Duration) (*Store, error) {
+	key, err := loadOrCreateKey(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("opening transcript database: %w", err)
+	}
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS transcripts (
+			id      TEXT PRIMARY KEY,
+			ts      INTEGER NOT NULL,
+			model   TEXT NOT NULL DEFAULT '',
+			user_id TEXT NOT NULL DEFAULT '',
+			sealed  BLOB NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS transcripts_ts ON transcripts(ts);
+	`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating transcript schema: %w", err)
+	}
+	s := &Store{db: db, key: key, retention: retention}
+	if err := s.Prune(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// loadOrCreateKey reads the 32-byte sealing key, generating and storing a
+// fresh one with owner-only permissions when the file does not exist.
+func loadOrCreateKey(path string) ([32]byte, error) {
+	var key [32]byte
+	raw, err := os.ReadFile(path)
+	if err == nil {
+		if len(raw) != len(key) {
+			return key, fmt.Errorf("transcript key %s has wrong length %d", path, len(raw))
+		}
+		copy(key[:], raw)
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return key, fmt.Errorf("reading transcript key: %w", err)
+	}
+	if _, err := rand.Read(key[:]); err != nil {
+		return key, fmt.Errorf("generating transcript key: %w", err)
+	}
+	if err := os.WriteFile(path, key[:], 0o600); err != nil {
+		return key, fmt.Errorf("writing transcript key: %w", err)
+	}
+	return key, nil
+}
+
+// Close closes the underlying database.
+func (s *Store) Close() error { return s.db.Close() }
+
+// Record seals and inserts one exchange, then applies retention.
+func (s *Store) Record(e Entry) error {
+	raw, err := json.Marshal(payload{Prompt: e.Prompt, Response: e.Response})
+	if err != nil {
+		return fmt.Errorf("encoding transcript: %w", err)
+	}
+	var nonce [24]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return fmt.Errorf("generating transcript nonce: %w", err)
+	}
+	sealed := secretbox.Seal(nonce[:], raw, &nonce, &s.key)
+	_, err = s.db.Exec(
+		`INSERT OR REPLACE INTO transcripts (id, ts, model, user_id, sealed) VALUES (?, ?, ?, ?, ?)`,
+		e.ID, e.Timestamp.UnixMilli(), e.Model, e.UserID, sealed)
+	if err != nil {
+		return fmt.Errorf("recording transcript: %w", err)
+	}
+	return s.Prune()
+}
+
+// Prune deletes entries older than the retention window.
+func (s *Store) Prune() error {
+	if s.retention <= 0 {
+		return nil
+	}
+	cutoff := time.Now().Add(-s.retention).UnixMilli()
+	if _, err := s.db.Exec(`DELETE FROM transcripts WHERE ts < ?`, cutoff); err != nil {
+		return fmt.Errorf("pruning transcripts: %w", err)
+	}
+	return nil
+}
+
+// open unseals one stored blob.
+func (s *Store) open(sealed []byte) (payload, error) {
+	var p payload
+	if len(sealed) < 24 {
+		return p, fmt.Errorf("sealed transcript too short")
+	}
+	var nonce [24]byte
+	copy(nonce[:], sealed[:24])
+	raw, ok := secretbox.Open(nil, sealed[24:], &nonce, &s.key)
+	if !ok {
+		return p, fmt.Errorf("decrypting transcript failed; wrong or replaced key file")
+	}
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return p, fmt.Errorf("decoding transcript: %w", err)
+	}
+	return p, nil
+}
+
+// List returns decrypted entries newer than since, newest first. A zero
+// since matches everything; limit zero means no limit.
+func (s *Store) List(since time.Time, limit int) ([]Entry, error) {
+	q := `SELECT id, ts, model, user_id, sealed FROM transcripts WHERE ts >= ? ORDER BY ts DESC`
+	if limit > 0 {
+		q += " LIMIT " + strconv.Itoa(limit)
+	}
+	rows, err := s.db.Query(q, since.UnixMilli())
+	if err != nil {
+		return nil, fmt.Errorf("querying transcripts: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Entry
+	for rows.Next() {
+		var e Entry
+		var ts int64
+		var sealed []byte
+		if err := rows.Scan(&e.ID, &ts, &e.Model, &e.UserID, &sealed); err != nil {
+			return nil, err
+		}
+		p, err := s.open(sealed)
+		if err != nil {
+			return nil, err
+		}
+		e.Timestamp = time.UnixMilli(ts)
+		e.Prompt = p.Prompt
+		e.Response = p.Response
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// ExportJSONL writes decrypted entries newer than since to w as one JSON
+// object per line, newest first.
+func (s *Store) ExportJSONL(w io.Writer, since time.Time) error {
+	entries, err := s.List(since, 0)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PromptFromBody extracts the user-visible prompt from a chat or generate
+// request body: the "prompt" field, or the last message's content.
+func PromptFromBody(body []byte) string {
+	var partial struct {
+		Prompt   string `json:"prompt"`
+		Messages []struct {
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(body, &partial); err != nil {
+		return ""
+	}
+	if partial.Prompt != "" {
+		return partial.Prompt
+	}
+	if n := len(partial.Messages); n > 0 {
+		return partial.Messages[n-1].Content
+	}
+	return ""
+}
+
+// Delta extracts the generated text from one Ollama NDJSON line: the
+// "response" field for /api/generate, or message.content for /api/chat.
+func Delta(line []byte) string {
+	var partial struct {
+		Response string `json:"response"`
+		Message  struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(line, &partial); err != nil {
+		return ""
+	}
+	if partial.Response != "" {
+		return partial.Response
+	}
+	return partial.Message.Content
+}
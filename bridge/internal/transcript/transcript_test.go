@@ -0,0 +1,76 @@
+package transcript
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordListRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(filepath.Join(dir, "t.db"), filepath.Join(dir, "t.key"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	entry := Entry{
+		ID:        "req-1",
+		Timestamp: time.Now(),
+		Model:     "llama3",
+		Prompt:    "hello",
+		Response:  "hi there",
+	}
+	if err := store.Record(entry); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.List(time.Time{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(got))
+	}
+	if got[0].Prompt != entry.Prompt || got[0].Response != entry.Response {
+		t.Fatalf("round trip mismatch: %+v", got[0])
+	}
+}
+
+func TestWrongKeyFailsToDecrypt(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(filepath.Join(dir, "t.db"), filepath.Join(dir, "key1"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Record(Entry{ID: "req-1", Timestamp: time.Now(), Prompt: "p", Response: "r"}); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	reopened, err := Open(filepath.Join(dir, "t.db"), filepath.Join(dir, "key2"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if _, err := reopened.List(time.Time{}, 0); err == nil {
+		t.Fatal("expected decryption failure with a different key")
+	}
+}
+
+func TestPromptFromBody(t *testing.T) {
+	if p := PromptFromBody([]byte(`{"prompt":"direct"}`)); p != "direct" {
+		t.Fatalf("prompt field: got %q", p)
+	}
+	if p := PromptFromBody([]byte(`{"messages":[{"content":"first"},{"content":"last"}]}`)); p != "last" {
+		t.Fatalf("last message: got %q", p)
+	}
+}
+
+func TestDelta(t *testing.T) {
+	if d := Delta([]byte(`{"response":"word"}`)); d != "word" {
+		t.Fatalf("generate delta: got %q", d)
+	}
+	if d := Delta([]byte(`{"message":{"content":"chat"}}`)); d != "chat" {
+		t.Fatalf("chat delta: got %q", d)
+	}
+}
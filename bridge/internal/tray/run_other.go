@@ -0,0 +1,13 @@
+//go:build !linux
+
+package tray
+
+import (
+	"context"
+	"fmt"
+)
+
+// Run is unavailable outside the Linux StatusNotifier implementation.
+func Run(ctx context.Context, source func() State, controls Controls) error {
+	return fmt.Errorf("tray icons are not implemented on this platform")
+}
@@ -0,0 +1,468 @@
+//go:build linux
+
+package tray
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// The tray is a native StatusNotifierItem: the bridge claims a
+// org.kde.StatusNotifierItem-<pid>-1 bus name, exports the item and a
+// com.canonical.dbusmenu menu, and registers with the desktop's watcher.
+// Talking DBus directly avoids the blank icons older tray libraries show
+// on KDE and GNOME.
+const (
+	itemPath  = "/StatusNotifierItem"
+	menuPath  = "/MenuBar"
+	itemIface = "org.kde.StatusNotifierItem"
+	menuIface = "com.canonical.dbusmenu"
+)
+
+// menu item IDs; 0 is the dbusmenu root.
+const (
+	menuStatusID = 1
+	menuPauseID  = 2
+	menuTestID   = 3
+	menuLogsID   = 4
+	menuMuteID   = 5
+	menuQuitID   = 6
+	menuCancelID = 7
+	menuDebugID  = 8
+	menuLoginID  = 9
+	menuShareID  = 10
+	menuLockID   = 11
+	menuQueueID  = 12
+)
+
+// pixmap is one ARGB32 icon frame in the wire format SNI hosts expect.
+type pixmap struct {
+	Width  int32
+	Height int32
+	Data   []byte
+}
+
+// tooltip matches the (sa(iiay)ss) ToolTip property signature.
+type tooltip struct {
+	IconName string
+	Pixmaps  []pixmap
+	Title    string
+	Text     string
+}
+
+// layoutNode matches the (ia{sv}av) dbusmenu layout signature.
+type layoutNode struct {
+	ID       int32
+	Props    map[string]dbus.Variant
+	Children []dbus.Variant
+}
+
+type sni struct {
+	conn     *dbus.Conn
+	source   func() State
+	controls Controls
+
+	mu       sync.Mutex
+	last     State
+	revision uint32
+}
+
+// Run shows the tray icon until the context is canceled. It returns an
+// error when the session bus or the StatusNotifierWatcher is unavailable;
+// callers fall back to headless operation.
+func Run(ctx context.Context, source func() State, controls Controls) error {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return fmt.Errorf("connecting to session bus: %w", err)
+	}
+	name := fmt.Sprintf("org.kde.StatusNotifierItem-%d-1", os.Getpid())
+	reply, err := conn.RequestName(name, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		return fmt.Errorf("requesting bus name: %w", err)
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		return fmt.Errorf("bus name %s already taken", name)
+	}
+	defer conn.ReleaseName(name)
+
+	s := &sni{conn: conn, source: source, controls: controls, last: source()}
+	if err := s.export(); err != nil {
+		return err
+	}
+	err = conn.Object(watcherName, "/StatusNotifierWatcher").
+		Call(watcherName+".RegisterStatusNotifierItem", 0, name).Err
+	if err != nil {
+		return fmt.Errorf("registering with StatusNotifierWatcher: %w", err)
+	}
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			s.refresh()
+		}
+	}
+}
+
+// export publishes the item, menu and their property handlers.
+func (s *sni) export() error {
+	for path, iface := range map[dbus.ObjectPath]string{itemPath: itemIface, menuPath: menuIface} {
+		if err := s.conn.Export(s, path, iface); err != nil {
+			return fmt.Errorf("exporting %s: %w", iface, err)
+		}
+		if err := s.conn.Export(s, path, "org.freedesktop.DBus.Properties"); err != nil {
+			return fmt.Errorf("exporting properties on %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// refresh emits change signals when the bridge state visibly changed.
+func (s *sni) refresh() {
+	st := s.source()
+	s.mu.Lock()
+	changed := st.Connected != s.last.Connected || st.Paused != s.last.Paused ||
+		st.AuthRequired != s.last.AuthRequired || st.Degraded != s.last.Degraded ||
+		st.Queued != s.last.Queued
+	tipChanged := changed || st.RequestsHandled != s.last.RequestsHandled
+	s.last = st
+	if changed {
+		s.revision++
+	}
+	revision := s.revision
+	s.mu.Unlock()
+
+	if changed {
+		s.conn.Emit(itemPath, itemIface+".NewIcon")
+		s.conn.Emit(itemPath, itemIface+".NewStatus", s.status(st))
+		s.conn.Emit(menuPath, menuIface+".LayoutUpdated", revision, int32(0))
+	}
+	if tipChanged {
+		s.conn.Emit(itemPath, itemIface+".NewToolTip")
+	}
+}
+
+func (s *sni) state() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last
+}
+
+func (s *sni) status(st State) string {
+	if st.Paused != "" || st.AuthRequired || st.Degraded {
+		return "NeedsAttention"
+	}
+	return "Active"
+}
+
+// icon renders a 22x22 filled circle in the status color: red when
+// re-login is required, green while connected, orange while paused,
+// yellow while registration is degraded, gray otherwise. The disc gets an
+// outline in the variant's contrast color so it stays visible on both
+// light and dark panels. Shipping pixmaps directly sidesteps icon theme
+// lookups that fail for unthemed apps.
+func (s *sni) icon(st State) []pixmap {
+	var r, g, b byte
+	switch {
+	case st.AuthRequired:
+		r, g, b = 0xD9, 0x3D, 0x2F
+	case st.Paused != "":
+		r, g, b = 0xE6, 0x9A, 0x1E
+	case st.Degraded:
+		r, g, b = 0xE6, 0xC1, 0x50
+	case st.Connected:
+		r, g, b = 0x2E, 0xB8, 0x5C
+	default:
+		r, g, b = 0x80, 0x80, 0x80
+	}
+	// Dark panels get a light outline and vice versa.
+	var outline byte = 0xF0
+	if iconTheme() == "light" {
+		outline = 0x30
+	}
+	const size, radius = 22, 9
+	data := make([]byte, size*size*4)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			dx, dy := x-size/2, y-size/2
+			d := dx*dx + dy*dy
+			if d > radius*radius {
+				continue
+			}
+			i := (y*size + x) * 4
+			if d > (radius-2)*(radius-2) {
+				data[i], data[i+1], data[i+2], data[i+3] = 0xFF, outline, outline, outline
+				continue
+			}
+			data[i], data[i+1], data[i+2], data[i+3] = 0xFF, r, g, b
+		}
+	}
+	return []pixmap{{Width: size, Height: size, Data: data}}
+}
+
+func (s *sni) tooltip(st State) tooltip {
+	text := "Disconnected from the relay"
+	switch {
+	case st.AuthRequired:
+		text = "Login required: run `bridge login`"
+	case st.Paused != "":
+		text = "Paused: " + st.Paused
+	case st.Degraded:
+		text = "Connected, but relay registration is failing"
+	case st.Connected:
+		text = fmt.Sprintf("Connected · %d requests served", st.RequestsHandled)
+	}
+	return tooltip{Title: "CloudToLocalLLM Bridge", Text: text}
+}
+
+// itemProps are the StatusNotifierItem properties hosts query.
+func (s *sni) itemProps() map[string]dbus.Variant {
+	st := s.state()
+	return map[string]dbus.Variant{
+		"Category":   dbus.MakeVariant("ApplicationStatus"),
+		"Id":         dbus.MakeVariant("cloudtolocalllm-bridge"),
+		"Title":      dbus.MakeVariant("CloudToLocalLLM Bridge"),
+		"Status":     dbus.MakeVariant(s.status(st)),
+		"IconName":   dbus.MakeVariant(""),
+		"IconPixmap": dbus.MakeVariant(s.icon(st)),
+		"ToolTip":    dbus.MakeVariant(s.tooltip(st)),
+		"Menu":       dbus.MakeVariant(dbus.ObjectPath(menuPath)),
+		"ItemIsMenu": dbus.MakeVariant(true),
+	}
+}
+
+// menuProps are the com.canonical.dbusmenu interface properties.
+func (s *sni) menuProps() map[string]dbus.Variant {
+	return map[string]dbus.Variant{
+		"Version":       dbus.MakeVariant(uint32(3)),
+		"Status":        dbus.MakeVariant("normal"),
+		"TextDirection": dbus.MakeVariant("ltr"),
+		"IconThemePath": dbus.MakeVariant([]string{}),
+	}
+}
+
+// Get implements org.freedesktop.DBus.Properties for both objects.
+func (s *sni) Get(iface, name string) (dbus.Variant, *dbus.Error) {
+	all, err := s.GetAll(iface)
+	if err != nil {
+		return dbus.Variant{}, err
+	}
+	v, ok := all[name]
+	if !ok {
+		return dbus.Variant{}, dbus.MakeFailedError(fmt.Errorf("no property %s.%s", iface, name))
+	}
+	return v, nil
+}
+
+// GetAll implements org.freedesktop.DBus.Properties for both objects.
+func (s *sni) GetAll(iface string) (map[string]dbus.Variant, *dbus.Error) {
+	switch iface {
+	case itemIface:
+		return s.itemProps(), nil
+	case menuIface:
+		return s.menuProps(), nil
+	}
+	return nil, dbus.MakeFailedError(fmt.Errorf("unknown interface %s", iface))
+}
+
+// Set implements org.freedesktop.DBus.Properties; nothing is writable.
+func (s *sni) Set(iface, name string, value dbus.Variant) *dbus.Error {
+	return dbus.MakeFailedError(fmt.Errorf("property %s.%s is read-only", iface, name))
+}
+
+// Activate handles a primary click; with ItemIsMenu set most hosts open
+// the menu instead.
+func (s *sni) Activate(x, y int32) *dbus.Error { return nil }
+
+// SecondaryActivate handles a middle click by toggling pause.
+func (s *sni) SecondaryActivate(x, y int32) *dbus.Error {
+	if s.controls.TogglePause != nil {
+		s.controls.TogglePause()
+		s.refresh()
+	}
+	return nil
+}
+
+// Scroll is part of the SNI interface; the bridge has nothing to scroll.
+func (s *sni) Scroll(delta int32, orientation string) *dbus.Error { return nil }
+
+// ContextMenu is handled by the host through the Menu property.
+func (s *sni) ContextMenu(x, y int32) *dbus.Error { return nil }
+
+// menuItems returns the current menu entries in display order.
+func (s *sni) menuItems() []struct {
+	id      int32
+	label   string
+	enabled bool
+} {
+	st := s.state()
+	status := "Disconnected"
+	switch {
+	case st.AuthRequired:
+		status = "Login required"
+	case st.Paused != "":
+		status = "Paused: " + st.Paused
+	case st.Degraded:
+		status = "Degraded: registration failing"
+	case st.Connected:
+		status = fmt.Sprintf("Connected · %d requests", st.RequestsHandled)
+	}
+	pause := "Pause forwarding"
+	if st.Paused != "" {
+		pause = "Resume forwarding"
+	}
+	mute := "Mute notifications"
+	if s.controls.Muted != nil && s.controls.Muted() {
+		mute = "Unmute notifications"
+	}
+	return []struct {
+		id      int32
+		label   string
+		enabled bool
+	}{
+		{menuStatusID, status, false},
+		{menuPauseID, pause, true},
+		{menuLoginID, "Copy login URL", st.AuthRequired && s.controls.CopyLoginURL != nil},
+		{menuCancelID, "Cancel active requests", s.controls.CancelRequests != nil},
+		{menuQueueID, fmt.Sprintf("Clear request queue (%d waiting)", st.Queued),
+			s.controls.ClearQueue != nil && st.Queued > 0},
+		{menuTestID, "Test generation", s.controls.TestGeneration != nil},
+		{menuLogsID, "View logs", s.controls.OpenLogs != nil},
+		{menuDebugID, "Debug logging for 10 min", s.controls.DebugLogs != nil},
+		{menuShareID, "Copy guest share link (4h)", s.controls.ShareLink != nil},
+		{menuLockID, "Emergency lockdown", s.controls.Lockdown != nil},
+		{menuMuteID, mute, s.controls.ToggleMute != nil},
+		{menuQuitID, "Quit bridge", true},
+	}
+}
+
+func (s *sni) itemProperties(id int32) map[string]dbus.Variant {
+	for _, item := range s.menuItems() {
+		if item.id != id {
+			continue
+		}
+		return map[string]dbus.Variant{
+			"label":   dbus.MakeVariant(item.label),
+			"enabled": dbus.MakeVariant(item.enabled),
+			"visible": dbus.MakeVariant(true),
+		}
+	}
+	return map[string]dbus.Variant{}
+}
+
+// GetLayout implements com.canonical.dbusmenu.
+func (s *sni) GetLayout(parent int32, depth int32, names []string) (uint32, layoutNode, *dbus.Error) {
+	s.mu.Lock()
+	revision := s.revision
+	s.mu.Unlock()
+
+	root := layoutNode{
+		ID:    0,
+		Props: map[string]dbus.Variant{"children-display": dbus.MakeVariant("submenu")},
+	}
+	for _, item := range s.menuItems() {
+		root.Children = append(root.Children, dbus.MakeVariant(layoutNode{
+			ID:    item.id,
+			Props: s.itemProperties(item.id),
+		}))
+	}
+	return revision, root, nil
+}
+
+// GetGroupProperties implements com.canonical.dbusmenu.
+func (s *sni) GetGroupProperties(ids []int32, names []string) ([]struct {
+	ID    int32
+	Props map[string]dbus.Variant
+}, *dbus.Error) {
+	var out []struct {
+		ID    int32
+		Props map[string]dbus.Variant
+	}
+	for _, id := range ids {
+		out = append(out, struct {
+			ID    int32
+			Props map[string]dbus.Variant
+		}{id, s.itemProperties(id)})
+	}
+	return out, nil
+}
+
+// GetProperty implements com.canonical.dbusmenu.
+func (s *sni) GetProperty(id int32, name string) (dbus.Variant, *dbus.Error) {
+	v, ok := s.itemProperties(id)[name]
+	if !ok {
+		return dbus.Variant{}, dbus.MakeFailedError(fmt.Errorf("no property %q on item %d", name, id))
+	}
+	return v, nil
+}
+
+// Event implements com.canonical.dbusmenu click handling.
+func (s *sni) Event(id int32, eventID string, data dbus.Variant, timestamp uint32) *dbus.Error {
+	if eventID != "clicked" {
+		return nil
+	}
+	switch id {
+	case menuPauseID:
+		if s.controls.TogglePause != nil {
+			s.controls.TogglePause()
+			s.refresh()
+		}
+	case menuLoginID:
+		if s.controls.CopyLoginURL != nil {
+			go s.controls.CopyLoginURL()
+		}
+	case menuCancelID:
+		if s.controls.CancelRequests != nil {
+			s.controls.CancelRequests()
+			s.refresh()
+		}
+	case menuQueueID:
+		if s.controls.ClearQueue != nil {
+			s.controls.ClearQueue()
+			s.refresh()
+		}
+	case menuTestID:
+		if s.controls.TestGeneration != nil {
+			go s.controls.TestGeneration()
+		}
+	case menuLogsID:
+		if s.controls.OpenLogs != nil {
+			s.controls.OpenLogs()
+		}
+	case menuDebugID:
+		if s.controls.DebugLogs != nil {
+			s.controls.DebugLogs()
+		}
+	case menuShareID:
+		if s.controls.ShareLink != nil {
+			go s.controls.ShareLink()
+		}
+	case menuLockID:
+		if s.controls.Lockdown != nil {
+			go s.controls.Lockdown()
+			s.refresh()
+		}
+	case menuMuteID:
+		if s.controls.ToggleMute != nil {
+			s.controls.ToggleMute()
+			s.refresh()
+		}
+	case menuQuitID:
+		if s.controls.Quit != nil {
+			s.controls.Quit()
+		}
+	}
+	return nil
+}
+
+// AboutToShow implements com.canonical.dbusmenu; the layout is always
+// current.
+func (s *sni) AboutToShow(id int32) (bool, *dbus.Error) { return false, nil }
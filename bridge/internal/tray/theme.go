@@ -0,0 +1,42 @@
+package tray
+
+import (
+	"sync"
+)
+
+// Icon theme handling: panels come in light and dark variants and an
+// icon drawn for one is invisible on the other. The variant is either
+// forced via bridge.tray_icon_theme or detected from the desktop's
+// color-scheme preference. On macOS the equivalent would be a template
+// icon; that waits for a macOS tray implementation.
+
+var (
+	themeMu       sync.Mutex
+	themeOverride string
+	themeDetected string
+)
+
+// SetIconTheme forces the icon variant: "light", "dark", or "auto"/""
+// to follow the desktop preference.
+func SetIconTheme(theme string) {
+	themeMu.Lock()
+	if theme == "auto" {
+		theme = ""
+	}
+	themeOverride = theme
+	themeMu.Unlock()
+}
+
+// iconTheme resolves the variant to draw for, detecting the desktop
+// preference once.
+func iconTheme() string {
+	themeMu.Lock()
+	defer themeMu.Unlock()
+	if themeOverride != "" {
+		return themeOverride
+	}
+	if themeDetected == "" {
+		themeDetected = detectTheme()
+	}
+	return themeDetected
+}
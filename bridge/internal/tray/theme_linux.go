@@ -0,0 +1,19 @@
+//go:build linux
+
+package tray
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// detectTheme asks the desktop for its color-scheme preference. GNOME
+// and modern KDE expose it through gsettings; anything else defaults to
+// dark, the more common panel background.
+func detectTheme() string {
+	out, err := exec.Command("gsettings", "get", "org.gnome.desktop.interface", "color-scheme").Output()
+	if err == nil && strings.Contains(string(out), "prefer-light") {
+		return "light"
+	}
+	return "dark"
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package tray
+
+// detectTheme defaults to dark panels on platforms without detection.
+func detectTheme() string {
+	return "dark"
+}
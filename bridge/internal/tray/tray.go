@@ -0,0 +1,66 @@
+// Package tray shows a status icon in the desktop's notification area.
+// Not every desktop has one (GNOME needs an extension, Wayland kiosks
+// have none at all), so callers should check Supported first and fall
+// back to headless operation with the local status dashboard.
+package tray
+
+// State is the subset of bridge status the tray surfaces.
+type State struct {
+	// Connected reports whether the tunnel currently has a relay
+	// connection.
+	Connected bool
+	// Paused carries the pause reason while forwarding is suspended.
+	Paused string
+	// AuthRequired reports that token refresh is failing and the user
+	// must log in again.
+	AuthRequired bool
+	// Degraded reports that relay registration keeps failing while the
+	// tunnel itself is up; cloud clients may not be routed here.
+	Degraded bool
+	// RequestsHandled is the total requests served this session.
+	RequestsHandled uint64
+	// Queued is how many requests are waiting for a concurrency slot,
+	// shown on the clear-queue entry so users see why things are slow.
+	Queued int
+}
+
+// Controls are the actions the tray menu exposes.
+type Controls struct {
+	// TogglePause pauses or resumes forwarding and returns the new pause
+	// reason (empty when resumed).
+	TogglePause func() string
+	// TestGeneration runs a tiny prompt against the local backend and
+	// reports the result in a notification, bypassing the cloud path.
+	TestGeneration func()
+	// CancelRequests aborts every in-flight request and returns how many
+	// were cancelled, for when a runaway generation hogs the machine.
+	CancelRequests func() int
+	// ClearQueue drops every request still waiting for a concurrency
+	// slot and returns how many were dropped; in-flight requests keep
+	// running.
+	ClearQueue func() int
+	// OpenLogs opens the local log viewer in the browser.
+	OpenLogs func()
+	// DebugLogs enables debug logging for a support window that reverts
+	// itself.
+	DebugLogs func()
+	// CopyLoginURL starts a fresh login and copies its URL to the
+	// clipboard instead of opening a browser, for remote-desktop
+	// sessions where the browser must run on the user's own machine.
+	// Shown while AuthRequired is set.
+	CopyLoginURL func()
+	// ShareLink mints a temporary guest share link and copies it to the
+	// clipboard, for lending the bridge to a teammate for a few hours.
+	ShareLink func()
+	// Lockdown engages the emergency lockdown: cancel everything, pause
+	// forwarding and revoke the relay session. Unlocking requires
+	// `bridge lockdown --unlock`, deliberately not a tray click.
+	Lockdown func()
+	// ToggleMute flips notification muting and returns the new state.
+	ToggleMute func() bool
+	// Muted reports whether notifications are currently muted, for the
+	// menu label.
+	Muted func() bool
+	// Quit shuts the bridge down.
+	Quit func()
+}
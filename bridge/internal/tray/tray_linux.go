@@ -0,0 +1,49 @@
+//go:build linux
+
+package tray
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// watcherName is the well-known bus name a StatusNotifier-capable desktop
+// registers.
+const watcherName = "org.kde.StatusNotifierWatcher"
+
+// Supported reports whether the desktop can host a tray icon: a session
+// bus must be reachable and a StatusNotifierWatcher with at least one
+// registered host must be present. The returned reason explains a false
+// result for the fallback log message.
+func Supported() (bool, string) {
+	if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" && os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+		return false, "no graphical session detected"
+	}
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return false, fmt.Sprintf("session bus unavailable: %v", err)
+	}
+
+	var hasWatcher bool
+	err = conn.BusObject().Call("org.freedesktop.DBus.NameHasOwner", 0, watcherName).Store(&hasWatcher)
+	if err != nil {
+		return false, fmt.Sprintf("querying StatusNotifierWatcher failed: %v", err)
+	}
+	if !hasWatcher {
+		return false, "no StatusNotifierWatcher on the session bus (GNOME needs the AppIndicator extension)"
+	}
+
+	var hostRegistered bool
+	err = conn.Object(watcherName, "/StatusNotifierWatcher").
+		Call("org.freedesktop.DBus.Properties.Get", 0, watcherName, "IsStatusNotifierHostRegistered").
+		Store(&hostRegistered)
+	if err != nil {
+		return false, fmt.Sprintf("querying StatusNotifierHost failed: %v", err)
+	}
+	if !hostRegistered {
+		return false, "StatusNotifierWatcher present but no host is showing icons"
+	}
+	return true, ""
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package tray
+
+// Supported reports tray availability. Only the Linux StatusNotifier
+// implementation exists today; other platforms run headless with the
+// local status dashboard.
+func Supported() (bool, string) {
+	return false, "tray icons are not implemented on this platform"
+}
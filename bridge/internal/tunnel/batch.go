@@ -0,0 +1,111 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+)
+
+// Batch messages bundle several sub-requests into one tunnel frame,
+// cutting per-message overhead for bulk jobs like embedding a document
+// set. Each sub-request runs through the full forwarding pipeline
+// (filter, quotas, access, limits) under a synthetic ID; the results
+// come back in submission order as a single batch_result. Sub-requests
+// run with the lesser of the message's requested concurrency and
+// limits.batch_concurrency.
+
+// maxBatchItems bounds the sub-requests in one batch message so a single
+// frame cannot queue unbounded work.
+const maxBatchItems = 64
+
+func (t *Tunnel) handleBatch(ctx context.Context, msg *Message) {
+	var data BatchData
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		slog.Error("Malformed batch message", "id", msg.ID, "error", err)
+		t.sendError(msg.ID, http.StatusBadRequest, "malformed batch")
+		return
+	}
+	if len(data.Requests) == 0 {
+		t.sendError(msg.ID, http.StatusBadRequest, "batch contains no requests")
+		return
+	}
+	if len(data.Requests) > maxBatchItems {
+		t.sendError(msg.ID, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("batch of %d requests exceeds the limit of %d", len(data.Requests), maxBatchItems))
+		return
+	}
+	if err := t.resourceGuardrailErr(false); err != nil {
+		slog.Info("Batch refused by resource guardrail", "id", msg.ID, "error", err)
+		t.sendError(msg.ID, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	go t.runBatch(ctx, msg.ID, &data)
+}
+
+func (t *Tunnel) runBatch(ctx context.Context, id string, data *BatchData) {
+	conc := data.Concurrency
+	if conc <= 0 {
+		conc = 1
+	}
+	if limit := t.cfg.Limits.BatchConcurrency; limit > 0 && conc > limit {
+		conc = limit
+	}
+	slog.Debug("Running batch", "id", id, "requests", len(data.Requests), "concurrency", conc)
+
+	results := make([]*ResponseData, len(data.Requests))
+	sem := make(chan struct{}, conc)
+	var wg sync.WaitGroup
+	for i := range data.Requests {
+		req := &data.Requests[i]
+		// Sub-results are buffered into the aggregate; a sub-request
+		// cannot stream on its own.
+		req.Stream = false
+		subID := fmt.Sprintf("%s#%d", id, i)
+		ch := make(chan *ResponseData, 1)
+		t.mu.Lock()
+		t.batchPending[subID] = ch
+		t.mu.Unlock()
+		wg.Add(1)
+		go func(i int, subID string, ch chan *ResponseData) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			t.handleOllamaRequest(ctx, subID, req)
+			results[i] = <-ch
+			t.mu.Lock()
+			delete(t.batchPending, subID)
+			t.mu.Unlock()
+		}(i, subID, ch)
+	}
+	wg.Wait()
+
+	raw, err := json.Marshal(BatchResultData{Results: results})
+	if err != nil {
+		slog.Error("Encoding batch result failed", "id", id, "error", err)
+		t.sendError(id, http.StatusInternalServerError, "encoding batch result failed")
+		return
+	}
+	t.sendMessage(&Message{Type: TypeBatchResult, ID: id, Data: raw})
+}
+
+// claimBatchResponse diverts the response for a batch sub-request ID to
+// its collector; it reports false when id is not a batch sub-request.
+func (t *Tunnel) claimBatchResponse(id string, data *ResponseData) bool {
+	t.mu.Lock()
+	ch := t.batchPending[id]
+	t.mu.Unlock()
+	if ch == nil {
+		return false
+	}
+	select {
+	case ch <- data:
+	default:
+		// A second response for the same sub-request is a bug upstream;
+		// keep the first.
+		slog.Warn("Dropping duplicate batch sub-response", "id", id)
+	}
+	return true
+}
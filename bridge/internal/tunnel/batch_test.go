@@ -0,0 +1,105 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/ollama"
+)
+
+// echoBackend answers every forward with the request body echoed back.
+type echoBackend struct{ Backend }
+
+func (b *echoBackend) Forward(ctx context.Context, method, path string, headers map[string]string, body []byte) (*ollama.Response, error) {
+	return &ollama.Response{
+		StatusCode: http.StatusOK,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       append([]byte(nil), body...),
+	}, nil
+}
+
+func batchTunnel(sent *[]*Message, mu *sync.Mutex) *Tunnel {
+	tn := &Tunnel{
+		cfg:          &config.Config{},
+		dedupe:       newDedupe(),
+		jobs:         make(map[string]*job),
+		batchPending: make(map[string]chan *ResponseData),
+		inflightReqs: make(map[string]*inflightRequest),
+		backends:     []backendRoute{{prefix: "/", name: "echo", client: &echoBackend{}}},
+	}
+	tn.sendHook = func(m *Message) {
+		mu.Lock()
+		*sent = append(*sent, m)
+		mu.Unlock()
+	}
+	return tn
+}
+
+func TestBatchAggregatesResultsInOrder(t *testing.T) {
+	var mu sync.Mutex
+	var sent []*Message
+	tn := batchTunnel(&sent, &mu)
+
+	raw, _ := json.Marshal(BatchData{
+		Requests: []RequestData{
+			{Method: "POST", Path: "/api/embed", Body: `{"input":"first"}`},
+			{Method: "POST", Path: "/api/embed", Body: `{"input":"second"}`},
+		},
+		Concurrency: 8,
+	})
+	tn.handleBatch(context.Background(), &Message{ID: "b1", Data: raw})
+
+	var result *Message
+	deadline := time.Now().Add(5 * time.Second)
+	for result == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("no batch_result within deadline")
+		}
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		for _, m := range sent {
+			if m.Type == TypeBatchResult {
+				result = m
+			}
+		}
+		mu.Unlock()
+	}
+	var data BatchResultData
+	if err := json.Unmarshal(result.Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Results) != 2 {
+		t.Fatalf("got %d results, want 2", len(data.Results))
+	}
+	if data.Results[0].Body != `{"input":"first"}` || data.Results[1].Body != `{"input":"second"}` {
+		t.Fatalf("results out of order: %q, %q", data.Results[0].Body, data.Results[1].Body)
+	}
+	if len(tn.batchPending) != 0 {
+		t.Fatalf("%d pending sub-requests leaked", len(tn.batchPending))
+	}
+}
+
+func TestBatchRejectsOversizedBatch(t *testing.T) {
+	var mu sync.Mutex
+	var sent []*Message
+	tn := batchTunnel(&sent, &mu)
+
+	reqs := make([]RequestData, maxBatchItems+1)
+	raw, _ := json.Marshal(BatchData{Requests: reqs})
+	tn.handleBatch(context.Background(), &Message{ID: "b2", Data: raw})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(sent) != 1 || sent[0].Type != TypeResponse {
+		t.Fatalf("oversized batch sent %+v, want one error response", sent)
+	}
+	var resp ResponseData
+	if err := json.Unmarshal(sent[0].Data, &resp); err != nil || resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized batch answered with status %d (%v)", resp.StatusCode, err)
+	}
+}
@@ -0,0 +1,71 @@
+package tunnel
+
+import (
+	"log/slog"
+	"math/rand"
+	"time"
+)
+
+// ChaosConfig is the test-only fault injection configuration, settable at
+// runtime through the control API's /debug/chaos endpoint when the
+// BRIDGE_CHAOS environment variable is set. It exists to exercise the
+// reconnect and retransmit paths; production builds never enable it.
+type ChaosConfig struct {
+	// DisconnectPercent randomly closes the relay connection on this
+	// percentage of incoming messages.
+	DisconnectPercent int `json:"disconnect_percent"`
+	// DelayMS sleeps this long before handling each incoming message.
+	DelayMS int `json:"delay_ms"`
+	// CorruptPercent replaces this percentage of outgoing frames with
+	// garbage, to verify the relay survives malformed bridge messages.
+	CorruptPercent int `json:"corrupt_percent"`
+}
+
+// SetChaos replaces the fault injection configuration. The zero value
+// disables all faults.
+func (t *Tunnel) SetChaos(c ChaosConfig) {
+	t.mu.Lock()
+	t.chaos = c
+	t.mu.Unlock()
+	slog.Warn("Chaos configuration changed",
+		"disconnect_percent", c.DisconnectPercent,
+		"delay_ms", c.DelayMS,
+		"corrupt_percent", c.CorruptPercent)
+}
+
+// Chaos returns the current fault injection configuration.
+func (t *Tunnel) Chaos() ChaosConfig {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.chaos
+}
+
+// chaosOnReceive applies configured receive-side faults and reports
+// whether the connection was deliberately dropped.
+func (t *Tunnel) chaosOnReceive() (dropped bool) {
+	c := t.Chaos()
+	if c.DelayMS > 0 {
+		time.Sleep(time.Duration(c.DelayMS) * time.Millisecond)
+	}
+	if c.DisconnectPercent > 0 && rand.Intn(100) < c.DisconnectPercent {
+		slog.Warn("Chaos: dropping relay connection")
+		t.mu.Lock()
+		conn := t.conn
+		t.mu.Unlock()
+		if conn != nil {
+			conn.Close()
+		}
+		return true
+	}
+	return false
+}
+
+// chaosCorrupt mangles an outgoing frame when corruption is enabled.
+func (t *Tunnel) chaosCorrupt(frame []byte) []byte {
+	c := t.Chaos()
+	if c.CorruptPercent > 0 && rand.Intn(100) < c.CorruptPercent {
+		slog.Warn("Chaos: corrupting outgoing frame")
+		return append([]byte("\x00corrupt\x00"), frame[:len(frame)/2]...)
+	}
+	return frame
+}
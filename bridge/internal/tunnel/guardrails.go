@@ -0,0 +1,37 @@
+package tunnel
+
+import (
+	"fmt"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/disk"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/resources"
+)
+
+// resourceGuardrailErr evaluates the resources config thresholds and
+// returns a descriptive error naming the first resource below its
+// guardrail, or nil. includeDisk is set for operations that write model
+// data (pulls); jobs and batches only consume memory. A measurement
+// that fails — unsupported platform, no NVIDIA GPU — skips its check
+// rather than blocking the operation.
+func (t *Tunnel) resourceGuardrailErr(includeDisk bool) error {
+	rc := t.cfg.Resources
+	if includeDisk && rc.MinFreeDiskBytes > 0 {
+		if free, err := disk.FreeBytes(modelsDir()); err == nil && free < rc.MinFreeDiskBytes {
+			return fmt.Errorf("only %d MB of disk free on the models volume, below the %d MB guardrail",
+				free>>20, rc.MinFreeDiskBytes>>20)
+		}
+	}
+	if rc.MinFreeRAMBytes > 0 {
+		if free, err := resources.FreeRAMBytes(); err == nil && free < rc.MinFreeRAMBytes {
+			return fmt.Errorf("only %d MB of RAM free, below the %d MB guardrail",
+				free>>20, rc.MinFreeRAMBytes>>20)
+		}
+	}
+	if rc.MinFreeVRAMBytes > 0 {
+		if free, err := resources.FreeVRAMBytes(); err == nil && free < rc.MinFreeVRAMBytes {
+			return fmt.Errorf("only %d MB of GPU memory free, below the %d MB guardrail",
+				free>>20, rc.MinFreeVRAMBytes>>20)
+		}
+	}
+	return nil
+}
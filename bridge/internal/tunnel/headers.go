@@ -0,0 +1,87 @@
+package tunnel
+
+import "strings"
+
+// strippedByDefault are response headers removed when headers.sanitize
+// is on: hop-by-hop headers that are meaningless across the tunnel, and
+// infrastructure-identifying headers that leak internal details (server
+// software, proxy hostnames, internal IPs) to cloud clients.
+var strippedByDefault = []string{
+	"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Connection",
+	"Te", "Trailer", "Transfer-Encoding", "Upgrade",
+	"Server", "X-Powered-By", "Via", "X-Real-Ip", "X-Forwarded-For",
+}
+
+// Limits on relay-supplied request headers, guarding the forwarding
+// path against oversized or smuggled header blocks.
+const (
+	maxRequestHeaders     = 64
+	maxRequestHeaderValue = 8 * 1024
+)
+
+// requestStrippedByDefault are relay-supplied request headers dropped
+// when headers.sanitize is on: hop-by-hop headers plus Host and
+// Content-Length, which the local transport computes itself and which
+// are the usual vehicles for header-smuggling attempts.
+var requestStrippedByDefault = []string{
+	"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization",
+	"Proxy-Connection", "Te", "Trailer", "Transfer-Encoding", "Upgrade",
+	"Host", "Content-Length",
+}
+
+// sanitizeRequestHeaders filters relay-supplied request headers: the
+// built-in strip list when sanitize is on, headers.request_strip, and
+// the size limits above. Headers exceeding the limits are dropped
+// rather than truncated.
+func (t *Tunnel) sanitizeRequestHeaders(h map[string]string) map[string]string {
+	pol := t.cfg.Headers
+	strip := make(map[string]bool, len(requestStrippedByDefault)+len(pol.RequestStrip))
+	if pol.Sanitize {
+		for _, k := range requestStrippedByDefault {
+			strip[strings.ToLower(k)] = true
+		}
+	}
+	for _, k := range pol.RequestStrip {
+		strip[strings.ToLower(k)] = true
+	}
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		if strip[strings.ToLower(k)] || len(v) > maxRequestHeaderValue {
+			continue
+		}
+		if len(out) >= maxRequestHeaders {
+			break
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// sanitizeHeaders applies the configured response header policy: the
+// built-in strip list when sanitize is on, then headers.strip, then
+// headers.set overrides. The input map is not modified.
+func (t *Tunnel) sanitizeHeaders(h map[string]string) map[string]string {
+	pol := t.cfg.Headers
+	if !pol.Sanitize && len(pol.Strip) == 0 && len(pol.Set) == 0 {
+		return h
+	}
+	strip := make(map[string]bool, len(strippedByDefault)+len(pol.Strip))
+	if pol.Sanitize {
+		for _, k := range strippedByDefault {
+			strip[strings.ToLower(k)] = true
+		}
+	}
+	for _, k := range pol.Strip {
+		strip[strings.ToLower(k)] = true
+	}
+	out := make(map[string]string, len(h)+len(pol.Set))
+	for k, v := range h {
+		if !strip[strings.ToLower(k)] {
+			out[k] = v
+		}
+	}
+	for k, v := range pol.Set {
+		out[k] = v
+	}
+	return out
+}
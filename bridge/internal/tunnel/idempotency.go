@@ -0,0 +1,70 @@
+package tunnel
+
+import (
+	"sync"
+	"time"
+)
+
+// dedupeTTL is how long a completed request's result is kept for
+// retransmit detection. Relay retries after a tunnel blip arrive well
+// within this window.
+const dedupeTTL = 2 * time.Minute
+
+// dedupeEntry tracks one seen request ID. resp is nil while the request
+// is still in flight; completed non-streaming requests cache their
+// response for replay.
+type dedupeEntry struct {
+	resp *ResponseData
+	seen time.Time
+}
+
+// dedupe detects retransmitted requests by message ID so a relay retry
+// after a dropped connection does not run the same generation twice.
+type dedupe struct {
+	mu      sync.Mutex
+	entries map[string]*dedupeEntry
+}
+
+func newDedupe() *dedupe {
+	return &dedupe{entries: make(map[string]*dedupeEntry)}
+}
+
+// begin records id as in flight. It returns duplicate=true when the ID
+// was already seen, along with the cached response if the original
+// request has completed (nil while still running).
+func (d *dedupe) begin(id string) (duplicate bool, cached *ResponseData) {
+	now := time.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for k, e := range d.entries {
+		if now.Sub(e.seen) > dedupeTTL {
+			delete(d.entries, k)
+		}
+	}
+	if e, ok := d.entries[id]; ok {
+		return true, e.resp
+	}
+	d.entries[id] = &dedupeEntry{seen: now}
+	return false, nil
+}
+
+// finish caches the response for id so a later retransmit can be answered
+// without re-running the request. A nil resp (streams, which cannot be
+// replayed) removes the entry instead.
+func (d *dedupe) finish(id string, resp *ResponseData) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if resp == nil {
+		delete(d.entries, id)
+		return
+	}
+	d.entries[id] = &dedupeEntry{resp: resp, seen: time.Now()}
+}
+
+// fail removes id so a retransmit of a request that errored is retried
+// rather than replayed.
+func (d *dedupe) fail(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.entries, id)
+}
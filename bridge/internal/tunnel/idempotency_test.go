@@ -0,0 +1,33 @@
+package tunnel
+
+import "testing"
+
+func TestDedupeReplaysCompletedRequest(t *testing.T) {
+	d := newDedupe()
+	if dup, _ := d.begin("r1"); dup {
+		t.Fatal("first begin reported duplicate")
+	}
+	d.finish("r1", &ResponseData{StatusCode: 200, Body: "ok"})
+	dup, cached := d.begin("r1")
+	if !dup || cached == nil || cached.Body != "ok" {
+		t.Fatalf("retransmit not answered from cache: dup=%v cached=%v", dup, cached)
+	}
+}
+
+func TestDedupeInFlightDuplicate(t *testing.T) {
+	d := newDedupe()
+	d.begin("r1")
+	dup, cached := d.begin("r1")
+	if !dup || cached != nil {
+		t.Fatalf("in-flight duplicate: dup=%v cached=%v", dup, cached)
+	}
+}
+
+func TestDedupeFailAllowsRetry(t *testing.T) {
+	d := newDedupe()
+	d.begin("r1")
+	d.fail("r1")
+	if dup, _ := d.begin("r1"); dup {
+		t.Fatal("failed request not retryable")
+	}
+}
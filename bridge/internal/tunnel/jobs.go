@@ -0,0 +1,168 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Async job flow for generations that outlive typical WebSocket or HTTP
+// timeouts. The relay submits a job_submit carrying a normal RequestData;
+// the bridge runs it detached from the connection that delivered it, so a
+// multi-minute 70B generation keeps going through a network hiccup. While
+// the job runs the bridge sends periodic job_progress heartbeats, and the
+// finished result goes out as job_result. A relay that reconnects mid-job
+// resubscribes with job_poll: it gets the cached result if the job
+// finished, a progress report if it is still running, or state "unknown"
+// (resubmit) if the job was never seen or its result has aged out.
+
+// jobTimeout bounds one job end to end; a forward still running at the
+// deadline is cancelled and reported as an error result.
+const jobTimeout = 30 * time.Minute
+
+// jobRetention is how long a finished job's result is kept for polls.
+// It is deliberately longer than dedupeTTL: the whole point of jobs is
+// surviving outages the plain retransmit window cannot.
+const jobRetention = 15 * time.Minute
+
+// jobProgressInterval spaces the running-job heartbeats.
+const jobProgressInterval = 15 * time.Second
+
+// job tracks one submitted job. result is nil while the job is running.
+type job struct {
+	started time.Time
+	done    time.Time
+	result  *ResponseData
+}
+
+func (t *Tunnel) handleJobSubmit(ctx context.Context, msg *Message) {
+	var data RequestData
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		slog.Error("Malformed job_submit message", "id", msg.ID, "error", err)
+		t.sendError(msg.ID, http.StatusBadRequest, "malformed job submission")
+		return
+	}
+	// Jobs are always buffered: the result has to be storable and
+	// re-deliverable after a reconnect, which a stream is not.
+	data.Stream = false
+	if err := t.resourceGuardrailErr(false); err != nil {
+		slog.Info("Job refused by resource guardrail", "id", msg.ID, "error", err)
+		t.sendError(msg.ID, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	t.mu.Lock()
+	t.pruneJobsLocked()
+	if j, ok := t.jobs[msg.ID]; ok {
+		result := j.result
+		t.mu.Unlock()
+		slog.Info("Ignoring resubmitted job", "id", msg.ID)
+		if result != nil {
+			t.sendJobResult(msg.ID, result)
+		} else {
+			t.sendJobStatus(msg.ID, "running")
+		}
+		return
+	}
+	j := &job{started: time.Now()}
+	t.jobs[msg.ID] = j
+	t.mu.Unlock()
+	slog.Info("Job accepted", "id", msg.ID, "path", data.Path)
+	t.sendJobStatus(msg.ID, "running")
+
+	// The job runs on its own context, not the connection's: a dropped
+	// WebSocket must not cancel the generation it asked for.
+	jctx, cancel := context.WithTimeout(context.Background(), jobTimeout)
+	go func() {
+		defer cancel()
+		t.handleOllamaRequest(jctx, msg.ID, &data)
+	}()
+	go t.jobHeartbeat(jctx, msg.ID)
+}
+
+// jobHeartbeat sends job_progress while the job context is live; the
+// runner cancels the context when the job finishes.
+func (t *Tunnel) jobHeartbeat(ctx context.Context, id string) {
+	ticker := time.NewTicker(jobProgressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.sendJobStatus(id, "running")
+		}
+	}
+}
+
+func (t *Tunnel) handleJobPoll(ctx context.Context, msg *Message) {
+	t.mu.Lock()
+	t.pruneJobsLocked()
+	j := t.jobs[msg.ID]
+	var result *ResponseData
+	if j != nil {
+		result = j.result
+	}
+	t.mu.Unlock()
+	switch {
+	case j == nil:
+		// Never seen (or aged out): the relay should resubmit.
+		t.sendJobStatus(msg.ID, "unknown")
+	case result != nil:
+		t.sendJobResult(msg.ID, result)
+	default:
+		t.sendJobStatus(msg.ID, "running")
+	}
+}
+
+// finishJob intercepts the response for a job ID: the result is cached
+// for later polls and delivered as job_result instead of a plain
+// response. It reports false when id is not a job, leaving the normal
+// response path to handle it.
+func (t *Tunnel) finishJob(id string, data *ResponseData) bool {
+	t.mu.Lock()
+	j := t.jobs[id]
+	if j == nil {
+		t.mu.Unlock()
+		return false
+	}
+	if j.result == nil {
+		j.result = data
+		j.done = time.Now()
+	}
+	t.mu.Unlock()
+	t.sendJobResult(id, data)
+	return true
+}
+
+// pruneJobsLocked drops finished jobs past the retention window. Caller
+// holds t.mu.
+func (t *Tunnel) pruneJobsLocked() {
+	for id, j := range t.jobs {
+		if !j.done.IsZero() && time.Since(j.done) > jobRetention {
+			delete(t.jobs, id)
+		}
+	}
+}
+
+func (t *Tunnel) sendJobStatus(id, state string) {
+	t.mu.Lock()
+	j := t.jobs[id]
+	t.mu.Unlock()
+	data := JobStatusData{JobID: id, State: state}
+	if j != nil {
+		data.ElapsedMs = time.Since(j.started).Milliseconds()
+	}
+	raw, _ := json.Marshal(data)
+	t.sendMessage(&Message{Type: TypeJobProgress, ID: id, Data: raw})
+}
+
+func (t *Tunnel) sendJobResult(id string, data *ResponseData) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		slog.Error("Encoding job result failed", "id", id, "error", err)
+		return
+	}
+	t.sendMessage(&Message{Type: TypeJobResult, ID: id, Data: raw})
+}
@@ -0,0 +1,79 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// jobTunnel builds a Tunnel with just enough wiring to exercise the job
+// table, capturing outgoing messages via sendHook.
+func jobTunnel(sent *[]*Message) *Tunnel {
+	tn := &Tunnel{jobs: make(map[string]*job)}
+	tn.sendHook = func(m *Message) { *sent = append(*sent, m) }
+	return tn
+}
+
+func TestJobPollStates(t *testing.T) {
+	var sent []*Message
+	tn := jobTunnel(&sent)
+	tn.jobs["j1"] = &job{started: time.Now()}
+
+	tn.handleJobPoll(context.Background(), &Message{ID: "j1"})
+	if len(sent) != 1 || sent[0].Type != TypeJobProgress {
+		t.Fatalf("poll of running job sent %+v, want job_progress", sent)
+	}
+	var status JobStatusData
+	if err := json.Unmarshal(sent[0].Data, &status); err != nil || status.State != "running" {
+		t.Fatalf("running job reported state %q (%v)", status.State, err)
+	}
+
+	sent = nil
+	tn.handleJobPoll(context.Background(), &Message{ID: "nope"})
+	if err := json.Unmarshal(sent[0].Data, &status); err != nil || status.State != "unknown" {
+		t.Fatalf("unknown job reported state %q (%v)", status.State, err)
+	}
+}
+
+func TestFinishJobCachesResult(t *testing.T) {
+	var sent []*Message
+	tn := jobTunnel(&sent)
+	tn.jobs["j1"] = &job{started: time.Now()}
+
+	if !tn.finishJob("j1", &ResponseData{StatusCode: 200, Body: "done"}) {
+		t.Fatal("finishJob did not claim the job id")
+	}
+	if len(sent) != 1 || sent[0].Type != TypeJobResult {
+		t.Fatalf("finish sent %+v, want job_result", sent)
+	}
+
+	// A later poll replays the cached result.
+	sent = nil
+	tn.handleJobPoll(context.Background(), &Message{ID: "j1"})
+	if len(sent) != 1 || sent[0].Type != TypeJobResult {
+		t.Fatalf("poll after finish sent %+v, want job_result", sent)
+	}
+
+	// Non-job ids fall through to the normal response path.
+	if tn.finishJob("other", &ResponseData{}) {
+		t.Fatal("finishJob claimed a non-job id")
+	}
+}
+
+func TestPruneJobs(t *testing.T) {
+	tn := &Tunnel{jobs: map[string]*job{
+		"old":     {started: time.Now(), done: time.Now().Add(-jobRetention - time.Minute), result: &ResponseData{}},
+		"fresh":   {started: time.Now(), done: time.Now(), result: &ResponseData{}},
+		"running": {started: time.Now()},
+	}}
+	tn.mu.Lock()
+	tn.pruneJobsLocked()
+	tn.mu.Unlock()
+	if _, ok := tn.jobs["old"]; ok {
+		t.Fatal("expired job not pruned")
+	}
+	if len(tn.jobs) != 2 {
+		t.Fatalf("pruned too much: %d jobs left, want 2", len(tn.jobs))
+	}
+}
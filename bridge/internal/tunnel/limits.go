@@ -0,0 +1,58 @@
+package tunnel
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// checkBodyLimits enforces the configured limits on a decoded request
+// body. A non-nil error describes the violated limit and warrants a 413
+// answer.
+func (t *Tunnel) checkBodyLimits(body []byte, textBody bool) error {
+	lim := t.cfg.Limits
+	if lim.MaxBodyBytes > 0 && int64(len(body)) > lim.MaxBodyBytes {
+		return fmt.Errorf("request body of %d bytes exceeds limits.max_body_bytes (%d)",
+			len(body), lim.MaxBodyBytes)
+	}
+	if lim.MaxJSONDepth > 0 && textBody && looksLikeJSON(body) {
+		if depth := jsonDepth(body, lim.MaxJSONDepth); depth > lim.MaxJSONDepth {
+			return fmt.Errorf("request JSON nested deeper than limits.max_json_depth (%d)",
+				lim.MaxJSONDepth)
+		}
+	}
+	return nil
+}
+
+func looksLikeJSON(body []byte) bool {
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')
+}
+
+// jsonDepth reports the maximum nesting depth of a JSON document,
+// stopping early once limit is exceeded. Malformed JSON yields the
+// depth reached before the error; Ollama rejects the document itself.
+func jsonDepth(body []byte, limit int) int {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	depth, deepest := 0, 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return deepest
+		}
+		if d, ok := tok.(json.Delim); ok {
+			switch d {
+			case '{', '[':
+				depth++
+				if depth > deepest {
+					deepest = depth
+				}
+				if deepest > limit {
+					return deepest
+				}
+			case '}', ']':
+				depth--
+			}
+		}
+	}
+}
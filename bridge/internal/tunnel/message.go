@@ -0,0 +1,271 @@
+package tunnel
+
+import (
+	"encoding/json"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/attest"
+)
+
+// Message is the JSON envelope exchanged with the relay over the bridge
+// WebSocket. The wire format matches api-backend/server.js.
+type Message struct {
+	Type      string          `json:"type"`
+	ID        string          `json:"id"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	Timestamp string          `json:"timestamp,omitempty"`
+	// Optional marks extension messages the receiver may not understand;
+	// an unknown optional message is acked with "unsupported" instead of
+	// logged as an error.
+	Optional bool `json:"optional,omitempty"`
+	// Channel tags the logical channel this message travels on; see
+	// ChannelData and ChannelControl. Receivers that predate channels
+	// ignore the field.
+	Channel int `json:"channel,omitempty"`
+}
+
+// Logical channels multiplexed over the single WebSocket. Control
+// traffic (heartbeats, hello, credits, cancel acks) is written ahead of
+// bulk data so it never queues behind a large response body — queued
+// pings were previously read as disconnects by the relay.
+const (
+	ChannelData    = 0
+	ChannelControl = 1
+)
+
+// controlType reports whether a message type is control-plane traffic
+// that belongs on ChannelControl.
+func controlType(mt string) bool {
+	switch mt {
+	case TypeHello, TypeAuth, TypePing, TypePong, TypeStreamCredit, TypeUnsupported:
+		return true
+	}
+	return false
+}
+
+// ProtocolVersion is the tunnel protocol revision this bridge speaks.
+// Version 1 is the original request/response protocol plus streaming;
+// the hello exchange itself is a version 1 addition that older relays
+// silently ignore.
+const ProtocolVersion = 1
+
+// Capability flags advertised in the hello exchange.
+const (
+	CapStreaming   = "streaming"
+	CapCompression = "compression"
+	CapBinary      = "binary"
+	CapCancel      = "cancel"
+	CapJobs        = "jobs"
+	CapBatch       = "batch"
+	CapPrefetch    = "prefetch"
+	CapModelOps    = "model_ops"
+)
+
+// Message types understood by the bridge and the relay.
+const (
+	// TypeHello carries the protocol version and capability flags; each
+	// side sends one after connecting.
+	TypeHello    = "hello"
+	TypeAuth     = "auth"
+	TypePing     = "ping"
+	TypePong     = "pong"
+	TypeRequest  = "request"
+	TypeResponse = "response"
+	// Streaming responses are delivered as a stream_start message with the
+	// status and headers, one stream_chunk per Ollama NDJSON line, and a
+	// final stream_end.
+	TypeStreamStart = "stream_start"
+	TypeStreamChunk = "stream_chunk"
+	TypeStreamEnd   = "stream_end"
+	// TypeStreamCredit grants the bridge additional chunk credits for one
+	// stream; see RequestData.Window.
+	TypeStreamCredit = "stream_credit"
+	// Async jobs: job_submit carries a RequestData the bridge runs
+	// detached from the connection; job_progress reports a running job,
+	// job_result delivers the finished response, and job_poll
+	// resubscribes after a reconnect. See jobs.go.
+	TypeJobSubmit   = "job_submit"
+	TypeJobProgress = "job_progress"
+	TypeJobResult   = "job_result"
+	TypeJobPoll     = "job_poll"
+	// Batches: a batch message bundles several sub-requests answered
+	// together as one batch_result. See batch.go.
+	TypeBatch       = "batch"
+	TypeBatchResult = "batch_result"
+	// Prefetch: the relay pushes models a user is about to need; the
+	// bridge pulls or warms them and reports readiness. See prefetch.go.
+	TypePrefetch       = "prefetch"
+	TypePrefetchStatus = "prefetch_status"
+	// Model management: model_op pulls, deletes or copies a local model
+	// behind a policy gate, with pull progress streamed back as
+	// model_op_progress. See modelmgmt.go.
+	TypeModelOp         = "model_op"
+	TypeModelOpProgress = "model_op_progress"
+	// TypeUnsupported acknowledges an optional message the receiver has no
+	// handler for; its data carries the original type.
+	TypeUnsupported = "unsupported"
+)
+
+// RequestData is the payload of a "request" message from the relay.
+type RequestData struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+	// BodyEncoding is "base64" when Body carries binary data (audio
+	// uploads, multipart payloads); empty means plain text.
+	BodyEncoding string `json:"bodyEncoding,omitempty"`
+	// UserID is the cloud user the relay attributes this request to, when
+	// the account has multiple users sharing one bridge.
+	UserID string `json:"userId,omitempty"`
+	// ShareToken is the temporary guest token the request arrived under,
+	// when the caller used a share link instead of an account; see the
+	// share package.
+	ShareToken string `json:"shareToken,omitempty"`
+	// ClientIP is the cloud client's address as seen by the relay, used
+	// for the X-Forwarded-For header when forward_client_info is set.
+	ClientIP string `json:"clientIp,omitempty"`
+	// Stream is set by relays that support the streaming response protocol
+	// for this request.
+	Stream bool `json:"stream,omitempty"`
+	// Window is the initial chunk credit for credit-based flow control.
+	// Zero disables flow control for this stream (legacy relays).
+	Window int `json:"window,omitempty"`
+	// Priority is the relay's traffic class for this request:
+	// "interactive" (the default when empty) for chat-style traffic, or
+	// "background"/"batch" for embedding and bulk jobs that should yield
+	// the GPU to interactive users when both queue up.
+	Priority string `json:"priority,omitempty"`
+	// DeadlineMs is the relay's remaining deadline budget for this
+	// request in milliseconds; zero means the client set none. A request
+	// whose estimated queue wait plus service time cannot fit in the
+	// budget is shed instead of forwarded.
+	DeadlineMs int `json:"deadlineMs,omitempty"`
+}
+
+// StreamCreditData is the payload of a "stream_credit" message from the
+// relay, granting further chunk credits for a slow-draining stream.
+type StreamCreditData struct {
+	Credits int `json:"credits"`
+}
+
+// StreamStartData is the payload of a "stream_start" message.
+type StreamStartData struct {
+	StatusCode int               `json:"statusCode"`
+	Headers    map[string]string `json:"headers,omitempty"`
+}
+
+// ResponseData is the payload of a "response" message to the relay.
+type ResponseData struct {
+	StatusCode int               `json:"statusCode"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Body       string            `json:"body,omitempty"`
+	// BodyEncoding is "base64" when Body carries a binary response.
+	BodyEncoding string `json:"bodyEncoding,omitempty"`
+	// Attestation, when attestation is enabled, signs the request and
+	// response digests with the bridge-held key; see the attest package.
+	Attestation *attest.Attestation `json:"attestation,omitempty"`
+	// Timing breaks down where this request's time went, for the cloud
+	// UI to show network versus model slowness.
+	Timing *TimingData `json:"timing,omitempty"`
+}
+
+// TimingData splits a request's wall time into phases, all in
+// milliseconds: waiting for a local concurrency slot, the local
+// server's time to first byte, generation, and encoding the response
+// for the tunnel. Buffered responses cannot separate first byte from
+// generation and report the whole forward as GenerateMs; for streams
+// serialization is interleaved with generation and left zero.
+type TimingData struct {
+	QueueMs     int64 `json:"queueMs"`
+	TTFBMs      int64 `json:"ttfbMs,omitempty"`
+	GenerateMs  int64 `json:"generateMs"`
+	SerializeMs int64 `json:"serializeMs,omitempty"`
+	TotalMs     int64 `json:"totalMs"`
+}
+
+// StreamEndData is the payload of a "stream_end" message, carrying the
+// timing breakdown and, when enabled, the attestation over the streamed
+// body.
+type StreamEndData struct {
+	Attestation *attest.Attestation `json:"attestation,omitempty"`
+	Timing      *TimingData         `json:"timing,omitempty"`
+}
+
+// BatchData is the payload of a "batch" message. Concurrency asks for
+// that many sub-requests in flight at once; the bridge clamps it to
+// limits.batch_concurrency.
+type BatchData struct {
+	Requests    []RequestData `json:"requests"`
+	Concurrency int           `json:"concurrency,omitempty"`
+}
+
+// BatchResultData is the payload of a "batch_result" message, carrying
+// one response per sub-request in submission order.
+type BatchResultData struct {
+	Results []*ResponseData `json:"results"`
+}
+
+// PrefetchData is the payload of a "prefetch" message listing models
+// the relay expects to be needed soon.
+type PrefetchData struct {
+	Models []string `json:"models"`
+}
+
+// PrefetchStatusData reports one model's prefetch outcome: "pulling"
+// while a download runs, then "ready" or "failed"; a bridge with
+// prefetch disabled answers once with state "disabled" and no model.
+type PrefetchStatusData struct {
+	Model string `json:"model,omitempty"`
+	State string `json:"state"`
+	Error string `json:"error,omitempty"`
+}
+
+// ModelOpData is the payload of a "model_op" message. Op is "pull",
+// "delete" or "copy"; Destination is the new name for a copy.
+type ModelOpData struct {
+	Op          string `json:"op"`
+	Model       string `json:"model"`
+	Destination string `json:"destination,omitempty"`
+}
+
+// JobStatusData is the payload of a "job_progress" message. State is
+// "running" for a live job or "unknown" when a poll names a job the
+// bridge has no record of (the relay should resubmit).
+type JobStatusData struct {
+	JobID     string `json:"jobId"`
+	State     string `json:"state"`
+	ElapsedMs int64  `json:"elapsedMs,omitempty"`
+}
+
+// HelloData is the payload of a "hello" message announcing the sender's
+// protocol version and optional capabilities.
+type HelloData struct {
+	ProtocolVersion int      `json:"protocolVersion"`
+	Capabilities    []string `json:"capabilities,omitempty"`
+}
+
+// Capable reports whether the hello advertised a capability; a zero
+// hello (legacy relay) advertises nothing.
+func (h HelloData) Capable(name string) bool {
+	for _, c := range h.Capabilities {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// PongData is the optional payload of a "pong" reply, carrying the
+// bridge's fleet identity (bridge.group and bridge.tags) so the relay
+// can filter and route across large installations. Relays that predate
+// it ignore the payload.
+type PongData struct {
+	Group string   `json:"group,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+// AuthData is the payload of the relay's post-connect "auth" message.
+type AuthData struct {
+	Success  bool   `json:"success"`
+	BridgeID string `json:"bridgeId"`
+}
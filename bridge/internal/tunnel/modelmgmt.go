@@ -0,0 +1,172 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/disk"
+)
+
+// Remote model management: model_op messages pull, delete or copy local
+// models explicitly, instead of smuggling those calls through raw
+// forwarding where they are indistinguishable from inference traffic.
+// The whole feature sits behind ollama.allow_remote_model_management
+// (off by default); pulls additionally check free disk space first and
+// stream the server's progress lines back as model_op_progress. The
+// final outcome arrives as a normal response message under the op's ID.
+
+// modelOpTimeout bounds one management operation; pulls of large models
+// dominate it.
+const modelOpTimeout = 30 * time.Minute
+
+func (t *Tunnel) handleModelOp(ctx context.Context, msg *Message) {
+	var data ModelOpData
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		slog.Error("Malformed model_op message", "id", msg.ID, "error", err)
+		t.sendError(msg.ID, http.StatusBadRequest, "malformed model operation")
+		return
+	}
+	if !t.cfg.Ollama.AllowRemoteModelManagement {
+		slog.Info("Model operation denied; remote model management is disabled",
+			"id", msg.ID, "op", data.Op, "model", data.Model)
+		t.sendError(msg.ID, http.StatusForbidden,
+			"remote model management is disabled on this bridge (ollama.allow_remote_model_management)")
+		return
+	}
+	if data.Model == "" {
+		t.sendError(msg.ID, http.StatusBadRequest, "model is required")
+		return
+	}
+	switch data.Op {
+	case "pull", "delete":
+	case "copy":
+		if data.Destination == "" {
+			t.sendError(msg.ID, http.StatusBadRequest, "copy requires a destination")
+			return
+		}
+	default:
+		t.sendError(msg.ID, http.StatusBadRequest,
+			fmt.Sprintf("unknown model operation %q (want pull, delete or copy)", data.Op))
+		return
+	}
+	slog.Info("Running model operation", "id", msg.ID, "op", data.Op, "model", data.Model)
+	go t.runModelOp(msg.ID, &data)
+}
+
+func (t *Tunnel) runModelOp(id string, data *ModelOpData) {
+	ctx, cancel := context.WithTimeout(context.Background(), modelOpTimeout)
+	defer cancel()
+	switch data.Op {
+	case "pull":
+		t.runModelPull(ctx, id, data.Model)
+	case "delete":
+		body := fmt.Sprintf(`{"name":%q}`, data.Model)
+		t.forwardModelOp(ctx, id, http.MethodDelete, "/api/delete", body)
+	case "copy":
+		body := fmt.Sprintf(`{"source":%q,"destination":%q}`, data.Model, data.Destination)
+		t.forwardModelOp(ctx, id, http.MethodPost, "/api/copy", body)
+	}
+}
+
+// runModelPull checks free space on the models volume, then streams the
+// pull so the relay can show download progress.
+func (t *Tunnel) runModelPull(ctx context.Context, id, model string) {
+	if err := t.resourceGuardrailErr(true); err != nil {
+		slog.Info("Model pull refused by resource guardrail", "id", id, "model", model, "error", err)
+		t.sendError(id, http.StatusInsufficientStorage, err.Error())
+		return
+	}
+	if minFree := t.cfg.Ollama.PullMinFreeBytes; minFree > 0 {
+		free, err := disk.FreeBytes(modelsDir())
+		switch {
+		case err != nil:
+			// Best effort: an unreadable volume should not block the
+			// pull, Ollama will fail it properly if space runs out.
+			slog.Warn("Checking free disk space failed", "error", err)
+		case free < minFree:
+			slog.Info("Model pull refused; disk space below threshold",
+				"id", id, "model", model, "free", free, "min_free", minFree)
+			t.sendError(id, http.StatusInsufficientStorage,
+				fmt.Sprintf("only %d MB free on the models volume, below the %d MB pull threshold",
+					free>>20, minFree>>20))
+			return
+		}
+	}
+	body := fmt.Sprintf(`{"name":%q,"stream":true}`, model)
+	headers := map[string]string{"Content-Type": "application/json"}
+	var status int
+	err := t.ollamaClient().ForwardStream(ctx, http.MethodPost, "/api/pull", headers, []byte(body),
+		func(s int, _ map[string]string) { status = s },
+		func(chunk []byte, binary bool) error {
+			if binary || len(chunk) == 0 {
+				return nil
+			}
+			t.sendModelOpProgress(id, chunk)
+			return nil
+		})
+	if err != nil {
+		slog.Error("Model pull failed", "id", id, "model", model, "error", err)
+		t.sendError(id, http.StatusBadGateway, "model pull failed")
+		return
+	}
+	if status >= 400 {
+		t.sendError(id, status, fmt.Sprintf("pull returned status %d", status))
+		return
+	}
+	slog.Info("Model pull finished", "id", id, "model", model)
+	t.sendResponse(id, &ResponseData{
+		StatusCode: http.StatusOK,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       `{"status":"success"}`,
+	})
+}
+
+// forwardModelOp runs a non-streaming management call and relays the
+// server's answer as the op's response.
+func (t *Tunnel) forwardModelOp(ctx context.Context, id, method, path, body string) {
+	headers := map[string]string{"Content-Type": "application/json"}
+	resp, err := t.ollamaClient().Forward(ctx, method, path, headers, []byte(body))
+	if err != nil {
+		slog.Error("Model operation failed", "id", id, "path", path, "error", err)
+		t.sendError(id, http.StatusBadGateway, "model operation failed")
+		return
+	}
+	defer resp.Release()
+	t.sendResponse(id, &ResponseData{
+		StatusCode: resp.StatusCode,
+		Headers:    t.sanitizeHeaders(resp.Headers),
+		Body:       string(resp.Body),
+	})
+}
+
+// sendModelOpProgress forwards one progress line from the pull stream.
+// Ollama emits JSON objects; anything else is wrapped so the payload
+// stays valid JSON.
+func (t *Tunnel) sendModelOpProgress(id string, chunk []byte) {
+	var raw json.RawMessage
+	if json.Valid(chunk) {
+		raw = append(json.RawMessage(nil), chunk...)
+	} else {
+		raw, _ = json.Marshal(map[string]string{"status": string(chunk)})
+	}
+	t.sendMessage(&Message{Type: TypeModelOpProgress, ID: id, Data: raw})
+}
+
+// modelsDir is the volume the disk-space check applies to: Ollama's
+// model store, falling back to the home directory when unset.
+func modelsDir() string {
+	if v := os.Getenv("OLLAMA_MODELS"); v != "" {
+		return v
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "."
+	}
+	return filepath.Join(home, ".ollama")
+}
@@ -0,0 +1,54 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+)
+
+func modelOpStatus(t *testing.T, tn *Tunnel, sent *[]*Message, data ModelOpData) int {
+	t.Helper()
+	*sent = nil
+	raw, _ := json.Marshal(data)
+	tn.handleModelOp(context.Background(), &Message{ID: "op1", Data: raw})
+	if len(*sent) != 1 || (*sent)[0].Type != TypeResponse {
+		t.Fatalf("model_op sent %+v, want one response", *sent)
+	}
+	var resp ResponseData
+	if err := json.Unmarshal((*sent)[0].Data, &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode
+}
+
+func TestModelOpPolicyGate(t *testing.T) {
+	var sent []*Message
+	tn := &Tunnel{cfg: &config.Config{}}
+	tn.sendHook = func(m *Message) { sent = append(sent, m) }
+
+	op := ModelOpData{Op: "delete", Model: "llama3"}
+	if got := modelOpStatus(t, tn, &sent, op); got != http.StatusForbidden {
+		t.Fatalf("disabled management answered %d, want 403", got)
+	}
+}
+
+func TestModelOpValidation(t *testing.T) {
+	var sent []*Message
+	cfg := &config.Config{}
+	cfg.Ollama.AllowRemoteModelManagement = true
+	tn := &Tunnel{cfg: cfg}
+	tn.sendHook = func(m *Message) { sent = append(sent, m) }
+
+	if got := modelOpStatus(t, tn, &sent, ModelOpData{Op: "install", Model: "llama3"}); got != http.StatusBadRequest {
+		t.Fatalf("unknown op answered %d, want 400", got)
+	}
+	if got := modelOpStatus(t, tn, &sent, ModelOpData{Op: "copy", Model: "llama3"}); got != http.StatusBadRequest {
+		t.Fatalf("copy without destination answered %d, want 400", got)
+	}
+	if got := modelOpStatus(t, tn, &sent, ModelOpData{Op: "pull"}); got != http.StatusBadRequest {
+		t.Fatalf("missing model answered %d, want 400", got)
+	}
+}
@@ -0,0 +1,154 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Prefetch hints let the relay announce models a user is about to need.
+// Policy permitting (ollama.allow_prefetch), the bridge pulls missing
+// models and warms present ones in the background, then reports
+// per-model readiness as prefetch_status messages — so the first real
+// request does not pay the pull or cold-load latency. A hint is only
+// ever a hint: denial or failure costs the user nothing but the warm-up.
+
+// maxPrefetchModels bounds one hint; more is a misbehaving relay.
+const maxPrefetchModels = 4
+
+// prefetchPullTimeout bounds a background pull; large models on slow
+// links can legitimately take this long.
+const prefetchPullTimeout = 30 * time.Minute
+
+// prefetchWarmTimeout bounds the empty generate that loads a present
+// model into memory.
+const prefetchWarmTimeout = 2 * time.Minute
+
+func (t *Tunnel) handlePrefetch(ctx context.Context, msg *Message) {
+	var data PrefetchData
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		slog.Warn("Malformed prefetch message", "id", msg.ID, "error", err)
+		return
+	}
+	if !t.cfg.Ollama.AllowPrefetch {
+		slog.Debug("Ignoring prefetch hint; ollama.allow_prefetch is off", "models", data.Models)
+		t.sendPrefetchStatus("", "disabled", "")
+		return
+	}
+	models := data.Models
+	if len(models) > maxPrefetchModels {
+		slog.Warn("Truncating oversized prefetch hint", "models", len(models), "limit", maxPrefetchModels)
+		models = models[:maxPrefetchModels]
+	}
+	go t.runPrefetch(models)
+}
+
+// runPrefetch works through one hint's models sequentially: pulls are
+// disk- and network-heavy, and warming loads compete for VRAM.
+func (t *Tunnel) runPrefetch(models []string) {
+	for _, m := range models {
+		if m == "" {
+			continue
+		}
+		t.mu.Lock()
+		if t.prefetching[m] {
+			t.mu.Unlock()
+			continue
+		}
+		t.prefetching[m] = true
+		t.mu.Unlock()
+		t.prefetchModel(m)
+		t.mu.Lock()
+		delete(t.prefetching, m)
+		t.mu.Unlock()
+	}
+}
+
+func (t *Tunnel) prefetchModel(model string) {
+	if !t.modelPresent(model) {
+		if err := t.resourceGuardrailErr(true); err != nil {
+			slog.Info("Prefetch refused by resource guardrail", "model", model, "error", err)
+			t.sendPrefetchStatus(model, "failed", err.Error())
+			return
+		}
+		t.sendPrefetchStatus(model, "pulling", "")
+		slog.Info("Prefetch pulling model", "model", model)
+		ctx, cancel := context.WithTimeout(context.Background(), prefetchPullTimeout)
+		defer cancel()
+		body := fmt.Sprintf(`{"name":%q,"stream":false}`, model)
+		resp, err := t.ollamaClient().Forward(ctx, http.MethodPost, "/api/pull",
+			map[string]string{"Content-Type": "application/json"}, []byte(body))
+		if err != nil {
+			slog.Warn("Prefetch pull failed", "model", model, "error", err)
+			t.sendPrefetchStatus(model, "failed", "pull failed")
+			return
+		}
+		status := resp.StatusCode
+		resp.Release()
+		if status >= 400 {
+			slog.Warn("Prefetch pull rejected", "model", model, "status", status)
+			t.sendPrefetchStatus(model, "failed", fmt.Sprintf("pull returned status %d", status))
+			return
+		}
+	}
+	// An empty generate loads the model into memory so the first real
+	// request starts from a warm state.
+	ctx, cancel := context.WithTimeout(context.Background(), prefetchWarmTimeout)
+	defer cancel()
+	body := fmt.Sprintf(`{"model":%q}`, model)
+	resp, err := t.ollamaClient().Forward(ctx, http.MethodPost, "/api/generate",
+		map[string]string{"Content-Type": "application/json"}, []byte(body))
+	if err != nil {
+		slog.Warn("Prefetch warm-up failed", "model", model, "error", err)
+		t.sendPrefetchStatus(model, "failed", "warm-up failed")
+		return
+	}
+	status := resp.StatusCode
+	resp.Release()
+	if status >= 400 {
+		t.sendPrefetchStatus(model, "failed", fmt.Sprintf("warm-up returned status %d", status))
+		return
+	}
+	slog.Info("Prefetched model ready", "model", model)
+	t.sendPrefetchStatus(model, "ready", "")
+}
+
+// modelPresent reports whether the local server already has the model.
+// When the listing itself fails the model is treated as present: pulling
+// gigabytes on the strength of a failed status call is the wrong bet,
+// and the warm-up step will surface the real error.
+func (t *Tunnel) modelPresent(model string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	resp, err := t.ollamaClient().Forward(ctx, http.MethodGet, "/api/tags", nil, nil)
+	if err != nil {
+		return true
+	}
+	defer resp.Release()
+	if resp.StatusCode >= 400 {
+		return true
+	}
+	var tags struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.Unmarshal(resp.Body, &tags); err != nil {
+		return true
+	}
+	for _, m := range tags.Models {
+		if m.Name == model || strings.TrimSuffix(m.Name, ":latest") == model {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Tunnel) sendPrefetchStatus(model, state, errMsg string) {
+	raw, _ := json.Marshal(PrefetchStatusData{Model: model, State: state, Error: errMsg})
+	t.sendMessage(&Message{Type: TypePrefetchStatus, Data: raw, Optional: true})
+}
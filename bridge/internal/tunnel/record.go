@@ -0,0 +1,126 @@
+package tunnel
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// recordedMessage is one JSONL line in a session recording: the incoming
+// relay message plus the local arrival time.
+type recordedMessage struct {
+	ReceivedAt string  `json:"received_at"`
+	Message    Message `json:"message"`
+}
+
+// Recorder appends incoming tunnel messages to a writer as JSONL, for
+// offline replay against local Ollama with `bridge replay`.
+type Recorder struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewRecorder returns a Recorder writing to w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: w}
+}
+
+// Record writes one incoming message to the recording.
+func (r *Recorder) Record(msg *Message) {
+	line, err := json.Marshal(recordedMessage{
+		ReceivedAt: time.Now().UTC().Format(time.RFC3339Nano),
+		Message:    *msg,
+	})
+	if err != nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.w.Write(append(line, '\n'))
+}
+
+// SetRecorder attaches a session recorder; nil disables recording.
+func (t *Tunnel) SetRecorder(r *Recorder) { t.recorder = r }
+
+// Exec routes one request through the normal request pipeline — route
+// rules, filters, limits, quotas — against the local backends and
+// returns the response, for `bridge exec`. Streaming is disabled since
+// there is no relay to apply flow control.
+func (t *Tunnel) Exec(ctx context.Context, req *RequestData) (*ResponseData, error) {
+	results := make(chan *Message, 1)
+	t.sendHook = func(msg *Message) {
+		if msg.Type == TypeResponse {
+			results <- msg
+		}
+	}
+	defer func() { t.sendHook = nil }()
+
+	req.Stream = false
+	t.handleOllamaRequest(ctx, uuid.NewString(), req)
+	select {
+	case msg := <-results:
+		var resp ResponseData
+		if err := json.Unmarshal(msg.Data, &resp); err != nil {
+			return nil, fmt.Errorf("parsing response: %w", err)
+		}
+		return &resp, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Replay feeds the request messages of a recorded session through the
+// normal request handler against local Ollama, writing one summary line
+// per request to out. Streaming requests are replayed as buffered
+// requests since there is no relay to apply flow control.
+func (t *Tunnel) Replay(ctx context.Context, in io.Reader, out io.Writer) error {
+	results := make(chan *Message, 1)
+	t.sendHook = func(msg *Message) {
+		if msg.Type == TypeResponse {
+			results <- msg
+		}
+	}
+	defer func() { t.sendHook = nil }()
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), int(t.cfg.Cloud.WSMaxMessageSize))
+	n := 0
+	for scanner.Scan() {
+		var rec recordedMessage
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return fmt.Errorf("parsing recording line %d: %w", n+1, err)
+		}
+		if rec.Message.Type != TypeRequest {
+			continue
+		}
+		var req RequestData
+		if err := json.Unmarshal(rec.Message.Data, &req); err != nil {
+			return fmt.Errorf("parsing request %s: %w", rec.Message.ID, err)
+		}
+		req.Stream = false
+		n++
+		start := time.Now()
+		t.handleOllamaRequest(ctx, rec.Message.ID, &req)
+		select {
+		case msg := <-results:
+			var resp ResponseData
+			json.Unmarshal(msg.Data, &resp)
+			fmt.Fprintf(out, "%s %s %s -> %d (%d bytes, %s)\n",
+				rec.Message.ID, req.Method, req.Path,
+				resp.StatusCode, len(resp.Body), time.Since(start).Round(time.Millisecond))
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading recording: %w", err)
+	}
+	fmt.Fprintf(out, "replayed %d requests\n", n)
+	return nil
+}
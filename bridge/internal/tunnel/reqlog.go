@@ -0,0 +1,77 @@
+package tunnel
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+)
+
+// routeLog is one compiled logging.routes entry; see config.RouteLogConfig.
+type routeLog struct {
+	prefix string
+	level  slog.Level
+	off    bool
+	bodies bool
+}
+
+// maxLoggedBody bounds a request body included in a log line so one huge
+// prompt cannot blow up the log file.
+const maxLoggedBody = 2048
+
+// compileRouteLogs orders the configured entries longest prefix first so
+// a /api/chat entry wins over a broader /api one.
+func compileRouteLogs(routes []config.RouteLogConfig) []routeLog {
+	out := make([]routeLog, 0, len(routes))
+	for _, r := range routes {
+		rl := routeLog{prefix: r.Path, bodies: r.Bodies}
+		switch r.Level {
+		case "off":
+			rl.off = true
+		case "info":
+			rl.level = slog.LevelInfo
+		default:
+			rl.level = slog.LevelDebug
+		}
+		out = append(out, rl)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return len(out[i].prefix) > len(out[j].prefix) })
+	return out
+}
+
+// logRequest emits the per-request log line, honoring any logging.routes
+// entry matching the path: entries can silence chatty endpoints, raise
+// routes under investigation to info, or opt a route into body logging.
+// Unmatched requests log at debug without bodies; bodies are only ever
+// logged for text payloads on routes that explicitly opt in, and pass
+// through the handler's redaction like every other log line.
+func (t *Tunnel) logRequest(id string, req *RequestData, body []byte) {
+	level := slog.LevelDebug
+	logBody := false
+	for _, rl := range t.routeLogs {
+		if strings.HasPrefix(req.Path, rl.prefix) {
+			if rl.off {
+				return
+			}
+			level, logBody = rl.level, rl.bodies
+			break
+		}
+	}
+	if !slog.Default().Enabled(context.Background(), level) {
+		return
+	}
+	attrs := []any{"id", id, "method", req.Method, "path", req.Path, "bytes", len(body)}
+	if req.UserID != "" {
+		attrs = append(attrs, "user", req.UserID)
+	}
+	if logBody && req.BodyEncoding == "" {
+		b := body
+		if len(b) > maxLoggedBody {
+			b = b[:maxLoggedBody]
+		}
+		attrs = append(attrs, "body", string(b))
+	}
+	slog.Log(context.Background(), level, "Tunneled request", attrs...)
+}
@@ -0,0 +1,34 @@
+package tunnel
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+)
+
+func TestCompileRouteLogsLongestPrefixFirst(t *testing.T) {
+	logs := compileRouteLogs([]config.RouteLogConfig{
+		{Path: "/api/", Level: "off"},
+		{Path: "/api/chat", Level: "info", Bodies: true},
+	})
+	if len(logs) != 2 {
+		t.Fatalf("compiled %d entries, want 2", len(logs))
+	}
+	if logs[0].prefix != "/api/chat" {
+		t.Fatalf("longest prefix should sort first, got %q", logs[0].prefix)
+	}
+	if logs[0].level != slog.LevelInfo || !logs[0].bodies {
+		t.Fatal("chat entry lost its level or bodies flag")
+	}
+	if !logs[1].off {
+		t.Fatal("off entry not marked off")
+	}
+}
+
+func TestCompileRouteLogsDefaultLevel(t *testing.T) {
+	logs := compileRouteLogs([]config.RouteLogConfig{{Path: "/v1/"}})
+	if logs[0].level != slog.LevelDebug || logs[0].off || logs[0].bodies {
+		t.Fatalf("empty level should mean debug without bodies, got %+v", logs[0])
+	}
+}
@@ -0,0 +1,54 @@
+package tunnel
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+)
+
+func TestBackendForRuleRewrite(t *testing.T) {
+	rule := config.RouteRule{Path: "/lmstudio/*", Rewrite: "/v1/$1"}
+	re, err := rule.Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lm := &ollamaStub{}
+	tn := &Tunnel{
+		cfg:          &config.Config{},
+		backendRules: []backendRule{{re: re, rewrite: rule.Rewrite, name: "lmstudio", client: lm}},
+	}
+
+	backend, path := tn.backendFor("/lmstudio/chat/completions")
+	if backend != lm {
+		t.Fatalf("rule did not route to backend, got %T", backend)
+	}
+	if path != "/v1/chat/completions" {
+		t.Fatalf("rewrite produced %q, want /v1/chat/completions", path)
+	}
+
+	// Unmatched paths fall through to Ollama with the path unchanged.
+	backend, path = tn.backendFor("/api/generate")
+	if backend == lm || path != "/api/generate" {
+		t.Fatalf("unmatched path routed to %T with path %q", backend, path)
+	}
+}
+
+func TestBackendForRuleBeforePrefix(t *testing.T) {
+	ruleBackend := &ollamaStub{}
+	prefixBackend := &ollamaStub{}
+	tn := &Tunnel{
+		cfg:          &config.Config{},
+		backends:     []backendRoute{{prefix: "/api/", name: "prefix", client: prefixBackend}},
+		backendRules: []backendRule{{re: regexp.MustCompile(`^/api/special$`), name: "rule", client: ruleBackend}},
+	}
+	if backend, _ := tn.backendFor("/api/special"); backend != ruleBackend {
+		t.Fatal("rule should win over prefix routes")
+	}
+	if backend, _ := tn.backendFor("/api/generate"); backend != prefixBackend {
+		t.Fatal("prefix route should still serve non-rule paths")
+	}
+}
+
+// ollamaStub satisfies Backend without any network behavior.
+type ollamaStub struct{ Backend }
@@ -0,0 +1,120 @@
+package tunnel
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAcquireSlotShedsOnDeadline(t *testing.T) {
+	tn := &Tunnel{maxConcurrent: 1, avgService: time.Second}
+	if err := tn.acquireSlot(context.Background(), 0, slotWaiter{}); err != nil {
+		t.Fatal(err)
+	}
+	// The single slot is busy and recent requests take a second, so a
+	// 100ms deadline cannot be met.
+	if err := tn.acquireSlot(context.Background(), 100*time.Millisecond, slotWaiter{}); err != errWouldExceedDeadline {
+		t.Fatalf("want errWouldExceedDeadline, got %v", err)
+	}
+}
+
+func TestReleaseSlotHandsToWaiter(t *testing.T) {
+	tn := &Tunnel{maxConcurrent: 1}
+	if err := tn.acquireSlot(context.Background(), 0, slotWaiter{}); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- tn.acquireSlot(context.Background(), 0, slotWaiter{}) }()
+	waitForQueued(t, tn, 1)
+
+	tn.releaseSlot()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("queued waiter not granted the slot: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("queued waiter still blocked after release")
+	}
+	tn.mu.Lock()
+	defer tn.mu.Unlock()
+	if tn.inflight != 1 {
+		t.Fatalf("inflight = %d after handoff, want 1", tn.inflight)
+	}
+}
+
+func TestInteractiveWaiterJumpsBackgroundQueue(t *testing.T) {
+	tn := &Tunnel{maxConcurrent: 1}
+	if err := tn.acquireSlot(context.Background(), 0, slotWaiter{}); err != nil {
+		t.Fatal(err)
+	}
+	batch := make(chan error, 1)
+	go func() { batch <- tn.acquireSlot(context.Background(), 0, slotWaiter{background: true}) }()
+	waitForQueued(t, tn, 1)
+	chat := make(chan error, 1)
+	go func() { chat <- tn.acquireSlot(context.Background(), 0, slotWaiter{}) }()
+	waitForQueued(t, tn, 2)
+
+	// The background waiter arrived first, but the interactive one gets
+	// the freed slot.
+	tn.releaseSlot()
+	select {
+	case err := <-chat:
+		if err != nil {
+			t.Fatalf("interactive waiter not granted the slot: %v", err)
+		}
+	case err := <-batch:
+		t.Fatalf("background waiter scheduled before interactive one: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no waiter granted the slot")
+	}
+}
+
+func waitForQueued(t *testing.T, tn *Tunnel, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		tn.mu.Lock()
+		queued := len(tn.slotWaiters)
+		tn.mu.Unlock()
+		if queued == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("queue never reached %d waiters", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestClearQueueDropsWaiters(t *testing.T) {
+	tn := &Tunnel{maxConcurrent: 1}
+	if err := tn.acquireSlot(context.Background(), 0, slotWaiter{}); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- tn.acquireSlot(context.Background(), 0,
+			slotWaiter{id: "q1", path: "/api/chat", model: "llama3", priority: "background", background: true})
+	}()
+	waitForQueued(t, tn, 1)
+
+	queued := tn.QueuedRequests()
+	if len(queued) != 1 || queued[0].ID != "q1" || queued[0].Path != "/api/chat" || queued[0].Model != "llama3" {
+		t.Fatalf("queue snapshot = %+v", queued)
+	}
+	if n := tn.ClearQueue(); n != 1 {
+		t.Fatalf("ClearQueue dropped %d waiters, want 1", n)
+	}
+	select {
+	case err := <-done:
+		if err != errQueueCleared {
+			t.Fatalf("cleared waiter got %v, want errQueueCleared", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cleared waiter still blocked")
+	}
+	if len(tn.QueuedRequests()) != 0 {
+		t.Fatal("queue not empty after clear")
+	}
+}
@@ -0,0 +1,193 @@
+package tunnel
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The transfer channel moves documents into the bridge without stuffing
+// multi-MB files into single JSON request bodies: a transfer_start with
+// name, size and checksum, base64 chunks in order, and a transfer_end
+// that is answered with a regular response message once the checksum
+// verifies. Files land in the transfers/ directory under the data dir.
+const (
+	TypeTransferStart = "transfer_start"
+	TypeTransferChunk = "transfer_chunk"
+	TypeTransferEnd   = "transfer_end"
+)
+
+// CapTransfer is advertised in the hello exchange when the transfer
+// channel is available.
+const CapTransfer = "transfer"
+
+// maxTransferSize bounds one transferred file.
+const maxTransferSize = 1 << 30
+
+// TransferStartData is the payload of a "transfer_start" message.
+type TransferStartData struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// TransferChunkData is the payload of a "transfer_chunk" message. Chunks
+// arrive in order on the single tunnel connection.
+type TransferChunkData struct {
+	Seq  int    `json:"seq"`
+	Data string `json:"data"`
+}
+
+// transfer is one in-flight incoming file.
+type transfer struct {
+	name      string
+	size      int64
+	sha256    string
+	file      *os.File
+	hash      hash.Hash
+	written   int64
+	nextSeq   int
+	tmpPath   string
+	finalPath string
+}
+
+func (t *Tunnel) handleTransferStart(ctx context.Context, msg *Message) {
+	var data TransferStartData
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		t.sendError(msg.ID, http.StatusBadRequest, "malformed transfer_start")
+		return
+	}
+	name := filepath.Base(data.Name)
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\\") {
+		t.sendError(msg.ID, http.StatusBadRequest, "invalid transfer name")
+		return
+	}
+	if data.Size <= 0 || data.Size > maxTransferSize {
+		t.sendError(msg.ID, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("transfer size must be 1-%d bytes", maxTransferSize))
+		return
+	}
+	dataDir, err := t.cfg.DataDir()
+	if err != nil {
+		t.sendError(msg.ID, http.StatusInternalServerError, "no data directory")
+		return
+	}
+	dir := filepath.Join(dataDir, "transfers")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		t.sendError(msg.ID, http.StatusInternalServerError, "creating transfers directory failed")
+		return
+	}
+	tmpPath := filepath.Join(dir, "."+name+".partial")
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		t.sendError(msg.ID, http.StatusInternalServerError, "creating transfer file failed")
+		return
+	}
+	t.mu.Lock()
+	t.transfers[msg.ID] = &transfer{
+		name:      name,
+		size:      data.Size,
+		sha256:    strings.ToLower(data.SHA256),
+		file:      f,
+		hash:      sha256.New(),
+		tmpPath:   tmpPath,
+		finalPath: filepath.Join(dir, name),
+	}
+	t.mu.Unlock()
+	slog.Info("Incoming transfer started", "id", msg.ID, "name", name, "size", data.Size)
+}
+
+func (t *Tunnel) handleTransferChunk(ctx context.Context, msg *Message) {
+	var data TransferChunkData
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		t.abortTransfer(msg.ID, http.StatusBadRequest, "malformed transfer_chunk")
+		return
+	}
+	t.mu.Lock()
+	tr := t.transfers[msg.ID]
+	t.mu.Unlock()
+	if tr == nil {
+		t.sendError(msg.ID, http.StatusBadRequest, "no transfer in progress for this id")
+		return
+	}
+	if data.Seq != tr.nextSeq {
+		t.abortTransfer(msg.ID, http.StatusBadRequest,
+			fmt.Sprintf("chunk %d out of order (expected %d)", data.Seq, tr.nextSeq))
+		return
+	}
+	chunk, err := base64.StdEncoding.DecodeString(data.Data)
+	if err != nil {
+		t.abortTransfer(msg.ID, http.StatusBadRequest, "chunk is not valid base64")
+		return
+	}
+	if tr.written+int64(len(chunk)) > tr.size {
+		t.abortTransfer(msg.ID, http.StatusBadRequest, "transfer exceeds announced size")
+		return
+	}
+	if _, err := tr.file.Write(chunk); err != nil {
+		t.abortTransfer(msg.ID, http.StatusInternalServerError, "writing transfer chunk failed")
+		return
+	}
+	tr.hash.Write(chunk)
+	tr.written += int64(len(chunk))
+	tr.nextSeq++
+}
+
+func (t *Tunnel) handleTransferEnd(ctx context.Context, msg *Message) {
+	t.mu.Lock()
+	tr := t.transfers[msg.ID]
+	delete(t.transfers, msg.ID)
+	t.mu.Unlock()
+	if tr == nil {
+		t.sendError(msg.ID, http.StatusBadRequest, "no transfer in progress for this id")
+		return
+	}
+	tr.file.Close()
+	if tr.written != tr.size {
+		os.Remove(tr.tmpPath)
+		t.sendError(msg.ID, http.StatusBadRequest,
+			fmt.Sprintf("transfer truncated: got %d of %d bytes", tr.written, tr.size))
+		return
+	}
+	sum := hex.EncodeToString(tr.hash.Sum(nil))
+	if tr.sha256 != "" && sum != tr.sha256 {
+		os.Remove(tr.tmpPath)
+		t.sendError(msg.ID, http.StatusUnprocessableEntity, "transfer checksum mismatch")
+		return
+	}
+	if err := os.Rename(tr.tmpPath, tr.finalPath); err != nil {
+		os.Remove(tr.tmpPath)
+		t.sendError(msg.ID, http.StatusInternalServerError, "finalizing transfer failed")
+		return
+	}
+	slog.Info("Transfer complete", "id", msg.ID, "name", tr.name, "sha256", sum)
+	body, _ := json.Marshal(map[string]string{"path": tr.finalPath, "sha256": sum})
+	t.sendResponse(msg.ID, &ResponseData{
+		StatusCode: http.StatusOK,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(body),
+	})
+}
+
+// abortTransfer tears down an in-flight transfer and reports the error.
+func (t *Tunnel) abortTransfer(id string, status int, reason string) {
+	t.mu.Lock()
+	tr := t.transfers[id]
+	delete(t.transfers, id)
+	t.mu.Unlock()
+	if tr != nil {
+		tr.file.Close()
+		os.Remove(tr.tmpPath)
+	}
+	slog.Warn("Transfer aborted", "id", id, "reason", reason)
+	t.sendError(id, status, reason)
+}
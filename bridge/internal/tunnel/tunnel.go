@@ -0,0 +1,2113 @@
+// Package tunnel maintains the WebSocket connection to the cloud relay and
+// forwards tunneled requests to the local Ollama server.
+package tunnel
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/access"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/anomaly"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/attest"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/bufpool"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/config"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/filter"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/flags"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/history"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/imagegen"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/mirror"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/ollama"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/quota"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/rag"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/share"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/stats"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/transcript"
+	"github.com/thrightguy/CloudToLocalLLM/bridge/internal/wol"
+)
+
+// State describes the tunnel's connection state.
+type State string
+
+const (
+	StateDisconnected State = "disconnected"
+	StateConnecting   State = "connecting"
+	StateConnected    State = "connected"
+	// StateIdle means the tunnel disconnected itself after the configured
+	// idle timeout and is waiting to be woken.
+	StateIdle State = "idle"
+)
+
+// ErrIdleExit is returned by Run when the idle timeout fires and
+// bridge.idle_action is "exit".
+var ErrIdleExit = fmt.Errorf("idle timeout reached with idle_action exit")
+
+// Tunnel connects to the relay, handles tunnel messages and forwards
+// requests to Ollama. It reconnects with exponential backoff.
+type Tunnel struct {
+	cfg      *config.Config
+	ollama   *ollama.Client
+	filter   *filter.Engine
+	quotas   *quota.Manager
+	access   *access.ACL
+	anomaly  *anomaly.Detector
+	attester *attest.Signer
+	shares   *share.Store
+	history  *history.Store
+	token    func() (string, error)
+
+	mu       sync.Mutex
+	conn     *websocket.Conn
+	state    State
+	bridgeID string
+
+	// counters for /status
+	requestsHandled uint64
+	requestsDenied  uint64
+	// recentErrors keeps short summaries of the latest failures for
+	// diagnostics and opt-in telemetry.
+	recentErrors []string
+	// lastActivity is when the last tunneled request arrived.
+	lastActivity time.Time
+	// wakeCh wakes an idle tunnel; see Wake.
+	wakeCh chan struct{}
+	// streamCredits routes stream_credit grants to active streams by
+	// request ID.
+	streamCredits map[string]chan int
+	// dedupe answers relay retransmits from cache instead of re-running
+	// the generation.
+	dedupe *dedupe
+	// mirror duplicates sampled requests to a secondary backend; nil when
+	// mirroring is disabled.
+	mirror *mirror.Mirror
+	// recorder captures incoming messages for offline replay; nil when
+	// disabled.
+	recorder *Recorder
+	// sendHook diverts outgoing messages during replay instead of writing
+	// to the (absent) WebSocket.
+	sendHook func(*Message)
+	// chaos is the test-only fault injection configuration; zero value
+	// means no faults.
+	chaos ChaosConfig
+	// relayHello is the relay's protocol announcement; zero version means
+	// a legacy relay that never sent one.
+	relayHello HelloData
+	// handlers maps message types to their handlers; see RegisterHandler.
+	handlers map[string]Handler
+	// clockSkew is the measured relay/local clock offset, surfaced in
+	// status so the tray can warn about a badly wrong clock.
+	clockSkew time.Duration
+	// version and commit identify this build in status and registration.
+	version string
+	commit  string
+	// flags gates experimental code paths; nil when flag sync is not
+	// wired up (replay, tests).
+	flags *flags.Client
+	// transfers tracks in-flight incoming file transfers by message ID.
+	transfers map[string]*transfer
+	// jobs tracks submitted async jobs by message ID; see jobs.go.
+	jobs map[string]*job
+	// batchPending routes responses for batch sub-request IDs to their
+	// collectors; see batch.go.
+	batchPending map[string]chan *ResponseData
+	// prefetching marks models with a prefetch in progress; see
+	// prefetch.go.
+	prefetching map[string]bool
+	// rag serves /api/retrieve locally; nil when the retrieval helper is
+	// disabled.
+	rag *rag.Index
+	// transcripts captures prompt/response pairs to the encrypted local
+	// store; nil when transcript capture is disabled.
+	transcripts *transcript.Store
+	// backends routes configured path prefixes to alternative local
+	// servers (whisper, TTS); everything else goes to Ollama.
+	backends     []backendRoute
+	backendRules []backendRule
+	// routeLogs holds compiled logging.routes entries, longest prefix
+	// first; see logRequest.
+	routeLogs []routeLog
+	// pauseReason, when non-empty, rejects new requests with 503; set by
+	// the power monitor and manual pause controls.
+	pauseReason string
+	// lockedDown keeps Run disconnected and forwarding paused until
+	// Unlock; see Lockdown.
+	lockedDown bool
+	// failFast makes Run return the first connection error instead of
+	// retrying; see SetFailFast.
+	failFast bool
+	// maxConcurrent caps concurrently forwarded requests; zero means
+	// unlimited. inflight counts requests currently being forwarded.
+	maxConcurrent int
+	inflight      int
+	// slotWaiters are requests queued for a concurrency slot: interactive
+	// waiters first, background ones after, oldest first within each
+	// class. releaseSlot hands its slot to the head instead of freeing
+	// it.
+	slotWaiters []slotWaiter
+	// avgService is an EWMA of recent forward durations, used to estimate
+	// whether a queued request can still make its deadline.
+	avgService time.Duration
+	// inflightReqs tracks requests currently being forwarded, keyed by
+	// message ID, so the control API can list and cancel them.
+	inflightReqs map[string]*inflightRequest
+	// onStateChange is called after each state transition; see
+	// SetStateHook.
+	onStateChange func(State)
+	// mgmtToken supplies access tokens for the management API when a
+	// separate audience is configured; nil falls back to the relay token.
+	mgmtToken func() (string, error)
+	// writer owns all writes to the current connection; see wsWriter.
+	writer *wsWriter
+	// stats accumulates lifetime counters across restarts; nil disables
+	// persistence. connectedOnce marks that one connection succeeded, so
+	// later ones count as reconnects.
+	stats         *stats.Store
+	connectedOnce bool
+	// wakingUntil is the deadline for a Wake-on-LAN'd Ollama host to come
+	// up; failed requests before it get a "warming up" answer.
+	wakingUntil time.Time
+	// registered reports whether the last registration round succeeded;
+	// regFailures counts consecutive failed rounds for the degraded
+	// state.
+	registered  bool
+	regFailures int
+	// lastWatchdogRestart rate-limits ollama.restart_command; see
+	// runWatchdog.
+	lastWatchdogRestart time.Time
+	// lastRelayPing is when the relay's last heartbeat arrived; a stale
+	// value while connected means traffic is likely not being routed here.
+	lastRelayPing time.Time
+}
+
+// registrationLease is how long a registration is considered fresh; the
+// bridge re-registers at half this interval while connected.
+const registrationLease = 5 * time.Minute
+
+// staleHeartbeat is how long without a relay ping (sent every 30s) before
+// a connected bridge is considered stale.
+const staleHeartbeat = 90 * time.Second
+
+// maxRecentErrors bounds the in-memory error summary list.
+const maxRecentErrors = 20
+
+// New returns a Tunnel using token to obtain the current access token
+// before each connection attempt.
+func New(cfg *config.Config, oc *ollama.Client, fe *filter.Engine, qm *quota.Manager, token func() (string, error)) *Tunnel {
+	t := &Tunnel{
+		cfg:           cfg,
+		ollama:        oc,
+		filter:        fe,
+		quotas:        qm,
+		access:        access.New(cfg.Access),
+		token:         token,
+		state:         StateDisconnected,
+		wakeCh:        make(chan struct{}, 1),
+		streamCredits: make(map[string]chan int),
+		dedupe:        newDedupe(),
+		handlers:      make(map[string]Handler),
+		transfers:     make(map[string]*transfer),
+		jobs:          make(map[string]*job),
+		batchPending:  make(map[string]chan *ResponseData),
+		prefetching:   make(map[string]bool),
+		inflightReqs:  make(map[string]*inflightRequest),
+	}
+	t.registerBuiltinHandlers()
+	t.routeLogs = compileRouteLogs(cfg.Logging.Routes)
+	for _, b := range cfg.Backends {
+		timeout := b.Timeout
+		if timeout <= 0 {
+			timeout = cfg.Ollama.Timeout
+		}
+		var client Backend
+		switch b.Kind {
+		case "comfyui":
+			client = imagegen.NewComfy(b.BaseURL, timeout)
+		default:
+			client = ollama.NewClient(b.BaseURL, timeout)
+		}
+		for _, route := range b.Routes {
+			t.backends = append(t.backends, backendRoute{prefix: route, name: b.Name, client: client})
+		}
+		for _, rule := range b.Rules {
+			re, err := rule.Compile()
+			if err != nil {
+				// Validated at config load; a broken rule here is a bug.
+				slog.Error("Skipping invalid backend route rule", "backend", b.Name, "error", err)
+				continue
+			}
+			t.backendRules = append(t.backendRules, backendRule{
+				re: re, rewrite: rule.Rewrite, name: b.Name, client: client})
+		}
+	}
+	return t
+}
+
+// Backend is the forwarding interface a configured backend must provide.
+// *ollama.Client implements it for plain HTTP backends; adapters such as
+// imagegen.Comfy implement it for servers with a job-queue API.
+type Backend interface {
+	Forward(ctx context.Context, method, path string, headers map[string]string, body []byte) (*ollama.Response, error)
+	ForwardStream(ctx context.Context, method, path string, headers map[string]string, body []byte,
+		onStart func(status int, headers map[string]string), onChunk func(chunk []byte, binary bool) error) error
+}
+
+// backendRoute maps one path prefix to an alternative backend client.
+type backendRoute struct {
+	prefix string
+	name   string
+	client Backend
+}
+
+// backendRule maps a wildcard or regex route rule to a backend client,
+// optionally rewriting the forwarded path.
+type backendRule struct {
+	re      *regexp.Regexp
+	rewrite string
+	name    string
+	client  Backend
+}
+
+// backendFor returns the client serving the given path and the path to
+// forward, which a route rule may have rewritten. Rules are checked in
+// configuration order first; then the backend with the longest matching
+// route prefix wins; unmatched paths go to Ollama unchanged.
+func (t *Tunnel) backendFor(path string) (Backend, string) {
+	for i := range t.backendRules {
+		r := &t.backendRules[i]
+		if !r.re.MatchString(path) {
+			continue
+		}
+		if r.rewrite != "" {
+			return r.client, r.re.ReplaceAllString(path, r.rewrite)
+		}
+		return r.client, path
+	}
+	var best *backendRoute
+	for i := range t.backends {
+		b := &t.backends[i]
+		if strings.HasPrefix(path, b.prefix) && (best == nil || len(b.prefix) > len(best.prefix)) {
+			best = b
+		}
+	}
+	if best != nil {
+		return best.client, path
+	}
+	return t.ollamaClient(), path
+}
+
+// wakeOllamaHost sends a Wake-on-LAN packet for a sleeping remote Ollama
+// host after a forwarding failure. It reports true while the host is
+// expected to still be booting, so callers can answer "warming up"
+// instead of a plain error. No-op without ollama.wake_mac configured.
+func (t *Tunnel) wakeOllamaHost() bool {
+	if t.cfg.Ollama.WakeMAC == "" {
+		return false
+	}
+	t.mu.Lock()
+	if time.Now().Before(t.wakingUntil) {
+		t.mu.Unlock()
+		return true
+	}
+	t.wakingUntil = time.Now().Add(t.cfg.Ollama.WakeTimeout)
+	t.mu.Unlock()
+
+	if err := wol.Send(t.cfg.Ollama.WakeMAC); err != nil {
+		slog.Warn("Sending Wake-on-LAN packet failed", "mac", t.cfg.Ollama.WakeMAC, "error", err)
+		return false
+	}
+	slog.Info("Sent Wake-on-LAN packet to Ollama host",
+		"mac", t.cfg.Ollama.WakeMAC, "timeout", t.cfg.Ollama.WakeTimeout)
+	return true
+}
+
+// Wake reconnects an idle tunnel immediately. It is safe to call from any
+// goroutine and is a no-op when the tunnel is not idle.
+func (t *Tunnel) Wake() {
+	select {
+	case t.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+// SetHistory attaches a request history store; nil disables recording.
+func (t *Tunnel) SetHistory(h *history.Store) { t.history = h }
+
+// SetShares attaches the temporary share store; nil rejects all guest
+// requests arriving under a share token.
+func (t *Tunnel) SetShares(s *share.Store) { t.shares = s }
+
+// SetAnomaly attaches the abuse tripwire detector; nil disables it.
+func (t *Tunnel) SetAnomaly(d *anomaly.Detector) { t.anomaly = d }
+
+// SetAttester attaches the response attestation signer; nil disables
+// attestations.
+func (t *Tunnel) SetAttester(s *attest.Signer) { t.attester = s }
+
+// SetTranscripts attaches an encrypted transcript store; nil disables
+// capture.
+func (t *Tunnel) SetTranscripts(s *transcript.Store) { t.transcripts = s }
+
+// SetPause pauses request forwarding with a human-readable reason; an
+// empty reason resumes. While paused, requests are answered with 503 so
+// the cloud client sees why the bridge is not serving.
+func (t *Tunnel) SetPause(reason string) {
+	t.mu.Lock()
+	t.pauseReason = reason
+	t.mu.Unlock()
+}
+
+// PauseReason returns the active pause reason, or empty when serving.
+func (t *Tunnel) PauseReason() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.pauseReason
+}
+
+// SetMaxConcurrent caps concurrently forwarded requests across all users;
+// zero removes the cap. Used by the power monitor to throttle on battery.
+func (t *Tunnel) SetMaxConcurrent(n int) {
+	t.mu.Lock()
+	t.maxConcurrent = n
+	t.mu.Unlock()
+}
+
+// SetFailFast makes Run return its first connection error instead of
+// reconnecting with backoff, so `bridge run --exit-on-error` can exit
+// with a distinct code for the supervisor to act on.
+func (t *Tunnel) SetFailFast(on bool) {
+	t.mu.Lock()
+	t.failFast = on
+	t.mu.Unlock()
+}
+
+// errWouldExceedDeadline sheds a request whose estimated queue wait plus
+// service time cannot fit in the relay-supplied deadline; the client
+// would have given up before the response arrived.
+var errWouldExceedDeadline = fmt.Errorf("would exceed deadline")
+
+// maxSlotWait bounds how long a request without a deadline queues for a
+// concurrency slot before being rejected.
+const maxSlotWait = 30 * time.Second
+
+// errQueueCleared sheds a queued request when the user clears the queue
+// from the tray or control API.
+var errQueueCleared = fmt.Errorf("queue cleared by user")
+
+// slotWaiter is one request queued for a concurrency slot. The metadata
+// fields describe the waiter in /status and the tray; ready and drop are
+// filled in by acquireSlot.
+type slotWaiter struct {
+	ready      chan struct{}
+	drop       chan struct{}
+	background bool
+	id         string
+	path       string
+	model      string
+	priority   string
+	enqueued   time.Time
+}
+
+// backgroundPriority reports whether a relay-set priority names the
+// background traffic class; anything else is treated as interactive.
+func backgroundPriority(p string) bool {
+	return p == "background" || p == "batch"
+}
+
+// acquireSlot reserves one concurrency slot, queueing behind in-flight
+// requests when the global cap is reached. Interactive waiters queue
+// ahead of background ones. With a deadline the request is shed up front
+// when the estimated wait plus expected service time cannot fit; without
+// one it waits at most maxSlotWait.
+func (t *Tunnel) acquireSlot(ctx context.Context, deadline time.Duration, w slotWaiter) error {
+	t.mu.Lock()
+	if t.maxConcurrent <= 0 || t.inflight < t.maxConcurrent {
+		t.inflight++
+		t.mu.Unlock()
+		return nil
+	}
+	wait := maxSlotWait
+	if deadline > 0 {
+		if est := t.estimatedWaitLocked(w.background); est+t.avgService > deadline {
+			t.mu.Unlock()
+			return errWouldExceedDeadline
+		}
+		wait = deadline
+	}
+	ready := make(chan struct{}, 1)
+	w.ready = ready
+	w.drop = make(chan struct{})
+	w.enqueued = time.Now()
+	t.enqueueWaiterLocked(w)
+	t.mu.Unlock()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ready:
+		return nil
+	case <-w.drop:
+		// ClearQueue already removed this waiter and will never grant
+		// the slot.
+		return errQueueCleared
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+	t.mu.Lock()
+	for i, w := range t.slotWaiters {
+		if w.ready == ready {
+			t.slotWaiters = append(t.slotWaiters[:i], t.slotWaiters[i+1:]...)
+			t.mu.Unlock()
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return fmt.Errorf("timed out waiting for a concurrency slot")
+		}
+	}
+	t.mu.Unlock()
+	// releaseSlot granted the slot while we were giving up; pass it on.
+	t.releaseSlot()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("timed out waiting for a concurrency slot")
+}
+
+// enqueueWaiterLocked inserts a waiter at its scheduling position:
+// interactive waiters go in front of the first background waiter,
+// background waiters go to the back.
+func (t *Tunnel) enqueueWaiterLocked(w slotWaiter) {
+	if !w.background {
+		for i, queued := range t.slotWaiters {
+			if queued.background {
+				t.slotWaiters = append(t.slotWaiters[:i],
+					append([]slotWaiter{w}, t.slotWaiters[i:]...)...)
+				return
+			}
+		}
+	}
+	t.slotWaiters = append(t.slotWaiters, w)
+}
+
+// estimatedWaitLocked estimates how long a newly queued request would
+// wait for a slot: the queue it would sit behind spread across the
+// worker slots, at the recent average service time. A background request
+// queues behind everything; an interactive one only behind other
+// interactive waiters.
+func (t *Tunnel) estimatedWaitLocked(background bool) time.Duration {
+	if t.maxConcurrent <= 0 {
+		return 0
+	}
+	ahead := len(t.slotWaiters)
+	if !background {
+		ahead = 0
+		for _, w := range t.slotWaiters {
+			if !w.background {
+				ahead++
+			}
+		}
+	}
+	turns := ahead/t.maxConcurrent + 1
+	return t.avgService * time.Duration(turns)
+}
+
+// QueuedRequest describes one request waiting for a concurrency slot,
+// for /status and the tray.
+type QueuedRequest struct {
+	ID       string `json:"id"`
+	Path     string `json:"path,omitempty"`
+	Model    string `json:"model,omitempty"`
+	Priority string `json:"priority,omitempty"`
+	AgeMs    int64  `json:"ageMs"`
+}
+
+// queuedLocked snapshots the slot queue in scheduling order. Caller
+// holds t.mu.
+func (t *Tunnel) queuedLocked() []QueuedRequest {
+	if len(t.slotWaiters) == 0 {
+		return nil
+	}
+	out := make([]QueuedRequest, 0, len(t.slotWaiters))
+	for _, w := range t.slotWaiters {
+		out = append(out, QueuedRequest{
+			ID:       w.id,
+			Path:     w.path,
+			Model:    w.model,
+			Priority: w.priority,
+			AgeMs:    time.Since(w.enqueued).Milliseconds(),
+		})
+	}
+	return out
+}
+
+// QueuedRequests lists the requests currently waiting for a concurrency
+// slot, in the order they would be served.
+func (t *Tunnel) QueuedRequests() []QueuedRequest {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.queuedLocked()
+}
+
+// ClearQueue drops every queued waiter; each answers its relay with a
+// 503. In-flight requests are untouched (CancelRequests covers those).
+// It returns how many waiters were dropped.
+func (t *Tunnel) ClearQueue() int {
+	t.mu.Lock()
+	waiters := t.slotWaiters
+	t.slotWaiters = nil
+	t.mu.Unlock()
+	for _, w := range waiters {
+		close(w.drop)
+	}
+	return len(waiters)
+}
+
+// releaseSlot frees one concurrency slot, handing it directly to the
+// highest-priority queued waiter when there is one.
+func (t *Tunnel) releaseSlot() {
+	t.mu.Lock()
+	if len(t.slotWaiters) > 0 && t.maxConcurrent > 0 && t.inflight <= t.maxConcurrent {
+		ready := t.slotWaiters[0].ready
+		t.slotWaiters = t.slotWaiters[1:]
+		ready <- struct{}{}
+		t.mu.Unlock()
+		return
+	}
+	t.inflight--
+	t.mu.Unlock()
+}
+
+// inflightRequest is one request currently being forwarded, tracked so
+// the kill-switch can abort a runaway generation.
+type inflightRequest struct {
+	path    string
+	model   string
+	userID  string
+	started time.Time
+	cancel  context.CancelFunc
+}
+
+// InflightRequest describes one in-flight request for the control API.
+type InflightRequest struct {
+	ID         string  `json:"id"`
+	Path       string  `json:"path"`
+	Model      string  `json:"model,omitempty"`
+	UserID     string  `json:"userId,omitempty"`
+	AgeSeconds float64 `json:"ageSeconds"`
+}
+
+// trackRequest registers an in-flight request and returns a context the
+// kill-switch can cancel plus a function that deregisters it.
+func (t *Tunnel) trackRequest(ctx context.Context, id string, req *RequestData, body []byte) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	t.mu.Lock()
+	t.inflightReqs[id] = &inflightRequest{
+		path:    req.Path,
+		model:   history.ModelFromBody(body),
+		userID:  req.UserID,
+		started: time.Now(),
+		cancel:  cancel,
+	}
+	t.mu.Unlock()
+	return ctx, func() {
+		t.mu.Lock()
+		delete(t.inflightReqs, id)
+		t.mu.Unlock()
+		cancel()
+	}
+}
+
+// InflightRequests lists requests currently being forwarded, oldest
+// first.
+func (t *Tunnel) InflightRequests() []InflightRequest {
+	now := time.Now()
+	t.mu.Lock()
+	out := make([]InflightRequest, 0, len(t.inflightReqs))
+	for id, r := range t.inflightReqs {
+		out = append(out, InflightRequest{
+			ID:         id,
+			Path:       r.path,
+			Model:      r.model,
+			UserID:     r.userID,
+			AgeSeconds: now.Sub(r.started).Seconds(),
+		})
+	}
+	t.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].AgeSeconds > out[j].AgeSeconds })
+	return out
+}
+
+// CancelRequest aborts one in-flight request by message ID, reporting
+// whether it was found.
+func (t *Tunnel) CancelRequest(id string) bool {
+	t.mu.Lock()
+	r := t.inflightReqs[id]
+	t.mu.Unlock()
+	if r == nil {
+		return false
+	}
+	slog.Info("Request cancelled via kill-switch", "id", id, "path", r.path, "user", r.userID)
+	r.cancel()
+	return true
+}
+
+// CancelRequests aborts every in-flight request attributed to the given
+// cloud user, or all of them when userID is empty, returning how many
+// were cancelled.
+func (t *Tunnel) CancelRequests(userID string) int {
+	t.mu.Lock()
+	var victims []*inflightRequest
+	for _, r := range t.inflightReqs {
+		if userID == "" || r.userID == userID {
+			victims = append(victims, r)
+		}
+	}
+	t.mu.Unlock()
+	for _, r := range victims {
+		r.cancel()
+	}
+	if len(victims) > 0 {
+		slog.Info("Requests cancelled via kill-switch", "count", len(victims), "user", userID)
+	}
+	return len(victims)
+}
+
+// observeService folds one forward duration into the service-time EWMA
+// used for deadline shedding.
+func (t *Tunnel) observeService(d time.Duration) {
+	t.mu.Lock()
+	if t.avgService == 0 {
+		t.avgService = d
+	} else {
+		t.avgService += (d - t.avgService) / 8
+	}
+	t.mu.Unlock()
+}
+
+// SetMirror attaches a shadow traffic mirror; nil disables mirroring.
+func (t *Tunnel) SetMirror(m *mirror.Mirror) { t.mirror = m }
+
+// SetRAG attaches the document retrieval index serving /api/retrieve.
+func (t *Tunnel) SetRAG(ix *rag.Index) { t.rag = ix }
+
+// SetFeatureFlags attaches the cloud-synced feature flag client; flags
+// are refreshed after each successful registration.
+func (t *Tunnel) SetFeatureFlags(c *flags.Client) { t.flags = c }
+
+// FeatureEnabled reports whether a cloud feature flag is on. Without a
+// flag client everything is off.
+func (t *Tunnel) FeatureEnabled(name string) bool {
+	return t.flags != nil && t.flags.Enabled(name)
+}
+
+// SetBuildInfo records the bridge version and commit for status reporting
+// and relay registration.
+func (t *Tunnel) SetBuildInfo(version, commit string) {
+	t.version = version
+	t.commit = commit
+}
+
+// SetClockSkew records the measured relay/local clock offset for status
+// reporting.
+func (t *Tunnel) SetClockSkew(d time.Duration) {
+	t.mu.Lock()
+	t.clockSkew = d
+	t.mu.Unlock()
+}
+
+// ollamaClient returns the current Ollama client. Requests capture the
+// client once at start, so a concurrent target swap drains in-flight work
+// against the old target.
+func (t *Tunnel) ollamaClient() *ollama.Client {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.ollama
+}
+
+// SetOllamaTarget swaps the Ollama host/port at runtime without touching
+// the cloud tunnel. New requests go to the new target immediately;
+// in-flight requests finish against the old one.
+func (t *Tunnel) SetOllamaTarget(host string, port int) {
+	baseURL := fmt.Sprintf("http://%s:%d", host, port)
+	client := ollama.NewTunedClient(baseURL, t.cfg.Ollama.Timeout, ollama.TransportOptions{
+		MaxIdleConns:    t.cfg.Ollama.MaxIdleConns,
+		IdleConnTimeout: t.cfg.Ollama.IdleConnTimeout,
+		ForceHTTP2:      t.cfg.Ollama.HTTP2,
+	})
+	t.mu.Lock()
+	old := t.ollama.BaseURL()
+	t.ollama = client
+	t.mu.Unlock()
+	slog.Info("Ollama target changed", "old", old, "new", baseURL)
+}
+
+// Status is a snapshot of the tunnel state for the status server.
+type Status struct {
+	State    State  `json:"state"`
+	BridgeID string `json:"bridge_id,omitempty"`
+	// Version and Commit identify the running build.
+	Version         string `json:"version,omitempty"`
+	Commit          string `json:"commit,omitempty"`
+	RequestsHandled uint64 `json:"requests_handled"`
+	RequestsDenied  uint64 `json:"requests_denied"`
+	// OllamaTarget is the base URL requests are currently forwarded to.
+	OllamaTarget string `json:"ollama_target"`
+	// Registration is "registered" while the relay lease is held and
+	// heartbeats are arriving, "stale" when connected but the relay has
+	// stopped pinging (likely not routing traffic here), "unregistered"
+	// when the last registration round failed, or "degraded" once
+	// several consecutive rounds have failed. Empty while disconnected.
+	Registration string `json:"registration,omitempty"`
+	// ClockSkewSeconds is the measured relay/local clock offset; the tray
+	// warns when it exceeds the tolerated skew.
+	ClockSkewSeconds float64 `json:"clock_skew_seconds,omitempty"`
+	// Paused carries the pause reason while forwarding is suspended
+	// (battery, thermal, manual pause).
+	Paused string `json:"paused,omitempty"`
+	// Group and Tags are the fleet identity from bridge.group and
+	// bridge.tags, as reported to the cloud side.
+	Group string   `json:"group,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+	// Queued lists requests waiting for a concurrency slot, oldest
+	// first within each priority class, so users can see why a request
+	// is slow.
+	Queued []QueuedRequest `json:"queued,omitempty"`
+	// Users holds per-user quota counters when quotas are enabled.
+	Users map[string]quota.Usage `json:"users,omitempty"`
+	// FeatureFlags are the cloud-synced flags currently in effect.
+	FeatureFlags map[string]bool `json:"feature_flags,omitempty"`
+	// Lifetime carries the persisted cumulative counters when the stats
+	// store is enabled.
+	Lifetime *stats.Totals `json:"lifetime,omitempty"`
+}
+
+// Status returns a snapshot of the tunnel state.
+func (t *Tunnel) Status() Status {
+	t.mu.Lock()
+	st := Status{
+		State:           t.state,
+		BridgeID:        t.bridgeID,
+		Version:         t.version,
+		Commit:          t.commit,
+		RequestsHandled: t.requestsHandled,
+		RequestsDenied:  t.requestsDenied,
+		OllamaTarget:    t.ollama.BaseURL(),
+		Paused:          t.pauseReason,
+		Group:           t.cfg.Bridge.Group,
+		Tags:            t.cfg.Bridge.Tags,
+		Queued:          t.queuedLocked(),
+	}
+	st.ClockSkewSeconds = t.clockSkew.Seconds()
+	if t.state == StateConnected {
+		switch {
+		case t.cfg.Cloud.Registration == "off":
+			// No lease to report; only heartbeats say anything here.
+			if time.Since(t.lastRelayPing) > staleHeartbeat {
+				st.Registration = "stale"
+			}
+		case t.regFailures >= registrationDegradedAfter:
+			st.Registration = "degraded"
+		case !t.registered:
+			st.Registration = "unregistered"
+		case time.Since(t.lastRelayPing) > staleHeartbeat:
+			st.Registration = "stale"
+		default:
+			st.Registration = "registered"
+		}
+	}
+	t.mu.Unlock()
+	if t.quotas.Enabled() {
+		st.Users = t.quotas.UsageSnapshot()
+	}
+	if t.flags != nil {
+		st.FeatureFlags = t.flags.All()
+	}
+	if t.stats != nil {
+		totals := t.stats.Totals()
+		st.Lifetime = &totals
+	}
+	return st
+}
+
+// RecentErrors returns a copy of the latest error summaries.
+func (t *Tunnel) RecentErrors() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]string, len(t.recentErrors))
+	copy(out, t.recentErrors)
+	return out
+}
+
+func (t *Tunnel) recordError(summary string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.recentErrors = append(t.recentErrors, summary)
+	if len(t.recentErrors) > maxRecentErrors {
+		t.recentErrors = t.recentErrors[len(t.recentErrors)-maxRecentErrors:]
+	}
+}
+
+func (t *Tunnel) setState(s State) {
+	t.mu.Lock()
+	changed := t.state != s
+	t.state = s
+	hook := t.onStateChange
+	t.mu.Unlock()
+	if changed && hook != nil {
+		hook(s)
+	}
+}
+
+// SetStateHook registers a callback invoked on every connection state
+// transition, used for connect/disconnect notifications.
+func (t *Tunnel) SetStateHook(hook func(State)) {
+	t.mu.Lock()
+	t.onStateChange = hook
+	t.mu.Unlock()
+}
+
+// SetStats attaches the persistent lifetime counter store.
+func (t *Tunnel) SetStats(s *stats.Store) { t.stats = s }
+
+// countRequest bumps the session counter and the lifetime store.
+func (t *Tunnel) countRequest(bytes int) {
+	t.mu.Lock()
+	t.requestsHandled++
+	t.mu.Unlock()
+	if t.stats != nil {
+		t.stats.AddRequest(uint64(bytes))
+	}
+}
+
+// SetManagementToken supplies a token source scoped to the management
+// API audience, used for registration and flag fetches instead of the
+// relay WebSocket token. Nil keeps the relay token for everything.
+func (t *Tunnel) SetManagementToken(fn func() (string, error)) { t.mgmtToken = fn }
+
+// managementToken returns a management-audience token, falling back to
+// the relay token when no separate source is configured or it fails.
+func (t *Tunnel) managementToken(fallback string) string {
+	if t.mgmtToken == nil {
+		return fallback
+	}
+	tok, err := t.mgmtToken()
+	if err != nil {
+		slog.Warn("Obtaining management token failed; using relay token", "error", err)
+		return fallback
+	}
+	return tok
+}
+
+// errIdleDisconnect signals that the idle monitor closed the connection.
+var errIdleDisconnect = fmt.Errorf("idle timeout reached")
+
+// Run connects to the relay and processes messages until ctx is cancelled,
+// reconnecting with exponential backoff on failure. When the configured
+// idle timeout fires the tunnel either waits for a wake event or returns
+// ErrIdleExit, depending on bridge.idle_action.
+func (t *Tunnel) Run(ctx context.Context) error {
+	go t.runWatchdog(ctx)
+	backoff := time.Second
+	for {
+		if err := t.waitWhileLocked(ctx); err != nil {
+			return err
+		}
+		err := t.connectAndServe(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == errIdleDisconnect {
+			if t.cfg.Bridge.IdleAction == "exit" {
+				return ErrIdleExit
+			}
+			if err := t.waitForWake(ctx); err != nil {
+				return err
+			}
+			backoff = time.Second
+			continue
+		}
+		if err != nil {
+			t.mu.Lock()
+			failFast := t.failFast
+			t.mu.Unlock()
+			if failFast {
+				t.setState(StateDisconnected)
+				return err
+			}
+			slog.Warn("Tunnel disconnected", "error", err, "retry_in", backoff)
+		}
+		t.setState(StateDisconnected)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > time.Minute {
+			backoff = time.Minute
+		}
+	}
+}
+
+// Lockdown cancels every in-flight request, pauses forwarding and drops
+// the relay connection. Run stays disconnected until Unlock. It returns
+// how many requests were cancelled.
+func (t *Tunnel) Lockdown() int {
+	t.mu.Lock()
+	t.lockedDown = true
+	t.pauseReason = "locked down"
+	conn := t.conn
+	t.mu.Unlock()
+	n := t.CancelRequests("")
+	if conn != nil {
+		conn.Close()
+	}
+	slog.Warn("Emergency lockdown engaged", "cancelled", n)
+	return n
+}
+
+// Unlock lifts a lockdown, resumes forwarding and lets Run reconnect.
+func (t *Tunnel) Unlock() {
+	t.mu.Lock()
+	t.lockedDown = false
+	if t.pauseReason == "locked down" {
+		t.pauseReason = ""
+	}
+	t.mu.Unlock()
+	t.Wake()
+	slog.Info("Lockdown lifted; reconnecting")
+}
+
+// LockedDown reports whether an emergency lockdown is in effect.
+func (t *Tunnel) LockedDown() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.lockedDown
+}
+
+// waitWhileLocked blocks while a lockdown is in effect, reporting the
+// tunnel as disconnected. Unlock wakes it through the wake channel.
+func (t *Tunnel) waitWhileLocked(ctx context.Context) error {
+	if !t.LockedDown() {
+		return nil
+	}
+	t.setState(StateDisconnected)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for t.LockedDown() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.wakeCh:
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// RevokeSession asks the relay to drop this bridge's registration and
+// invalidate its tunnel session, so a stolen token cannot keep routing
+// traffic here. Relays without the endpoint return an error the caller
+// should log and move past; the local lockdown holds regardless.
+func (t *Tunnel) RevokeSession(ctx context.Context) error {
+	token, err := t.token()
+	if err != nil {
+		return fmt.Errorf("no access token for session revocation: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		t.cfg.CloudURL("/ollama/bridge/revoke"), nil)
+	if err != nil {
+		return fmt.Errorf("building revocation request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+t.managementToken(token))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("relay rejected session revocation with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// waitForWake blocks while idle until Wake is called or the relay's wake
+// endpoint reports pending work.
+func (t *Tunnel) waitForWake(ctx context.Context) error {
+	t.setState(StateIdle)
+	slog.Info("Tunnel idle; waiting for wake",
+		"idle_timeout", t.cfg.Bridge.IdleTimeout)
+	poll := time.NewTicker(time.Minute)
+	defer poll.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.wakeCh:
+			slog.Info("Waking tunnel")
+			return nil
+		case <-poll.C:
+			if t.relayWantsWake(ctx) {
+				slog.Info("Relay requested wake")
+				return nil
+			}
+		}
+	}
+}
+
+// relayWantsWake polls the relay's lightweight wake endpoint. Relays that
+// do not implement it simply leave the bridge idle until a local wake.
+func (t *Tunnel) relayWantsWake(ctx context.Context) bool {
+	token, err := t.token()
+	if err != nil {
+		return false
+	}
+	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet,
+		t.cfg.CloudURL("/ollama/bridge/wake"), nil)
+	if err != nil {
+		return false
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false
+	}
+	var body struct {
+		Wake bool `json:"wake"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return false
+	}
+	return body.Wake
+}
+
+func (t *Tunnel) connectAndServe(ctx context.Context) error {
+	t.setState(StateConnecting)
+	token, err := t.token()
+	if err != nil {
+		return fmt.Errorf("obtaining access token: %w", err)
+	}
+	url := t.cfg.WebSocketURL() + "?token=" + token
+	tlsConf, err := t.cfg.TLS.ClientConfig()
+	if err != nil {
+		return fmt.Errorf("building TLS config: %w", err)
+	}
+	if t.cfg.Cloud.Insecure {
+		// Development-only; the startup warning already shouted about it.
+		if tlsConf == nil {
+			tlsConf = &tls.Config{}
+		}
+		tlsConf.InsecureSkipVerify = true
+	}
+	dialer := websocket.Dialer{
+		Proxy:             http.ProxyFromEnvironment,
+		TLSClientConfig:   tlsConf,
+		HandshakeTimeout:  45 * time.Second,
+		ReadBufferSize:    t.cfg.Cloud.WSReadBufferSize,
+		WriteBufferSize:   t.cfg.Cloud.WSWriteBufferSize,
+		EnableCompression: t.cfg.Cloud.WSCompression,
+	}
+	conn, _, err := dialer.DialContext(ctx, url, nil)
+	if err != nil {
+		return fmt.Errorf("dialing relay: %w", err)
+	}
+	defer conn.Close()
+	if t.cfg.Cloud.WSMaxMessageSize > 0 {
+		conn.SetReadLimit(t.cfg.Cloud.WSMaxMessageSize)
+	}
+
+	writer := newWSWriter(conn, t.cfg.Cloud.WriteQueueSize, t.cfg.Cloud.WriteOverflow == "drop")
+	defer writer.close()
+	t.mu.Lock()
+	t.conn = conn
+	t.writer = writer
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		t.writer = nil
+		t.mu.Unlock()
+	}()
+	t.setState(StateConnected)
+	slog.Info("Connected to relay", "url", t.cfg.WebSocketURL())
+	if t.stats != nil {
+		if t.connectedOnce {
+			t.stats.AddReconnect()
+		}
+		connectedAt := time.Now()
+		defer func() { t.stats.AddUptime(time.Since(connectedAt)) }()
+	}
+	t.connectedOnce = true
+
+	if err := t.registerBridge(ctx, token); err != nil && t.cfg.Cloud.Registration == "required" {
+		return fmt.Errorf("bridge registration failed: %w", err)
+	}
+	t.sendHello()
+	t.mu.Lock()
+	t.lastRelayPing = time.Now()
+	t.relayHello = HelloData{}
+	t.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	t.mu.Lock()
+	t.lastActivity = time.Now()
+	t.mu.Unlock()
+	serveDone := make(chan struct{})
+	defer close(serveDone)
+	go t.renewRegistration(ctx, token, serveDone)
+	idleTripped := make(chan struct{})
+	if t.cfg.Bridge.IdleTimeout > 0 {
+		go t.idleMonitor(conn, serveDone, idleTripped)
+	}
+
+	for {
+		var msg Message
+		if err := conn.ReadJSON(&msg); err != nil {
+			select {
+			case <-idleTripped:
+				return errIdleDisconnect
+			default:
+			}
+			return fmt.Errorf("reading tunnel message: %w", err)
+		}
+		t.handleMessage(ctx, &msg)
+	}
+}
+
+// idleMonitor closes the connection once no tunneled request has arrived
+// for the configured idle timeout.
+func (t *Tunnel) idleMonitor(conn *websocket.Conn, done <-chan struct{}, tripped chan<- struct{}) {
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			t.mu.Lock()
+			idle := time.Since(t.lastActivity)
+			t.mu.Unlock()
+			if idle >= t.cfg.Bridge.IdleTimeout {
+				slog.Info("Idle timeout reached; disconnecting tunnel", "idle", idle.Round(time.Second))
+				close(tripped)
+				conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// registrationRetries is how many attempts one registration round makes
+// before giving up until the next lease renewal.
+const registrationRetries = 3
+
+// registrationDegradedAfter is how many consecutive failed rounds mark
+// the bridge degraded in status and tray.
+const registrationDegradedAfter = 2
+
+// registerBridge runs one registration round against the relay with
+// retries and backoff. A failed round leaves the bridge serving but
+// marked unregistered; the caller decides whether that is fatal (see
+// cloud.registration). Skipped entirely in "off" mode for relays without
+// the endpoint.
+func (t *Tunnel) registerBridge(ctx context.Context, token string) error {
+	if t.cfg.Cloud.Registration == "off" {
+		return nil
+	}
+	var err error
+	delay := 2 * time.Second
+	for attempt := 1; attempt <= registrationRetries; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+		if err = t.registerOnce(ctx, token); err == nil {
+			t.setRegistered(true)
+			return nil
+		}
+		slog.Warn("Bridge registration failed", "attempt", attempt, "error", err)
+	}
+	t.setRegistered(false)
+	return err
+}
+
+// registerOnce announces this bridge to the relay's registration
+// endpoint. The registration carries the lease TTL so the relay can
+// expire bridges that stop renewing.
+func (t *Tunnel) registerOnce(ctx context.Context, token string) error {
+	token = t.managementToken(token)
+	caps, _ := json.Marshal(t.localCapabilities())
+	body := fmt.Sprintf(`{"ollama_version":%q,"lease_seconds":%d,"protocol_version":%d,"capabilities":%s,"bridge_version":%q,"commit":%q%s}`,
+		t.ollamaVersion(ctx), int(registrationLease.Seconds()), ProtocolVersion, caps, t.version, t.commit, t.fleetFields())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		t.cfg.CloudURL("/ollama/bridge/register"),
+		strings.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("building registration request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("registration rejected with status %d", resp.StatusCode)
+	}
+	if t.flags != nil {
+		if err := t.flags.Fetch(ctx, t.cfg.CloudBase(), token); err != nil {
+			slog.Debug("Refreshing feature flags failed", "error", err)
+		}
+	}
+	return nil
+}
+
+// fleetFields renders the optional group/tags registration fields,
+// with a leading comma so they splice into the JSON body, or an empty
+// string when neither is configured.
+func (t *Tunnel) fleetFields() string {
+	var b strings.Builder
+	if g := t.cfg.Bridge.Group; g != "" {
+		fmt.Fprintf(&b, `,"group":%q`, g)
+	}
+	if len(t.cfg.Bridge.Tags) > 0 {
+		tags, _ := json.Marshal(t.cfg.Bridge.Tags)
+		b.WriteString(`,"tags":`)
+		b.Write(tags)
+	}
+	return b.String()
+}
+
+func (t *Tunnel) setRegistered(ok bool) {
+	t.mu.Lock()
+	if ok {
+		t.regFailures = 0
+	} else {
+		t.regFailures++
+	}
+	t.registered = ok
+	t.mu.Unlock()
+}
+
+// renewRegistration re-registers on a lease schedule while the connection
+// is up, so a relay restart that loses registrations heals without a
+// reconnect.
+func (t *Tunnel) renewRegistration(ctx context.Context, token string, done <-chan struct{}) {
+	ticker := time.NewTicker(registrationLease / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Renewal failures are never fatal mid-connection; the next
+			// round or a reconnect will heal the lease.
+			if err := t.registerBridge(ctx, token); err != nil {
+				slog.Warn("Registration lease renewal failed", "error", err)
+			}
+		}
+	}
+}
+
+func (t *Tunnel) ollamaVersion(ctx context.Context) string {
+	vctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	v, err := t.ollamaClient().Version(vctx)
+	if err != nil {
+		return "unknown"
+	}
+	return v
+}
+
+// localCapabilities lists the protocol features this bridge supports with
+// its current configuration.
+func (t *Tunnel) localCapabilities() []string {
+	caps := []string{CapStreaming, CapBinary, CapTransfer, CapJobs, CapBatch}
+	if t.cfg.Cloud.WSCompression {
+		caps = append(caps, CapCompression)
+	}
+	if t.cfg.Ollama.AllowPrefetch {
+		caps = append(caps, CapPrefetch)
+	}
+	if t.cfg.Ollama.AllowRemoteModelManagement {
+		caps = append(caps, CapModelOps)
+	}
+	return caps
+}
+
+// sendHello announces our protocol version and capabilities. Legacy
+// relays log and ignore the unknown type.
+func (t *Tunnel) sendHello() {
+	raw, _ := json.Marshal(HelloData{
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    t.localCapabilities(),
+	})
+	t.sendMessage(&Message{Type: TypeHello, Data: raw})
+}
+
+// Handler processes one incoming tunnel message of a registered type.
+type Handler func(ctx context.Context, msg *Message)
+
+// RegisterHandler maps a message type to a handler, replacing any
+// existing one. Extensions register their types before Run; registry
+// access is not synchronized with the read loop.
+func (t *Tunnel) RegisterHandler(msgType string, h Handler) {
+	t.handlers[msgType] = h
+}
+
+func (t *Tunnel) registerBuiltinHandlers() {
+	t.RegisterHandler(TypeHello, t.handleHello)
+	t.RegisterHandler(TypeAuth, t.handleAuth)
+	t.RegisterHandler(TypePing, t.handlePing)
+	t.RegisterHandler(TypeRequest, t.handleRequest)
+	t.RegisterHandler(TypeStreamCredit, t.handleStreamCredit)
+	t.RegisterHandler(TypeTransferStart, t.handleTransferStart)
+	t.RegisterHandler(TypeTransferChunk, t.handleTransferChunk)
+	t.RegisterHandler(TypeTransferEnd, t.handleTransferEnd)
+	t.RegisterHandler(TypeJobSubmit, t.handleJobSubmit)
+	t.RegisterHandler(TypeJobPoll, t.handleJobPoll)
+	t.RegisterHandler(TypeBatch, t.handleBatch)
+	t.RegisterHandler(TypePrefetch, t.handlePrefetch)
+	t.RegisterHandler(TypeModelOp, t.handleModelOp)
+}
+
+func (t *Tunnel) handleMessage(ctx context.Context, msg *Message) {
+	if t.recorder != nil {
+		t.recorder.Record(msg)
+	}
+	if t.chaosOnReceive() {
+		return
+	}
+	if h, ok := t.handlers[msg.Type]; ok {
+		h(ctx, msg)
+		return
+	}
+	t.handleUnknown(msg)
+}
+
+func (t *Tunnel) handleHello(ctx context.Context, msg *Message) {
+	var data HelloData
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		slog.Warn("Malformed hello message", "error", err)
+		return
+	}
+	t.mu.Lock()
+	t.relayHello = data
+	t.mu.Unlock()
+	if data.ProtocolVersion > ProtocolVersion {
+		slog.Warn("Relay speaks a newer tunnel protocol; some features may be unavailable",
+			"relay_version", data.ProtocolVersion, "bridge_version", ProtocolVersion)
+	}
+	if data.ProtocolVersion > 0 && data.ProtocolVersion < ProtocolVersion {
+		slog.Warn("Relay speaks an older tunnel protocol than this bridge; streaming and binary responses are gated on its advertised capabilities",
+			"relay_version", data.ProtocolVersion, "bridge_version", ProtocolVersion)
+	}
+	if t.cfg.Cloud.WSCompression && data.ProtocolVersion > 0 && !data.Capable(CapCompression) {
+		slog.Warn("Relay did not advertise compression; frames travel uncompressed despite ws_compression")
+	}
+	slog.Info("Relay protocol negotiated",
+		"version", data.ProtocolVersion, "capabilities", data.Capabilities)
+}
+
+// relayCapable reports whether the relay advertised a capability in its
+// hello. Legacy relays that never sent one predate capability flags and
+// are assumed to support everything they route.
+func (t *Tunnel) relayCapable(name string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.relayHello.ProtocolVersion == 0 {
+		return true
+	}
+	return t.relayHello.Capable(name)
+}
+
+func (t *Tunnel) handleAuth(ctx context.Context, msg *Message) {
+	var data AuthData
+	if err := json.Unmarshal(msg.Data, &data); err == nil && data.Success {
+		t.mu.Lock()
+		t.bridgeID = data.BridgeID
+		t.mu.Unlock()
+		slog.Info("Bridge authenticated", "bridge_id", data.BridgeID)
+	}
+}
+
+func (t *Tunnel) handlePing(ctx context.Context, msg *Message) {
+	t.mu.Lock()
+	t.lastRelayPing = time.Now()
+	t.mu.Unlock()
+	pong := &Message{Type: TypePong, ID: msg.ID}
+	if t.cfg.Bridge.Group != "" || len(t.cfg.Bridge.Tags) > 0 {
+		// Heartbeats carry the fleet identity too, so relays can keep
+		// tag indexes current without waiting for a lease renewal.
+		pong.Data, _ = json.Marshal(PongData{Group: t.cfg.Bridge.Group, Tags: t.cfg.Bridge.Tags})
+	}
+	t.sendMessage(pong)
+}
+
+func (t *Tunnel) handleRequest(ctx context.Context, msg *Message) {
+	t.mu.Lock()
+	t.lastActivity = time.Now()
+	t.mu.Unlock()
+	var data RequestData
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		slog.Error("Malformed request message", "id", msg.ID, "error", err)
+		t.sendError(msg.ID, http.StatusBadRequest, "malformed request")
+		return
+	}
+	if dup, cached := t.dedupe.begin(msg.ID); dup {
+		if cached != nil {
+			slog.Info("Replaying cached response for retransmitted request", "id", msg.ID)
+			t.sendResponse(msg.ID, cached)
+		} else {
+			slog.Info("Ignoring retransmitted request still in flight", "id", msg.ID)
+		}
+		return
+	}
+	go t.handleOllamaRequest(ctx, msg.ID, &data)
+}
+
+func (t *Tunnel) handleStreamCredit(ctx context.Context, msg *Message) {
+	var data StreamCreditData
+	if err := json.Unmarshal(msg.Data, &data); err != nil || data.Credits <= 0 {
+		slog.Warn("Malformed stream_credit message", "id", msg.ID)
+		return
+	}
+	t.mu.Lock()
+	ch := t.streamCredits[msg.ID]
+	t.mu.Unlock()
+	if ch != nil {
+		select {
+		case ch <- data.Credits:
+		default:
+			slog.Debug("Dropping credit grant for saturated stream", "id", msg.ID)
+		}
+	}
+}
+
+// handleUnknown deals with message types nothing is registered for.
+// Optional extension messages are acked with "unsupported" so the relay
+// can fall back; anything else is logged, with a clearer message when the
+// relay negotiated a newer protocol.
+func (t *Tunnel) handleUnknown(msg *Message) {
+	if msg.Optional {
+		raw, _ := json.Marshal(map[string]string{"type": msg.Type})
+		t.sendMessage(&Message{Type: TypeUnsupported, ID: msg.ID, Data: raw})
+		slog.Debug("Acked unsupported optional message", "type", msg.Type, "id", msg.ID)
+		return
+	}
+	t.mu.Lock()
+	relayVersion := t.relayHello.ProtocolVersion
+	t.mu.Unlock()
+	if relayVersion > ProtocolVersion {
+		slog.Warn("Ignoring message from newer protocol version",
+			"type", msg.Type, "id", msg.ID,
+			"relay_version", relayVersion, "bridge_version", ProtocolVersion)
+		return
+	}
+	slog.Warn("Unknown message type", "type", msg.Type, "id", msg.ID)
+}
+
+// handleOllamaRequest applies the content filter and forwards one tunneled
+// request to Ollama, sending the response back through the tunnel.
+func (t *Tunnel) handleOllamaRequest(ctx context.Context, id string, req *RequestData) {
+	t.anomaly.Request(req.Path)
+	if reason := t.PauseReason(); reason != "" {
+		slog.Info("Request rejected while paused", "id", id, "reason", reason)
+		t.dedupe.fail(id)
+		t.sendError(id, http.StatusServiceUnavailable, "bridge paused: "+reason)
+		return
+	}
+	handleStart := time.Now()
+	deadline := time.Duration(req.DeadlineMs) * time.Millisecond
+	waiter := slotWaiter{
+		background: backgroundPriority(req.Priority),
+		id:         id,
+		path:       req.Path,
+		priority:   req.Priority,
+	}
+	if req.BodyEncoding == "" {
+		waiter.model = history.ModelFromBody([]byte(req.Body))
+	}
+	if err := t.acquireSlot(ctx, deadline, waiter); err != nil {
+		slog.Info("Request shed", "id", id, "error", err)
+		t.dedupe.fail(id)
+		status := http.StatusServiceUnavailable
+		if err == errWouldExceedDeadline {
+			status = http.StatusGatewayTimeout
+		}
+		t.sendError(id, status, err.Error())
+		return
+	}
+	defer t.releaseSlot()
+	// Everything up to here is queue wait, dominated by the slot.
+	timing := &TimingData{QueueMs: time.Since(handleStart).Milliseconds()}
+
+	started := time.Now()
+	defer func() { t.observeService(time.Since(started)) }()
+	if deadline > 0 {
+		// The remaining budget also bounds the forward itself, so a slow
+		// generation stops occupying the GPU once the client has given up.
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, deadline)
+		defer cancel()
+	}
+
+	if t.quotas.Enabled() && req.UserID != "" {
+		if err := t.quotas.Acquire(req.UserID); err != nil {
+			slog.Info("Request rejected by quota", "id", id, "user", req.UserID, "error", err)
+			t.anomaly.Denied(err.Error())
+			t.dedupe.fail(id)
+			t.sendError(id, http.StatusTooManyRequests, err.Error())
+			return
+		}
+		defer t.quotas.Release(req.UserID)
+	}
+
+	body := []byte(req.Body)
+	if req.BodyEncoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(req.Body)
+		if err != nil {
+			t.dedupe.fail(id)
+			t.sendError(id, http.StatusBadRequest, "body is not valid base64")
+			return
+		}
+		body = decoded
+	}
+	t.logRequest(id, req, body)
+	if err := t.checkBodyLimits(body, req.BodyEncoding == ""); err != nil {
+		slog.Info("Request rejected by body limits", "id", id, "path", req.Path, "error", err)
+		t.anomaly.Denied(err.Error())
+		t.dedupe.fail(id)
+		t.sendError(id, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+	if req.ShareToken != "" {
+		// Guest traffic under a temporary share link: the token must
+		// match a live share, and the access list does not apply.
+		if !t.shares.Valid(req.ShareToken) {
+			t.mu.Lock()
+			t.requestsDenied++
+			t.mu.Unlock()
+			slog.Info("Request denied: share link expired or revoked", "id", id)
+			t.anomaly.Denied("share link expired or revoked")
+			t.dedupe.fail(id)
+			t.sendError(id, http.StatusForbidden, "share link expired or revoked")
+			return
+		}
+	} else if t.access.Enabled() {
+		var model string
+		if req.BodyEncoding == "" {
+			model = history.ModelFromBody(body)
+		}
+		if err := t.access.Check(req.UserID, req.Path, model); err != nil {
+			t.mu.Lock()
+			t.requestsDenied++
+			t.mu.Unlock()
+			slog.Info("Request denied by access list", "id", id, "user", req.UserID, "error", err)
+			t.anomaly.Denied(err.Error())
+			t.dedupe.fail(id)
+			t.sendError(id, http.StatusForbidden, err.Error())
+			return
+		}
+	}
+	// The content filter works on text prompts; binary payloads (audio
+	// uploads) pass through unfiltered.
+	if t.filter.Enabled() && req.BodyEncoding == "" {
+		res := t.filter.Apply(body)
+		if res.Action == filter.Deny {
+			t.mu.Lock()
+			t.requestsDenied++
+			t.mu.Unlock()
+			slog.Info("Request denied by content filter", "id", id, "rule", res.Rule)
+			t.anomaly.Denied("content filter rule " + res.Rule)
+			t.dedupe.fail(id)
+			t.sendError(id, http.StatusForbidden,
+				fmt.Sprintf("blocked by content filter rule %q", res.Rule))
+			return
+		}
+		if res.Redacted {
+			slog.Debug("Request body redacted by content filter", "id", id)
+		}
+		body = res.Body
+	}
+
+	ctx, untrack := t.trackRequest(ctx, id, req, body)
+	defer untrack()
+
+	if req.Path == "/api/retrieve" {
+		t.handleRetrieve(ctx, id, body)
+		return
+	}
+
+	headers := t.forwardHeaders(req)
+
+	if req.Stream && !t.relayCapable(CapStreaming) {
+		// Defensive: a relay that requests streaming without advertising
+		// it gets a buffered response it can definitely handle.
+		slog.Warn("Relay requested streaming without advertising the capability; buffering response", "id", id)
+		req.Stream = false
+	}
+	if req.Stream {
+		t.streamOllamaRequest(ctx, id, req, headers, body, timing)
+		return
+	}
+
+	start := time.Now()
+	backend, fwdPath := t.backendFor(req.Path)
+	resp, err := backend.Forward(ctx, req.Method, fwdPath, headers, body)
+	if err != nil {
+		slog.Error("Forwarding request failed", "id", id, "path", req.Path, "error", err)
+		t.recordError(fmt.Sprintf("forward %s: %v", req.Path, err))
+		t.recordHistory(id, req, body, http.StatusBadGateway, start)
+		t.dedupe.fail(id)
+		if t.wakeOllamaHost() {
+			t.sendError(id, http.StatusServiceUnavailable, "Ollama host is warming up; retry shortly")
+			return
+		}
+		t.sendError(id, http.StatusBadGateway, "local Ollama request failed")
+		return
+	}
+	defer resp.Release()
+	timing.GenerateMs = time.Since(start).Milliseconds()
+	t.countRequest(len(resp.Body))
+	t.recordHistory(id, req, body, resp.StatusCode, start)
+	if resp.StatusCode < 400 {
+		t.recordTranscript(id, req, body, transcript.Delta(resp.Body), start)
+	}
+
+	if t.mirror != nil && t.mirror.Sample() {
+		primary := mirror.Primary{
+			StatusCode: resp.StatusCode,
+			BodySHA256: mirror.BodyDigest(resp.Body),
+			BodyBytes:  len(resp.Body),
+			Duration:   time.Since(start),
+		}
+		// The mirror outlives the primary response: the tracked request
+		// context is canceled as soon as the reply is sent, which would
+		// abort the secondary mid-generation. Give it its own deadline.
+		go func() {
+			timeout := t.cfg.Ollama.Timeout
+			if timeout <= 0 {
+				timeout = 2 * time.Minute
+			}
+			mctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			t.mirror.Run(mctx, req.Method, req.Path, headers, body, primary)
+		}()
+	}
+
+	serStart := time.Now()
+	data := &ResponseData{
+		StatusCode: resp.StatusCode,
+		Headers:    t.sanitizeHeaders(resp.Headers),
+	}
+	if isTextContent(resp.Headers["Content-Type"]) {
+		data.Body = string(resp.Body)
+	} else {
+		data.Body = base64.StdEncoding.EncodeToString(resp.Body)
+		data.BodyEncoding = "base64"
+	}
+	if t.attester != nil {
+		t.mu.Lock()
+		bridgeID := t.bridgeID
+		t.mu.Unlock()
+		a := t.attester.Attest(body, resp.Body, history.ModelFromBody(body), bridgeID)
+		data.Attestation = &a
+	}
+	timing.SerializeMs = time.Since(serStart).Milliseconds()
+	timing.TotalMs = time.Since(handleStart).Milliseconds()
+	data.Timing = timing
+	t.dedupe.finish(id, data)
+	t.sendResponse(id, data)
+}
+
+// handleRetrieve answers /api/retrieve from the local document index
+// instead of forwarding to Ollama.
+func (t *Tunnel) handleRetrieve(ctx context.Context, id string, body []byte) {
+	if t.rag == nil {
+		t.dedupe.fail(id)
+		t.sendError(id, http.StatusNotFound, "document retrieval is not enabled on this bridge")
+		return
+	}
+	var query struct {
+		Query string `json:"query"`
+		TopK  int    `json:"top_k"`
+	}
+	if err := json.Unmarshal(body, &query); err != nil || query.Query == "" {
+		t.dedupe.fail(id)
+		t.sendError(id, http.StatusBadRequest, "query is required")
+		return
+	}
+	results, err := t.rag.Retrieve(ctx, query.Query, query.TopK)
+	if err != nil {
+		slog.Error("Retrieval failed", "id", id, "error", err)
+		t.recordError(fmt.Sprintf("retrieve: %v", err))
+		t.dedupe.fail(id)
+		t.sendError(id, http.StatusInternalServerError, "retrieval failed")
+		return
+	}
+	respBody, _ := json.Marshal(map[string]any{"results": results})
+	t.countRequest(len(respBody))
+	data := &ResponseData{
+		StatusCode: http.StatusOK,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(respBody),
+	}
+	t.dedupe.finish(id, data)
+	t.sendResponse(id, data)
+}
+
+// isTextContent reports whether a response body can travel as plain text
+// in the JSON envelope; anything else is base64-encoded.
+func isTextContent(contentType string) bool {
+	ct := strings.ToLower(contentType)
+	if i := strings.IndexByte(ct, ';'); i >= 0 {
+		ct = ct[:i]
+	}
+	ct = strings.TrimSpace(ct)
+	switch {
+	case ct == "", strings.HasPrefix(ct, "text/"):
+		return true
+	case ct == "application/json", ct == "application/x-ndjson",
+		ct == "application/xml", ct == "application/javascript":
+		return true
+	case strings.HasSuffix(ct, "+json"):
+		return true
+	}
+	return false
+}
+
+// forwardHeaders merges the sanitized relay-supplied headers with the
+// configured User-Agent, extra headers, client attribution headers and
+// headers.request_set overrides.
+func (t *Tunnel) forwardHeaders(req *RequestData) map[string]string {
+	incoming := t.sanitizeRequestHeaders(req.Headers)
+	headers := make(map[string]string, len(incoming)+len(t.cfg.Ollama.ExtraHeaders)+3)
+	for k, v := range incoming {
+		headers[k] = v
+	}
+	for k, v := range t.cfg.Ollama.ExtraHeaders {
+		headers[k] = v
+	}
+	if t.cfg.Ollama.UserAgent != "" {
+		headers["User-Agent"] = t.cfg.Ollama.UserAgent
+	}
+	if t.cfg.Ollama.ForwardClientInfo {
+		if req.ClientIP != "" {
+			headers["X-Forwarded-For"] = req.ClientIP
+		}
+		if req.UserID != "" {
+			headers["X-CloudToLocalLLM-User"] = req.UserID
+		}
+	}
+	for k, v := range t.cfg.Headers.RequestSet {
+		headers[k] = v
+	}
+	return headers
+}
+
+// streamOllamaRequest pipes Ollama's NDJSON response directly into
+// WebSocket frames: a stream_start with status and headers, one
+// stream_chunk per line, and a closing stream_end. Chunk envelopes are
+// assembled in a pooled buffer instead of per-chunk Message structs.
+func (t *Tunnel) streamOllamaRequest(ctx context.Context, id string, req *RequestData, headers map[string]string, body []byte, timing *TimingData) {
+	start := time.Now()
+	status := http.StatusOK
+
+	// Transcript capture reassembles the generated text from the stream's
+	// NDJSON deltas, bounded by maxTranscriptResponse.
+	var response strings.Builder
+	streamed := 0
+
+	// Attestations hash the streamed body incrementally; the relay
+	// reassembles chunks in order, so the digest covers what the client
+	// saw. Text chunks are hashed with the newline separator the NDJSON
+	// stream carried.
+	var bodyHash hash.Hash
+	if t.attester != nil {
+		bodyHash = sha256.New()
+	}
+
+	// Credit-based flow control: with a non-zero window the bridge may
+	// only send that many chunks until the relay grants more, bounding
+	// buffering when the cloud client drains slowly.
+	credits := req.Window
+	var creditCh chan int
+	if req.Window > 0 {
+		creditCh = make(chan int, 4)
+		t.mu.Lock()
+		t.streamCredits[id] = creditCh
+		t.mu.Unlock()
+		defer func() {
+			t.mu.Lock()
+			delete(t.streamCredits, id)
+			t.mu.Unlock()
+		}()
+	}
+
+	backend, fwdPath := t.backendFor(req.Path)
+	err := backend.ForwardStream(ctx, req.Method, fwdPath, headers, body,
+		func(s int, headers map[string]string) {
+			status = s
+			timing.TTFBMs = time.Since(start).Milliseconds()
+			raw, _ := json.Marshal(StreamStartData{StatusCode: s, Headers: t.sanitizeHeaders(headers)})
+			t.sendMessage(&Message{Type: TypeStreamStart, ID: id, Data: raw})
+		},
+		func(chunk []byte, binary bool) error {
+			if creditCh != nil {
+				for credits <= 0 {
+					select {
+					case n := <-creditCh:
+						credits += n
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				}
+				credits--
+			}
+			streamed += len(chunk)
+			if bodyHash != nil {
+				bodyHash.Write(chunk)
+				if !binary {
+					bodyHash.Write([]byte{'\n'})
+				}
+			}
+			if binary {
+				if !t.relayCapable(CapBinary) {
+					return fmt.Errorf("relay does not support binary streams")
+				}
+				return t.sendBinaryChunk(id, chunk)
+			}
+			if t.transcripts != nil && response.Len() < maxTranscriptResponse {
+				response.WriteString(transcript.Delta(chunk))
+			}
+			return t.sendChunk(id, chunk)
+		})
+	if err != nil {
+		slog.Error("Streaming request failed", "id", id, "path", req.Path, "error", err)
+		t.recordError(fmt.Sprintf("stream %s: %v", req.Path, err))
+		t.recordHistory(id, req, body, http.StatusBadGateway, start)
+		t.dedupe.fail(id)
+		if status == http.StatusOK && t.wakeOllamaHost() {
+			t.sendError(id, http.StatusServiceUnavailable, "Ollama host is warming up; retry shortly")
+			return
+		}
+		t.sendError(id, http.StatusBadGateway, "local Ollama stream failed")
+		return
+	}
+	t.countRequest(streamed)
+	t.recordHistory(id, req, body, status, start)
+	if status < 400 {
+		t.recordTranscript(id, req, body, response.String(), start)
+	}
+	// Streams cannot be replayed from cache; drop the dedupe entry so a
+	// retransmit after completion runs afresh.
+	t.dedupe.finish(id, nil)
+	elapsed := time.Since(start).Milliseconds()
+	timing.GenerateMs = elapsed - timing.TTFBMs
+	timing.TotalMs = timing.QueueMs + elapsed
+	endData := StreamEndData{Timing: timing}
+	if bodyHash != nil {
+		t.mu.Lock()
+		bridgeID := t.bridgeID
+		t.mu.Unlock()
+		reqSum := sha256.Sum256(body)
+		a := t.attester.AttestDigests(hex.EncodeToString(reqSum[:]),
+			hex.EncodeToString(bodyHash.Sum(nil)), history.ModelFromBody(body), bridgeID)
+		endData.Attestation = &a
+	}
+	end := &Message{Type: TypeStreamEnd, ID: id}
+	end.Data, _ = json.Marshal(endData)
+	t.sendMessage(end)
+}
+
+// sendChunk writes one stream_chunk frame. Ollama NDJSON lines are
+// complete JSON values and are embedded into the envelope as-is; SSE
+// lines ("data: {...}", "data: [DONE]") and plain-text bodies are not,
+// so anything else is embedded as a JSON string to keep the frame
+// parseable.
+func (t *Tunnel) sendChunk(id string, chunk []byte) error {
+	buf := bufpool.Get()
+	defer bufpool.Put(buf)
+	buf.WriteString(`{"type":"stream_chunk","id":"`)
+	buf.WriteString(id)
+	buf.WriteString(`","data":{"chunk":`)
+	if json.Valid(chunk) {
+		buf.Write(chunk)
+	} else {
+		quoted, _ := json.Marshal(string(chunk))
+		buf.Write(quoted)
+	}
+	buf.WriteString(`},"timestamp":"`)
+	buf.WriteString(time.Now().UTC().Format(time.RFC3339))
+	buf.WriteString(`"}`)
+	return t.sendRaw(buf.Bytes(), prioStream)
+}
+
+// sendBinaryChunk writes one stream_chunk frame carrying binary data
+// (streamed TTS audio) base64-encoded, marked with an encoding field so
+// the relay can decode it before handing it to the client.
+func (t *Tunnel) sendBinaryChunk(id string, chunk []byte) error {
+	buf := bufpool.Get()
+	defer bufpool.Put(buf)
+	buf.WriteString(`{"type":"stream_chunk","id":"`)
+	buf.WriteString(id)
+	buf.WriteString(`","data":{"chunk":"`)
+	buf.WriteString(base64.StdEncoding.EncodeToString(chunk))
+	buf.WriteString(`","encoding":"base64"},"timestamp":"`)
+	buf.WriteString(time.Now().UTC().Format(time.RFC3339))
+	buf.WriteString(`"}`)
+	return t.sendRaw(buf.Bytes(), prioStream)
+}
+
+// sendRaw hands one pre-encoded message frame to the connection's
+// writer at the given priority. The frame is copied because callers
+// reuse pooled buffers while it sits in the queue.
+func (t *Tunnel) sendRaw(frame []byte, prio int) error {
+	frame = t.chaosCorrupt(frame)
+	t.mu.Lock()
+	w := t.writer
+	t.mu.Unlock()
+	if w == nil {
+		return fmt.Errorf("tunnel not connected")
+	}
+	return w.enqueue(append([]byte(nil), frame...), prio)
+}
+
+// recordHistory persists request metadata (and optionally the body) when
+// the history store is configured.
+func (t *Tunnel) recordHistory(id string, req *RequestData, body []byte, status int, start time.Time) {
+	if t.history == nil {
+		return
+	}
+	entry := history.Entry{
+		ID:         id,
+		Timestamp:  start,
+		Method:     req.Method,
+		Path:       req.Path,
+		Model:      history.ModelFromBody(body),
+		UserID:     req.UserID,
+		StatusCode: status,
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+	if t.cfg.History.RecordBodies {
+		entry.Body = string(body)
+	}
+	if err := t.history.Record(entry); err != nil {
+		slog.Warn("Recording request history failed", "id", id, "error", err)
+	}
+}
+
+// maxTranscriptResponse bounds one captured response; anything past this
+// is truncated rather than growing the database without limit.
+const maxTranscriptResponse = 1 << 20
+
+// recordTranscript captures one completed generation exchange when the
+// transcript store is configured. Non-generation paths (model listings,
+// embeddings) are skipped.
+func (t *Tunnel) recordTranscript(id string, req *RequestData, body []byte, response string, start time.Time) {
+	if t.transcripts == nil || response == "" {
+		return
+	}
+	switch req.Path {
+	case "/api/generate", "/api/chat", "/v1/chat/completions", "/v1/completions":
+	default:
+		return
+	}
+	err := t.transcripts.Record(transcript.Entry{
+		ID:        id,
+		Timestamp: start,
+		Model:     history.ModelFromBody(body),
+		UserID:    req.UserID,
+		Prompt:    transcript.PromptFromBody(body),
+		Response:  response,
+	})
+	if err != nil {
+		slog.Warn("Recording transcript failed", "id", id, "error", err)
+	}
+}
+
+func (t *Tunnel) sendResponse(id string, data *ResponseData) {
+	if t.claimBatchResponse(id, data) {
+		return
+	}
+	if t.finishJob(id, data) {
+		return
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		slog.Error("Encoding response failed", "id", id, "error", err)
+		return
+	}
+	t.sendMessage(&Message{Type: TypeResponse, ID: id, Data: raw})
+}
+
+func (t *Tunnel) sendError(id string, status int, message string) {
+	body, _ := json.Marshal(map[string]string{"error": message})
+	t.sendResponse(id, &ResponseData{
+		StatusCode: status,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(body),
+	})
+}
+
+// sendMessage writes one message to the WebSocket. The relay expects
+// each message to carry an ID and timestamp. Control-plane types are
+// tagged with ChannelControl and take the priority write path.
+func (t *Tunnel) sendMessage(msg *Message) {
+	if msg.ID == "" {
+		msg.ID = uuid.NewString()
+	}
+	msg.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	prio := prioBulk
+	switch {
+	case controlType(msg.Type):
+		msg.Channel = ChannelControl
+		prio = prioControl
+	case msg.Type == TypeStreamStart, msg.Type == TypeStreamEnd:
+		// Keep stream framing ordered with the chunks between them.
+		prio = prioStream
+	}
+	if t.sendHook != nil {
+		t.sendHook(msg)
+		return
+	}
+	frame, err := json.Marshal(msg)
+	if err != nil {
+		slog.Error("Encoding tunnel message failed", "type", msg.Type, "error", err)
+		return
+	}
+	if err := t.sendRaw(frame, prio); err != nil {
+		slog.Error("Writing tunnel message failed", "type", msg.Type, "error", err)
+	}
+}
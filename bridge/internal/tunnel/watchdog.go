@@ -0,0 +1,115 @@
+package tunnel
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// The watchdog scans in-flight requests every watchdogInterval for ones
+// older than stuckMultiplier times the configured Ollama timeout.
+// Context cancellation should have ended them long before that; hitting
+// the ceiling means a hung Ollama connection that ignores cancel.
+const (
+	watchdogInterval = 30 * time.Second
+	stuckMultiplier  = 3
+	// watchdogRestartCooldown rate-limits ollama.restart_command so a
+	// persistently wedged server is not restarted in a tight loop.
+	watchdogRestartCooldown = 5 * time.Minute
+)
+
+// runWatchdog recovers from stuck requests: it dumps goroutine stacks
+// for diagnosis, force-cancels the requests, resets the Ollama
+// connection pool and, when ollama.restart_command is configured,
+// restarts the managed Ollama process. Disabled without an Ollama
+// timeout, since there is no ceiling to enforce.
+func (t *Tunnel) runWatchdog(ctx context.Context) {
+	if t.cfg.Ollama.Timeout <= 0 {
+		return
+	}
+	ceiling := stuckMultiplier * t.cfg.Ollama.Timeout
+	ticker := time.NewTicker(watchdogInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.recoverStuck(ceiling)
+		}
+	}
+}
+
+func (t *Tunnel) recoverStuck(ceiling time.Duration) {
+	now := time.Now()
+	t.mu.Lock()
+	var stuck []*inflightRequest
+	var ids []string
+	for id, r := range t.inflightReqs {
+		if now.Sub(r.started) > ceiling {
+			stuck = append(stuck, r)
+			ids = append(ids, id)
+		}
+	}
+	restart := len(stuck) > 0 && t.cfg.Ollama.RestartCommand != "" &&
+		now.Sub(t.lastWatchdogRestart) > watchdogRestartCooldown
+	if restart {
+		t.lastWatchdogRestart = now
+	}
+	t.mu.Unlock()
+	if len(stuck) == 0 {
+		return
+	}
+
+	slog.Error("Watchdog found stuck requests past the hard ceiling",
+		"count", len(stuck), "ceiling", ceiling, "stacks", t.dumpStacks())
+	for i, r := range stuck {
+		slog.Error("Watchdog cancelling stuck request",
+			"id", ids[i], "path", r.path, "user", r.userID, "age", now.Sub(r.started).Round(time.Second))
+		r.cancel()
+		t.recordError("watchdog cancelled stuck request " + r.path)
+	}
+	t.ollamaClient().CloseIdleConnections()
+
+	if restart {
+		go t.restartOllama()
+	}
+}
+
+// dumpStacks writes all goroutine stacks to a file in the data
+// directory and returns its path, so a hung forward can be diagnosed
+// without flooding the log buffer.
+func (t *Tunnel) dumpStacks() string {
+	buf := make([]byte, 1<<20)
+	n := runtime.Stack(buf, true)
+	dir, err := t.cfg.DataDir()
+	if err != nil {
+		return "(no data dir: " + err.Error() + ")"
+	}
+	path := filepath.Join(dir, "watchdog-stacks-"+time.Now().Format("20060102-150405")+".txt")
+	if err := os.WriteFile(path, buf[:n], 0o600); err != nil {
+		return "(writing stack dump failed: " + err.Error() + ")"
+	}
+	return path
+}
+
+// restartOllama runs the configured restart command through the shell.
+func (t *Tunnel) restartOllama() {
+	cmdLine := t.cfg.Ollama.RestartCommand
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/c", cmdLine)
+	} else {
+		cmd = exec.Command("sh", "-c", cmdLine)
+	}
+	slog.Warn("Watchdog restarting Ollama", "command", cmdLine)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		slog.Error("Ollama restart command failed", "error", err, "output", string(out))
+	} else {
+		slog.Info("Ollama restart command completed")
+	}
+}
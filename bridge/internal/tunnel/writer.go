@@ -0,0 +1,133 @@
+package tunnel
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gorilla/websocket"
+)
+
+// Write priorities for the outbound queue; lower drains first. Control
+// keeps heartbeats and credits flowing, stream keeps in-flight NDJSON
+// chunks ordered ahead of whole-response bodies.
+const (
+	prioControl = iota
+	prioStream
+	prioBulk
+)
+
+// wsWriter owns every write to one WebSocket connection. Senders
+// enqueue pre-encoded frames by priority and a single goroutine drains
+// them — control first, then stream chunks, then bulk — which both
+// satisfies gorilla/websocket's single-writer requirement and stops one
+// giant response body from starving pings into false disconnects.
+type wsWriter struct {
+	conn    *websocket.Conn
+	control chan []byte
+	stream  chan []byte
+	bulk    chan []byte
+	// drop discards stream/bulk frames when their queue is full instead
+	// of blocking the sender; see cloud.write_overflow.
+	drop   bool
+	done   chan struct{}
+	once   sync.Once
+	failed atomic.Bool
+}
+
+func newWSWriter(conn *websocket.Conn, queueSize int, drop bool) *wsWriter {
+	if queueSize <= 0 {
+		queueSize = 64
+	}
+	w := &wsWriter{
+		conn:    conn,
+		control: make(chan []byte, queueSize),
+		stream:  make(chan []byte, queueSize),
+		bulk:    make(chan []byte, queueSize),
+		drop:    drop,
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+func (w *wsWriter) run() {
+	for {
+		// Drain any waiting control frame before looking lower.
+		select {
+		case f := <-w.control:
+			w.write(f)
+			continue
+		default:
+		}
+		select {
+		case f := <-w.control:
+			w.write(f)
+			continue
+		case f := <-w.stream:
+			w.write(f)
+			continue
+		default:
+		}
+		select {
+		case f := <-w.control:
+			w.write(f)
+		case f := <-w.stream:
+			w.write(f)
+		case f := <-w.bulk:
+			w.write(f)
+		case <-w.done:
+			return
+		}
+	}
+}
+
+func (w *wsWriter) write(frame []byte) {
+	if w.failed.Load() {
+		return
+	}
+	if err := w.conn.WriteMessage(websocket.TextMessage, frame); err != nil {
+		// The reader loop sees the same broken connection and triggers
+		// the reconnect; just stop accepting frames.
+		w.failed.Store(true)
+	}
+}
+
+// enqueue queues one frame at the given priority. Control frames always
+// wait for room; stream and bulk frames honor the overflow policy.
+func (w *wsWriter) enqueue(frame []byte, prio int) error {
+	if w.failed.Load() {
+		return fmt.Errorf("connection write failed")
+	}
+	var ch chan []byte
+	switch prio {
+	case prioControl:
+		ch = w.control
+	case prioStream:
+		ch = w.stream
+	default:
+		ch = w.bulk
+	}
+	if w.drop && prio != prioControl {
+		select {
+		case ch <- frame:
+			return nil
+		case <-w.done:
+			return fmt.Errorf("tunnel not connected")
+		default:
+			return fmt.Errorf("write queue full; frame dropped")
+		}
+	}
+	select {
+	case ch <- frame:
+		return nil
+	case <-w.done:
+		return fmt.Errorf("tunnel not connected")
+	}
+}
+
+// close stops the writer goroutine; queued frames are discarded along
+// with the connection.
+func (w *wsWriter) close() {
+	w.once.Do(func() { close(w.done) })
+}
@@ -0,0 +1,38 @@
+// Package wol sends Wake-on-LAN magic packets so the bridge can wake a
+// separate GPU host running Ollama before forwarding requests to it.
+package wol
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+)
+
+// Send broadcasts a magic packet for the given hardware address on UDP
+// port 9.
+func Send(mac string) error {
+	hw, err := net.ParseMAC(mac)
+	if err != nil {
+		return fmt.Errorf("parsing wake MAC: %w", err)
+	}
+	if len(hw) != 6 {
+		return fmt.Errorf("wake MAC %q is not a 6-byte ethernet address", mac)
+	}
+
+	// A magic packet is six 0xFF bytes followed by the target address
+	// repeated sixteen times.
+	packet := bytes.Repeat([]byte{0xFF}, 6)
+	for i := 0; i < 16; i++ {
+		packet = append(packet, hw...)
+	}
+
+	conn, err := net.Dial("udp", "255.255.255.255:9")
+	if err != nil {
+		return fmt.Errorf("opening broadcast socket: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write(packet); err != nil {
+		return fmt.Errorf("sending magic packet: %w", err)
+	}
+	return nil
+}
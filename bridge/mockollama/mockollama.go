@@ -0,0 +1,159 @@
+// Package mockollama serves canned Ollama API responses so the bridge,
+// relay and frontend can be exercised on machines without a GPU or an
+// Ollama installation. Like mockrelay it is exported for use from other
+// repositories' integration tests.
+package mockollama
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Server implements a minimal subset of the Ollama HTTP API: /api/tags,
+// /api/version, /api/generate and /api/chat (both streaming and not).
+type Server struct {
+	// Models are the names reported by /api/tags (default: one canned
+	// model).
+	Models []string
+	// Reply is the canned completion text, streamed word by word.
+	Reply string
+	// ChunkDelay inserts a pause between streamed chunks to mimic
+	// generation latency; zero streams as fast as possible.
+	ChunkDelay time.Duration
+}
+
+// New returns a Server with default canned data.
+func New() *Server {
+	return &Server{
+		Models: []string{"llama3:8b"},
+		Reply:  "This is a canned response from the CloudToLocalLLM mock Ollama server.",
+	}
+}
+
+// Handler returns the mock API surface.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/tags", s.handleTags)
+	mux.HandleFunc("/api/version", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{"version": "0.0.0-mock"})
+	})
+	mux.HandleFunc("/api/generate", s.handleGenerate)
+	mux.HandleFunc("/api/chat", s.handleChat)
+	return mux
+}
+
+func (s *Server) handleTags(w http.ResponseWriter, r *http.Request) {
+	models := make([]map[string]any, 0, len(s.Models))
+	for _, name := range s.Models {
+		models = append(models, map[string]any{
+			"name":        name,
+			"model":       name,
+			"modified_at": time.Now().UTC().Format(time.RFC3339),
+			"size":        4 << 30,
+		})
+	}
+	writeJSON(w, map[string]any{"models": models})
+}
+
+// completionRequest covers the fields of /api/generate and /api/chat the
+// mock cares about. Stream defaults to true, matching Ollama.
+type completionRequest struct {
+	Model  string `json:"model"`
+	Stream *bool  `json:"stream"`
+}
+
+func (r *completionRequest) streaming() bool {
+	return r.Stream == nil || *r.Stream
+}
+
+func (s *Server) handleGenerate(w http.ResponseWriter, r *http.Request) {
+	s.complete(w, r, func(model, word string, done bool) map[string]any {
+		out := map[string]any{
+			"model":      model,
+			"created_at": time.Now().UTC().Format(time.RFC3339),
+			"response":   word,
+			"done":       done,
+		}
+		if done {
+			out["eval_count"] = len(strings.Fields(s.Reply))
+		}
+		return out
+	})
+}
+
+func (s *Server) handleChat(w http.ResponseWriter, r *http.Request) {
+	s.complete(w, r, func(model, word string, done bool) map[string]any {
+		return map[string]any{
+			"model":      model,
+			"created_at": time.Now().UTC().Format(time.RFC3339),
+			"message":    map[string]string{"role": "assistant", "content": word},
+			"done":       done,
+		}
+	})
+}
+
+// complete writes the canned reply, either as NDJSON word chunks plus a
+// final done line, or as one buffered object.
+func (s *Server) complete(w http.ResponseWriter, r *http.Request,
+	chunk func(model, word string, done bool) map[string]any) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req completionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	model := req.Model
+	if model == "" {
+		writeError(w, http.StatusBadRequest, "model is required")
+		return
+	}
+	if !s.hasModel(model) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("model %q not found", model))
+		return
+	}
+
+	if !req.streaming() {
+		writeJSON(w, chunk(model, s.Reply, true))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	for _, word := range strings.Fields(s.Reply) {
+		enc.Encode(chunk(model, word+" ", false))
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if s.ChunkDelay > 0 {
+			time.Sleep(s.ChunkDelay)
+		}
+	}
+	enc.Encode(chunk(model, "", true))
+}
+
+func (s *Server) hasModel(name string) bool {
+	for _, m := range s.Models {
+		if m == name {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
@@ -0,0 +1,54 @@
+package mockollama
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGenerateStreaming(t *testing.T) {
+	srv := New()
+	srv.Reply = "one two three"
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL+"/api/generate", "application/json",
+		strings.NewReader(`{"model":"llama3:8b","prompt":"hi"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var lines int
+	var sawDone bool
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var chunk struct {
+			Done bool `json:"done"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &chunk); err != nil {
+			t.Fatalf("bad chunk %q: %v", scanner.Text(), err)
+		}
+		lines++
+		sawDone = chunk.Done
+	}
+	if lines != 4 || !sawDone {
+		t.Fatalf("expected 3 word chunks + done, got %d lines (done=%v)", lines, sawDone)
+	}
+}
+
+func TestGenerateUnknownModel(t *testing.T) {
+	ts := httptest.NewServer(New().Handler())
+	defer ts.Close()
+	resp, err := http.Post(ts.URL+"/api/generate", "application/json",
+		strings.NewReader(`{"model":"nope"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown model, got %d", resp.StatusCode)
+	}
+}
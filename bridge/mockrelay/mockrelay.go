@@ -0,0 +1,278 @@
+// Package mockrelay implements the cloud relay's bridge WebSocket
+// contract in-process, so bridge end-to-end tests and local development
+// do not need the real cloud service. It is exported deliberately: CI
+// jobs and other repositories' integration tests may import it.
+package mockrelay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// Request mirrors the relay's "request" message payload.
+type Request struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+	UserID  string            `json:"userId,omitempty"`
+	Stream  bool              `json:"stream,omitempty"`
+	Window  int               `json:"window,omitempty"`
+}
+
+// Response mirrors the bridge's "response" message payload. For streamed
+// requests the chunk lines are joined with newlines into Body.
+type Response struct {
+	StatusCode int               `json:"statusCode"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Body       string            `json:"body,omitempty"`
+}
+
+// message is the wire envelope; see api-backend/server.js.
+type message struct {
+	Type      string          `json:"type"`
+	ID        string          `json:"id"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	Timestamp string          `json:"timestamp,omitempty"`
+}
+
+// pendingRequest collects the bridge's reply to one forwarded request.
+type pendingRequest struct {
+	done   chan *Response
+	chunks []string
+	status int
+	header map[string]string
+}
+
+// Server is an in-process mock relay. Zero value is not usable; call New.
+type Server struct {
+	// PingInterval between relay heartbeats (default 30s, lower in tests).
+	PingInterval time.Duration
+
+	upgrader websocket.Upgrader
+
+	mu       sync.Mutex
+	conn     *websocket.Conn
+	bridgeID string
+	pending  map[string]*pendingRequest
+}
+
+// New returns a mock relay ready to accept one bridge connection.
+func New() *Server {
+	return &Server{
+		PingInterval: 30 * time.Second,
+		bridgeID:     "mock-" + uuid.NewString()[:8],
+		pending:      make(map[string]*pendingRequest),
+	}
+}
+
+// Handler returns the relay's HTTP surface: /ws/bridge, the registration
+// endpoint, /health, and POST /forward for driving requests externally.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws/bridge", s.handleWS)
+	mux.HandleFunc("/ollama/bridge/register", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/forward", s.handleForward)
+	return mux
+}
+
+// Connected reports whether a bridge is currently attached.
+func (s *Server) Connected() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn != nil
+}
+
+func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("token") == "" {
+		http.Error(w, "missing token", http.StatusUnauthorized)
+		return
+	}
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.conn = conn
+	s.mu.Unlock()
+	slog.Info("Mock relay: bridge connected", "remote", r.RemoteAddr)
+
+	authData, _ := json.Marshal(map[string]any{"success": true, "bridgeId": s.bridgeID})
+	s.send(&message{Type: "auth", Data: authData})
+
+	done := make(chan struct{})
+	go s.pingLoop(done)
+	defer close(done)
+
+	for {
+		var msg message
+		if err := conn.ReadJSON(&msg); err != nil {
+			slog.Info("Mock relay: bridge disconnected", "error", err)
+			s.mu.Lock()
+			if s.conn == conn {
+				s.conn = nil
+			}
+			s.mu.Unlock()
+			return
+		}
+		s.dispatch(&msg)
+	}
+}
+
+func (s *Server) pingLoop(done <-chan struct{}) {
+	ticker := time.NewTicker(s.PingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			s.send(&message{Type: "ping"})
+		}
+	}
+}
+
+// dispatch routes one bridge message to the waiting Forward call.
+func (s *Server) dispatch(msg *message) {
+	s.mu.Lock()
+	p := s.pending[msg.ID]
+	s.mu.Unlock()
+	if p == nil {
+		return
+	}
+	switch msg.Type {
+	case "response":
+		var resp Response
+		if err := json.Unmarshal(msg.Data, &resp); err != nil {
+			return
+		}
+		s.finish(msg.ID, &resp)
+	case "stream_start":
+		var start struct {
+			StatusCode int               `json:"statusCode"`
+			Headers    map[string]string `json:"headers"`
+		}
+		if err := json.Unmarshal(msg.Data, &start); err != nil {
+			return
+		}
+		s.mu.Lock()
+		p.status = start.StatusCode
+		p.header = start.Headers
+		s.mu.Unlock()
+	case "stream_chunk":
+		var chunk struct {
+			Chunk json.RawMessage `json:"chunk"`
+		}
+		if err := json.Unmarshal(msg.Data, &chunk); err != nil {
+			return
+		}
+		s.mu.Lock()
+		p.chunks = append(p.chunks, string(chunk.Chunk))
+		s.mu.Unlock()
+	case "stream_end":
+		s.mu.Lock()
+		resp := &Response{
+			StatusCode: p.status,
+			Headers:    p.header,
+			Body:       strings.Join(p.chunks, "\n"),
+		}
+		s.mu.Unlock()
+		s.finish(msg.ID, resp)
+	}
+}
+
+func (s *Server) finish(id string, resp *Response) {
+	s.mu.Lock()
+	p := s.pending[id]
+	delete(s.pending, id)
+	s.mu.Unlock()
+	if p != nil {
+		p.done <- resp
+	}
+}
+
+// Forward sends one request through the connected bridge and waits for
+// the full response (streamed chunks are reassembled).
+func (s *Server) Forward(ctx context.Context, req *Request) (*Response, error) {
+	s.mu.Lock()
+	if s.conn == nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("no bridge connected")
+	}
+	id := uuid.NewString()
+	p := &pendingRequest{done: make(chan *Response, 1)}
+	s.pending[id] = p
+	s.mu.Unlock()
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.send(&message{Type: "request", ID: id, Data: data}); err != nil {
+		s.finish(id, nil)
+		return nil, err
+	}
+	select {
+	case resp := <-p.done:
+		if resp == nil {
+			return nil, fmt.Errorf("request aborted")
+		}
+		return resp, nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		delete(s.pending, id)
+		s.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+func (s *Server) send(msg *message) error {
+	if msg.ID == "" {
+		msg.ID = uuid.NewString()
+	}
+	msg.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return fmt.Errorf("no bridge connected")
+	}
+	return s.conn.WriteJSON(msg)
+}
+
+// handleForward drives one tunneled request over HTTP, for curl-based
+// end-to-end testing against `bridge mock-relay`.
+func (s *Server) handleForward(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	resp, err := s.Forward(r.Context(), &req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,62 @@
+package mockrelay
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeBridge connects like the real bridge and echoes requests back.
+func fakeBridge(t *testing.T, wsURL string) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL+"?token=test", nil)
+	if err != nil {
+		t.Fatalf("dialing mock relay: %v", err)
+	}
+	var auth message
+	if err := conn.ReadJSON(&auth); err != nil || auth.Type != "auth" {
+		t.Fatalf("expected auth message, got %v (err %v)", auth.Type, err)
+	}
+	return conn
+}
+
+func TestForwardRoundTrip(t *testing.T) {
+	srv := New()
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws/bridge"
+	conn := fakeBridge(t, wsURL)
+	defer conn.Close()
+
+	go func() {
+		var msg message
+		if err := conn.ReadJSON(&msg); err != nil || msg.Type != "request" {
+			return
+		}
+		data, _ := json.Marshal(Response{StatusCode: 200, Body: "pong"})
+		conn.WriteJSON(message{Type: "response", ID: msg.ID, Data: data})
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := srv.Forward(ctx, &Request{Method: "GET", Path: "/api/tags"})
+	if err != nil {
+		t.Fatalf("Forward: %v", err)
+	}
+	if resp.StatusCode != 200 || resp.Body != "pong" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+}
+
+func TestForwardWithoutBridge(t *testing.T) {
+	srv := New()
+	if _, err := srv.Forward(context.Background(), &Request{}); err == nil {
+		t.Fatal("expected error with no bridge connected")
+	}
+}